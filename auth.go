@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+)
+
+// authTokens returns the bearer tokens accepted on /mcp. Several tokens can be
+// active at once so a new one can be rolled out before the old is removed.
+func authTokens(cfg *config.Config) []string {
+	var tokens []string
+	if cfg.MCPAuthToken != "" {
+		tokens = append(tokens, cfg.MCPAuthToken)
+	}
+	for _, t := range cfg.MCPAuthTokens {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// requireBearer rejects requests whose Authorization header does not carry one
+// of tokens. With no tokens configured it returns next unchanged.
+func requireBearer(tokens []string, next http.Handler) http.Handler {
+	if len(tokens) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !validBearer(r.Header.Get("Authorization"), tokens) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}` + "\n"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validBearer compares the presented token against every configured token in
+// constant time, so neither the match position nor a prefix leaks via timing.
+func validBearer(header string, tokens []string) bool {
+	scheme, presented, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	presented = strings.TrimSpace(presented)
+	if presented == "" {
+		return false
+	}
+	match := 0
+	for _, t := range tokens {
+		match |= subtle.ConstantTimeCompare([]byte(presented), []byte(t))
+	}
+	return match == 1
+}
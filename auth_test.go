@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireBearer(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := requireBearer([]string{"old-token", "new-token"}, ok)
+
+	cases := []struct {
+		header string
+		code   int
+	}{
+		{"Bearer new-token", http.StatusNoContent},
+		{"bearer old-token", http.StatusNoContent},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer ", http.StatusUnauthorized},
+		{"Basic new-token", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Fatalf("%q: status %d, want %d", tc.header, rec.Code, tc.code)
+		}
+		if tc.code == http.StatusUnauthorized && rec.Body.String() != "{\"error\":\"unauthorized\"}\n" {
+			t.Fatalf("%q: unexpected body %q", tc.header, rec.Body.String())
+		}
+	}
+}
+
+func TestRequireBearerDisabledWithoutTokens(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	rec := httptest.NewRecorder()
+	requireBearer(nil, ok).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status %d", rec.Code)
+	}
+}
@@ -40,12 +40,15 @@ func main() {
 		log.Fatalf("config error: %v", err)
 	}
 
-	surrealClient, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB)
+	surrealClient, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB, time.Duration(cfg.SurrealConnectTimeoutMS)*time.Millisecond)
 	if err != nil {
 		log.Fatalf("surreal client: %v", err)
 	}
 
-	embedClient := embedder.New(cfg.EmbedURL, cfg.EmbedModel)
+	embedClient, err := embedder.NewFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("embedder init: %v", err)
+	}
 
 	s := &tools.WorkspaceVectorSearch{DB: surrealClient, Embedder: embedClient}
 
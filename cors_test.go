@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := []struct {
+		name    string
+		origins []string
+		method  string
+		origin  string
+		code    int
+		allow   string
+	}{
+		{"disabled", nil, http.MethodPost, "https://app.example", http.StatusOK, ""},
+		{"listed origin", []string{"https://app.example"}, http.MethodPost, "https://app.example", http.StatusOK, "https://app.example"},
+		{"unlisted origin", []string{"https://app.example"}, http.MethodPost, "https://evil.example", http.StatusOK, ""},
+		{"wildcard", []string{"*"}, http.MethodPost, "https://any.example", http.StatusOK, "*"},
+		{"preflight", []string{"https://app.example"}, http.MethodOptions, "https://app.example", http.StatusNoContent, "https://app.example"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/mcp", nil)
+		req.Header.Set("Origin", tc.origin)
+		rec := httptest.NewRecorder()
+		withCORS(tc.origins, next).ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Fatalf("%s: status %d, want %d", tc.name, rec.Code, tc.code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tc.allow {
+			t.Fatalf("%s: allow origin %q, want %q", tc.name, got, tc.allow)
+		}
+		if tc.allow != "" && rec.Header().Get("Access-Control-Allow-Headers") == "" {
+			t.Fatalf("%s: missing allow headers", tc.name)
+		}
+	}
+}
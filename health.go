@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds each dependency check in /readyz.
+const readyTimeout = 5 * time.Second
+
+// pinger is a dependency that can report whether it is reachable.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type healthStatus struct {
+	OK       bool   `json:"ok"`
+	Surreal  string `json:"surreal,omitempty"`
+	Embedder string `json:"embedder,omitempty"`
+}
+
+// healthz is the liveness probe; it succeeds whenever the process is serving.
+func healthz(w http.ResponseWriter, _ *http.Request) {
+	writeHealth(w, http.StatusOK, healthStatus{OK: true})
+}
+
+// readyz is the readiness probe; it succeeds only when SurrealDB and the
+// embedding executor both answer.
+func readyz(db, embed pinger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		status := healthStatus{OK: true, Surreal: "connected", Embedder: "ok"}
+		if err := db.Ping(ctx); err != nil {
+			status.OK = false
+			status.Surreal = err.Error()
+		}
+		if err := embed.Ping(ctx); err != nil {
+			status.OK = false
+			status.Embedder = err.Error()
+		}
+		code := http.StatusOK
+		if !status.OK {
+			code = http.StatusServiceUnavailable
+		}
+		writeHealth(w, code, status)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type pingFunc func(ctx context.Context) error
+
+func (f pingFunc) Ping(ctx context.Context) error { return f(ctx) }
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "{\"ok\":true}\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	up := pingFunc(func(context.Context) error { return nil })
+	down := pingFunc(func(context.Context) error { return errors.New("connection refused") })
+
+	cases := []struct {
+		name     string
+		db, emb  pinger
+		code     int
+		surreal  string
+		embedder string
+	}{
+		{"ready", up, up, http.StatusOK, "connected", "ok"},
+		{"surreal down", down, up, http.StatusServiceUnavailable, "connection refused", "ok"},
+		{"embedder down", up, down, http.StatusServiceUnavailable, "connected", "connection refused"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		readyz(tc.db, tc.emb)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != tc.code {
+			t.Fatalf("%s: status %d, want %d", tc.name, rec.Code, tc.code)
+		}
+		var got healthStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: decode: %v", tc.name, err)
+		}
+		if got.OK != (tc.code == http.StatusOK) || got.Surreal != tc.surreal || got.Embedder != tc.embedder {
+			t.Fatalf("%s: unexpected status %+v", tc.name, got)
+		}
+	}
+}
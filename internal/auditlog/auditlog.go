@@ -0,0 +1,177 @@
+// Package auditlog records MCP tool invocations as JSON lines.
+package auditlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
+)
+
+// bufferSize is how many entries may be queued before Log starts dropping.
+const bufferSize = 1000
+
+// redacted replaces the value of sensitive input fields before hashing.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are substrings that mark an input field as sensitive,
+// matched case-insensitively against the field name.
+var sensitiveKeys = []string{"password", "token", "key"}
+
+// Entry is one tool invocation.
+type Entry struct {
+	TS         time.Time `json:"ts"`
+	SessionID  string    `json:"sessionId"`
+	Tool       string    `json:"tool"`
+	InputHash  string    `json:"inputHash"`
+	DurationMS int64     `json:"durationMs"`
+	Acceptance string    `json:"acceptance"`
+	Error      string    `json:"error"`
+}
+
+// Logger writes entries on a background goroutine so callers never block on
+// disk I/O. A nil *Logger discards entries.
+type Logger struct {
+	w      io.Writer
+	closer io.Closer
+	logger *slog.Logger
+	ch     chan Entry
+	done   chan struct{}
+
+	mu     sync.Mutex // guards closed and sends on ch
+	closed bool
+}
+
+// Open appends entries to the file at path, creating it if needed.
+func Open(path string, logger *slog.Logger) (*Logger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log %s: %w", path, err)
+	}
+	l := New(f, logger)
+	l.closer = f
+	return l, nil
+}
+
+// New writes entries to w. A nil logger uses slog.Default().
+func New(w io.Writer, logger *slog.Logger) *Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	l := &Logger{
+		w:      w,
+		logger: logger,
+		ch:     make(chan Entry, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go l.drain()
+	return l
+}
+
+// Log queues e for writing. When the queue is full the entry is dropped and
+// counted in metrics.AuditDropped. Entries logged after Close, such as from
+// tool calls still finishing at shutdown, are dropped the same way.
+func (l *Logger) Log(e Entry) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		metrics.AuditDropped.Inc()
+		return
+	}
+	select {
+	case l.ch <- e:
+	default:
+		metrics.AuditDropped.Inc()
+	}
+}
+
+// Close flushes queued entries and closes the underlying file, if any. It is
+// safe to call while other goroutines are still logging.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		<-l.done
+		return nil
+	}
+	l.closed = true
+	close(l.ch)
+	l.mu.Unlock()
+	<-l.done
+	if l.closer != nil {
+		return l.closer.Close()
+	}
+	return nil
+}
+
+func (l *Logger) drain() {
+	defer close(l.done)
+	enc := json.NewEncoder(l.w)
+	for e := range l.ch {
+		if err := enc.Encode(e); err != nil {
+			l.logger.Warn("audit log write failed", "tool", e.Tool, "err", err)
+		}
+	}
+}
+
+// HashInput returns the hex sha256 of input encoded as JSON, with the values
+// of sensitive fields replaced by "[REDACTED]" first.
+func HashInput(input any) (string, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("encode input: %w", err)
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return "", fmt.Errorf("decode input: %w", err)
+	}
+	raw, err = json.Marshal(redact(generic))
+	if err != nil {
+		return "", fmt.Errorf("encode redacted input: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// redact replaces sensitive values anywhere in a decoded JSON value.
+func redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSensitive(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redact(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redact(val)
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
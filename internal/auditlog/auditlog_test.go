@@ -0,0 +1,85 @@
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHashInputRedactsSensitiveFields(t *testing.T) {
+	a, err := HashInput(map[string]any{"query": "q", "apiKey": "one", "nested": map[string]any{"Password": "x"}})
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	b, err := HashInput(map[string]any{"query": "q", "apiKey": "two", "nested": map[string]any{"Password": "y"}})
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if a != b {
+		t.Fatalf("sensitive values should not affect the hash")
+	}
+	c, _ := HashInput(map[string]any{"query": "other", "apiKey": "one", "nested": map[string]any{"Password": "x"}})
+	if a == c {
+		t.Fatalf("non-sensitive values should affect the hash")
+	}
+	if len(a) != 64 {
+		t.Fatalf("expected hex sha256, got %q", a)
+	}
+}
+
+func TestLoggerWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, nil)
+	l.Log(Entry{TS: time.Unix(0, 0).UTC(), SessionID: "s1", Tool: "node_list", DurationMS: 3, Acceptance: "pass"})
+	l.Log(Entry{TS: time.Unix(1, 0).UTC(), SessionID: "s1", Tool: "term_exec", Acceptance: "fail", Error: "boom"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	var got []Entry
+	for dec.More() {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].Tool != "node_list" || got[1].Error != "boom" {
+		t.Fatalf("unexpected entries %+v", got)
+	}
+}
+
+func TestNilLoggerDiscards(t *testing.T) {
+	var l *Logger
+	l.Log(Entry{Tool: "node_list"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestLogAfterCloseDoesNotPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Log(Entry{Tool: "term_exec"})
+			}
+		}()
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	wg.Wait()
+	l.Log(Entry{Tool: "late"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("second close: %v", err)
+	}
+}
@@ -5,11 +5,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
+// Values accepted in Config.EmbedBalance.
+const (
+	EmbedBalanceFailover   = "failover"
+	EmbedBalanceRoundRobin = "round_robin"
+)
+
 // Config holds runtime configuration for Chaosmith Core.
 // Values map to PCS/1.3-native environment knobs and can be overridden by env vars.
 type Config struct {
@@ -18,26 +25,157 @@ type Config struct {
 	SurrealPass string `toml:"surreal_pass"`
 	SurrealNS   string `toml:"surreal_ns"`
 	SurrealDB   string `toml:"surreal_db"`
+	// SurrealPassFile names a file holding the password; it overrides SurrealPass.
+	SurrealPassFile string `toml:"surreal_pass_file"`
+
+	SurrealBatchSize int `toml:"surreal_batch_size"`
+	// SurrealQueryTimeoutMS bounds each Surreal operation that has no deadline
+	// of its own; zero disables the timeout.
+	SurrealQueryTimeoutMS int `toml:"surreal_query_timeout_ms"`
+	// SurrealConnectTimeoutMS bounds the initial connection to SurrealDB.
+	SurrealConnectTimeoutMS int `toml:"surreal_connect_timeout_ms"`
+	// SurrealConnectAttempts is how many times startup tries to connect to
+	// SurrealDB before giving up.
+	SurrealConnectAttempts int `toml:"surreal_connect_attempts"`
+	// SurrealConnectRetryMS is the wait after the first failed connect; it
+	// doubles after each further failure.
+	SurrealConnectRetryMS int `toml:"surreal_connect_retry_ms"`
 
 	EmbedKind     string `toml:"embed_kind"`
 	EmbedURL      string `toml:"embed_url"`
 	EmbedModel    string `toml:"embed_model"`
 	EmbedModelSHA string `toml:"embed_model_sha"`
+	EmbedAPIKey   string `toml:"embed_api_key"`
 	EffectiveDim  int    `toml:"effective_dim"`
 	TransformID   string `toml:"transform_id"`
 	TokenizerID   string `toml:"tokenizer_id"`
+	// TransformPath is a build-pca transform file applied to vectors of
+	// EmbedModel. When empty the transform is loaded from SurrealDB by
+	// TransformID, unless that is "none".
+	TransformPath string `toml:"transform_path"`
+	// EmbedAPIKeyFile names a file holding the API key; it overrides EmbedAPIKey.
+	EmbedAPIKeyFile string `toml:"embed_api_key_file"`
+	// EmbedHeaders are extra HTTP headers sent with every embedding request.
+	EmbedHeaders map[string]string `toml:"embed_headers"`
+	// EmbedURLs, when set, overrides EmbedURL with several embedding
+	// endpoints. Requests are spread across them and move on to the next when
+	// one fails with a network error or 5xx.
+	EmbedURLs []string `toml:"embed_urls"`
+	// EmbedBalance is round_robin (start each request at the next endpoint in
+	// turn) or failover (always start with the first endpoint).
+	EmbedBalance string `toml:"embed_balance"`
+	// EmbedRetryAfterSecs is how long a failed endpoint stays out of rotation.
+	EmbedRetryAfterSecs int `toml:"embed_retry_after_secs"`
+
+	// NormalizeEmbeddings L2-normalizes stored and query vectors.
+	NormalizeEmbeddings bool `toml:"normalize_embeddings"`
+
+	// EmbedSkipFailedChunks skips chunks the embedder rejects, recording each
+	// as a run risk, instead of failing the run. A run whose every chunk is
+	// rejected still fails.
+	EmbedSkipFailedChunks bool `toml:"embed_skip_failed_chunks"`
+
+	// AllowEmbedDimChange lets an embed run store vectors whose dimension
+	// differs from the native_dim already recorded for the model.
+	AllowEmbedDimChange bool `toml:"allow_embed_dim_change"`
+
+	EmbedConcurrency  int `toml:"embed_concurrency"`
+	MaxChunksInFlight int `toml:"max_chunks_in_flight"`
+	// EmbedTimeoutMS bounds each embedding request without a caller deadline.
+	EmbedTimeoutMS int `toml:"embed_timeout_ms"`
+	// KNNCandidateMultiplier is how many KNN candidates file_vector_search
+	// and workspace_vector_search fetch per requested match before filtering
+	// them by file, workspace, model and time.
+	KNNCandidateMultiplier int `toml:"knn_candidate_multiplier"`
+
+	// ChunkerReadBufferBytes is the slab size used to stream files larger than
+	// one slab through the chunker; ChunkerOverlapBytes is how close to the end
+	// of a slab a chunk may end before it waits for the next slab.
+	ChunkerReadBufferBytes int `toml:"chunker_read_buffer_bytes"`
+	ChunkerOverlapBytes    int `toml:"chunker_overlap_bytes"`
+
+	// MinChunkTokens drops chunks with fewer tokens, such as a lone closing
+	// brace; MaxChunkTokens is the size each file is split into.
+	MinChunkTokens int `toml:"min_chunk_tokens"`
+	MaxChunkTokens int `toml:"max_chunk_tokens"`
+	// ChunkerKind is token (fixed token windows), paragraph (whole paragraphs
+	// merged up to MaxChunkTokens) or auto (paragraph for markdown and text).
+	ChunkerKind string `toml:"chunker_kind"`
+
+	// SkipDirs names directories left out of scans and embed runs on top of
+	// the built-in VCS, dependency and build directories. SkipFilePatterns are
+	// globs matched against file names (e.g. "*.lock") whose files are not
+	// embedded.
+	SkipDirs         []string `toml:"skip_dirs"`
+	SkipFilePatterns []string `toml:"skip_file_patterns"`
 
 	ArtifactRoot string   `toml:"artifact_root"`
 	WorkspaceIDs []string `toml:"work_roots"`
+	// CompressArtifacts writes run artifacts as gzipped .ndjson.gz files.
+	CompressArtifacts bool `toml:"compress_artifacts"`
 
 	IndexerBinary string `toml:"indexer_bin"`
 	CTagsPath     string `toml:"ctags_path"`
+
+	LogLevel  string `toml:"log_level"`  // debug, info, warn, error
+	LogFormat string `toml:"log_format"` // text or json
+
+	// EnableAdmin registers the admin_query tool.
+	EnableAdmin bool `toml:"enable_admin"`
+
+	// AuditLogPath appends a JSON line per tool call; empty disables auditing.
+	AuditLogPath string `toml:"audit_log_path"`
+
+	// MetricsAddr serves Prometheus /metrics on its own listener; empty disables it.
+	MetricsAddr string `toml:"metrics_addr"`
+
+	// MaxExecPerMinute caps term_exec and term_pty calls per MCP session;
+	// ExecRateLimits gives individual tools their own per-minute limit.
+	MaxExecPerMinute int            `toml:"max_exec_per_minute"`
+	ExecRateLimits   map[string]int `toml:"exec_rate_limits"`
+
+	// ExecAllow limits term_exec and term_pty to these command basenames (any
+	// when empty); ExecDeny blocks basenames, and "*" disables both tools.
+	ExecAllow []string `toml:"exec_allow"`
+	ExecDeny  []string `toml:"exec_deny"`
+
+	// ShutdownTimeoutSecs bounds how long shutdown waits for PTY sessions and
+	// in-flight index runs before cancelling them.
+	ShutdownTimeoutSecs int `toml:"shutdown_timeout_secs"`
+
+	// CORSOrigins lists browser origins allowed to call /mcp; "*" allows any.
+	CORSOrigins []string `toml:"cors_origins"`
+
+	// MCPAuthToken, when set, is required as a bearer token on /mcp.
+	// MCPAuthTokens lists additional accepted tokens for key rotation.
+	MCPAuthToken  string   `toml:"mcp_auth_token"`
+	MCPAuthTokens []string `toml:"mcp_auth_tokens"`
 }
 
 // Load reads configuration from the provided path, applying environment overrides.
 func Load(path string) (*Config, error) {
 	cfg := &Config{
-		ArtifactRoot: "var/lib/chaosmith/artifacts",
+		ArtifactRoot:            "var/lib/chaosmith/artifacts",
+		EmbedConcurrency:        4,
+		EmbedTimeoutMS:          120000,
+		KNNCandidateMultiplier:  10,
+		MaxChunksInFlight:       10000,
+		ChunkerReadBufferBytes:  64 * 1024,
+		ChunkerOverlapBytes:     512,
+		MinChunkTokens:          20,
+		MaxChunkTokens:          768,
+		ChunkerKind:             "token",
+		EmbedBalance:            EmbedBalanceRoundRobin,
+		EmbedRetryAfterSecs:     30,
+		SurrealBatchSize:        500,
+		SurrealQueryTimeoutMS:   30000,
+		SurrealConnectTimeoutMS: 30000,
+		SurrealConnectAttempts:  5,
+		SurrealConnectRetryMS:   1000,
+		LogLevel:                "info",
+		LogFormat:               "text",
+		MaxExecPerMinute:        60,
+		ShutdownTimeoutSecs:     30,
 	}
 
 	if path != "" {
@@ -53,6 +191,21 @@ func Load(path string) (*Config, error) {
 	applyEnvOverrides(cfg)
 	normalize(cfg)
 
+	if cfg.SurrealPassFile != "" {
+		pass, err := readSecretFile(cfg.SurrealPassFile)
+		if err != nil {
+			return nil, fmt.Errorf("surreal_pass_file: %w", err)
+		}
+		cfg.SurrealPass = pass
+	}
+	if cfg.EmbedAPIKeyFile != "" {
+		key, err := readSecretFile(cfg.EmbedAPIKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("embed_api_key_file: %w", err)
+		}
+		cfg.EmbedAPIKey = key
+	}
+
 	if err := validate(cfg); err != nil {
 		return nil, err
 	}
@@ -68,14 +221,28 @@ func applyEnvOverrides(cfg *Config) {
 	set(&cfg.SurrealURL, "SURREAL_URL")
 	set(&cfg.SurrealUser, "SURREAL_USER")
 	set(&cfg.SurrealPass, "SURREAL_PASS")
+	set(&cfg.SurrealPassFile, "SURREAL_PASS_FILE")
 	set(&cfg.SurrealNS, "SURREAL_NS")
 	set(&cfg.SurrealDB, "SURREAL_DB")
 
 	set(&cfg.EmbedKind, "EMBED_KIND")
+	set(&cfg.ChunkerKind, "CHUNKER_KIND")
 	set(&cfg.EmbedURL, "EMBED_URL")
+	set(&cfg.EmbedBalance, "EMBED_BALANCE")
+	if v := strings.TrimSpace(os.Getenv("EMBED_RETRY_AFTER_SECS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.EmbedRetryAfterSecs = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("EMBED_URLS")); v != "" {
+		cfg.EmbedURLs = splitCSV(v)
+	}
 	set(&cfg.EmbedModel, "EMBED_MODEL")
 	set(&cfg.EmbedModelSHA, "EMBED_MODEL_SHA")
+	set(&cfg.EmbedAPIKey, "EMBED_API_KEY")
+	set(&cfg.EmbedAPIKeyFile, "EMBED_API_KEY_FILE")
 	set(&cfg.TransformID, "TRANSFORM_ID")
+	set(&cfg.TransformPath, "TRANSFORM_PATH")
 	set(&cfg.TokenizerID, "TOKENIZER_ID")
 
 	if v := strings.TrimSpace(os.Getenv("EFFECTIVE_DIM")); v != "" {
@@ -83,32 +250,203 @@ func applyEnvOverrides(cfg *Config) {
 			cfg.EffectiveDim = dim
 		}
 	}
+	if v := strings.TrimSpace(os.Getenv("NORMALIZE_EMBEDDINGS")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.NormalizeEmbeddings = b
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("EMBED_SKIP_FAILED_CHUNKS")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.EmbedSkipFailedChunks = b
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ALLOW_EMBED_DIM_CHANGE")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.AllowEmbedDimChange = b
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("EMBED_CONCURRENCY")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.EmbedConcurrency = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("EMBED_TIMEOUT_MS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.EmbedTimeoutMS = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("KNN_CANDIDATE_MULTIPLIER")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.KNNCandidateMultiplier = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SURREAL_BATCH_SIZE")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.SurrealBatchSize = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SURREAL_QUERY_TIMEOUT_MS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.SurrealQueryTimeoutMS = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SURREAL_CONNECT_TIMEOUT_MS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.SurrealConnectTimeoutMS = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SURREAL_CONNECT_ATTEMPTS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.SurrealConnectAttempts = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SURREAL_CONNECT_RETRY_MS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.SurrealConnectRetryMS = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("MAX_CHUNKS_IN_FLIGHT")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.MaxChunksInFlight = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("CHUNKER_READ_BUFFER_BYTES")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.ChunkerReadBufferBytes = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("CHUNKER_OVERLAP_BYTES")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.ChunkerOverlapBytes = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("MIN_CHUNK_TOKENS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.MinChunkTokens = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("MAX_CHUNK_TOKENS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.MaxChunkTokens = n
+		}
+	}
+
+	if v := strings.TrimSpace(os.Getenv("SKIP_DIRS")); v != "" {
+		cfg.SkipDirs = splitCSV(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("SKIP_FILE_PATTERNS")); v != "" {
+		cfg.SkipFilePatterns = splitCSV(v)
+	}
 
 	if v := strings.TrimSpace(os.Getenv("WORK_ROOTS")); v != "" {
 		cfg.WorkspaceIDs = splitCSV(v)
 	}
 	set(&cfg.ArtifactRoot, "ARTIFACT_ROOT")
+	if v := strings.TrimSpace(os.Getenv("COMPRESS_ARTIFACTS")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.CompressArtifacts = b
+		}
+	}
 	set(&cfg.IndexerBinary, "INDEXER_BIN")
 	set(&cfg.CTagsPath, "CTAGS_PATH")
+	set(&cfg.LogLevel, "LOG_LEVEL")
+	set(&cfg.LogFormat, "LOG_FORMAT")
+	set(&cfg.MetricsAddr, "METRICS_ADDR")
+	set(&cfg.AuditLogPath, "AUDIT_LOG_PATH")
+	if v := strings.TrimSpace(os.Getenv("MAX_EXEC_PER_MINUTE")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.MaxExecPerMinute = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("EXEC_ALLOW")); v != "" {
+		cfg.ExecAllow = splitCSV(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("EXEC_DENY")); v != "" {
+		cfg.ExecDeny = splitCSV(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("SHUTDOWN_TIMEOUT_SECS")); v != "" {
+		if n, err := parseInt(v); err == nil {
+			cfg.ShutdownTimeoutSecs = n
+		}
+	}
+	set(&cfg.MCPAuthToken, "MCP_AUTH_TOKEN")
+	if v := strings.TrimSpace(os.Getenv("CORS_ORIGINS")); v != "" {
+		cfg.CORSOrigins = splitCSV(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("MCP_AUTH_TOKENS")); v != "" {
+		cfg.MCPAuthTokens = splitCSV(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("ENABLE_ADMIN")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.EnableAdmin = b
+		}
+	}
 }
 
 func normalize(cfg *Config) {
 	cfg.SurrealURL = strings.TrimSpace(cfg.SurrealURL)
 	cfg.SurrealUser = strings.TrimSpace(cfg.SurrealUser)
 	cfg.SurrealPass = strings.TrimSpace(cfg.SurrealPass)
+	cfg.SurrealPassFile = strings.TrimSpace(cfg.SurrealPassFile)
 	cfg.SurrealNS = strings.TrimSpace(cfg.SurrealNS)
 	cfg.SurrealDB = strings.TrimSpace(cfg.SurrealDB)
 
 	cfg.EmbedKind = strings.ToLower(strings.TrimSpace(cfg.EmbedKind))
+	cfg.ChunkerKind = strings.ToLower(strings.TrimSpace(cfg.ChunkerKind))
+	cfg.EmbedBalance = strings.ToLower(strings.TrimSpace(cfg.EmbedBalance))
 	cfg.EmbedURL = strings.TrimSpace(cfg.EmbedURL)
+	urls := cfg.EmbedURLs[:0]
+	for _, u := range cfg.EmbedURLs {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	cfg.EmbedURLs = urls
+	if len(cfg.EmbedURLs) > 0 {
+		cfg.EmbedURL = cfg.EmbedURLs[0]
+	}
 	cfg.EmbedModel = strings.TrimSpace(cfg.EmbedModel)
 	cfg.EmbedModelSHA = strings.TrimSpace(cfg.EmbedModelSHA)
+	cfg.EmbedAPIKey = strings.TrimSpace(cfg.EmbedAPIKey)
+	cfg.EmbedAPIKeyFile = strings.TrimSpace(cfg.EmbedAPIKeyFile)
 	cfg.TransformID = strings.TrimSpace(cfg.TransformID)
+	cfg.TransformPath = strings.TrimSpace(cfg.TransformPath)
 	cfg.TokenizerID = strings.TrimSpace(cfg.TokenizerID)
+	if cfg.EmbedConcurrency <= 0 {
+		cfg.EmbedConcurrency = 1
+	}
+	if cfg.SurrealBatchSize <= 0 {
+		cfg.SurrealBatchSize = 1
+	}
+	if cfg.MaxChunksInFlight <= 0 {
+		cfg.MaxChunksInFlight = 1
+	}
+	if cfg.SurrealQueryTimeoutMS < 0 {
+		cfg.SurrealQueryTimeoutMS = 0
+	}
+
+	cfg.SkipDirs = trimList(cfg.SkipDirs)
+	cfg.SkipFilePatterns = trimList(cfg.SkipFilePatterns)
 
 	cfg.ArtifactRoot = filepath.Clean(cfg.ArtifactRoot)
 	cfg.IndexerBinary = strings.TrimSpace(cfg.IndexerBinary)
 	cfg.CTagsPath = strings.TrimSpace(cfg.CTagsPath)
+	cfg.LogLevel = strings.ToLower(strings.TrimSpace(cfg.LogLevel))
+	cfg.LogFormat = strings.ToLower(strings.TrimSpace(cfg.LogFormat))
+	cfg.MetricsAddr = strings.TrimSpace(cfg.MetricsAddr)
+	cfg.AuditLogPath = strings.TrimSpace(cfg.AuditLogPath)
+	if cfg.MaxExecPerMinute < 0 {
+		cfg.MaxExecPerMinute = 0
+	}
+	cfg.ExecAllow = trimList(cfg.ExecAllow)
+	cfg.ExecDeny = trimList(cfg.ExecDeny)
+	cfg.MCPAuthToken = strings.TrimSpace(cfg.MCPAuthToken)
+	for i, o := range cfg.CORSOrigins {
+		cfg.CORSOrigins[i] = strings.TrimSpace(o)
+	}
+	for i, t := range cfg.MCPAuthTokens {
+		cfg.MCPAuthTokens[i] = strings.TrimSpace(t)
+	}
 }
 
 func validate(cfg *Config) error {
@@ -141,10 +479,79 @@ func validate(cfg *Config) error {
 	if len(missing) > 0 {
 		return fmt.Errorf("config missing required fields: %s", strings.Join(missing, ", "))
 	}
+	switch cfg.EmbedKind {
+	case "", "openai", "ollama":
+	default:
+		return fmt.Errorf("embed_kind must be openai or ollama, got %q", cfg.EmbedKind)
+	}
+	switch cfg.EmbedBalance {
+	case "", EmbedBalanceFailover, EmbedBalanceRoundRobin:
+	default:
+		return fmt.Errorf("embed_balance must be failover or round_robin, got %q", cfg.EmbedBalance)
+	}
+	if cfg.EmbedRetryAfterSecs <= 0 {
+		return fmt.Errorf("embed_retry_after_secs must be positive, got %d", cfg.EmbedRetryAfterSecs)
+	}
+	switch cfg.ChunkerKind {
+	case "", "token", "paragraph", "auto":
+	default:
+		return fmt.Errorf("chunker_kind must be token, paragraph or auto, got %q", cfg.ChunkerKind)
+	}
+	if cfg.EmbedTimeoutMS <= 0 {
+		return fmt.Errorf("embed_timeout_ms must be positive, got %d", cfg.EmbedTimeoutMS)
+	}
+	if cfg.KNNCandidateMultiplier < 1 || cfg.KNNCandidateMultiplier > 100 {
+		return fmt.Errorf("knn_candidate_multiplier must be between 1 and 100, got %d", cfg.KNNCandidateMultiplier)
+	}
+	for _, p := range cfg.SkipFilePatterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("skip_file_patterns: invalid pattern %q: %w", p, err)
+		}
+	}
+	if cfg.ChunkerReadBufferBytes < 4096 {
+		return fmt.Errorf("chunker_read_buffer_bytes must be at least 4096, got %d", cfg.ChunkerReadBufferBytes)
+	}
+	if cfg.ChunkerOverlapBytes < 0 || cfg.ChunkerOverlapBytes >= cfg.ChunkerReadBufferBytes {
+		return fmt.Errorf("chunker_overlap_bytes must be between 0 and chunker_read_buffer_bytes, got %d", cfg.ChunkerOverlapBytes)
+	}
+	if cfg.MinChunkTokens <= 0 || cfg.MinChunkTokens > cfg.MaxChunkTokens || cfg.MaxChunkTokens > 4096 {
+		return fmt.Errorf("chunk tokens must satisfy 0 < min_chunk_tokens <= max_chunk_tokens <= 4096, got %d and %d", cfg.MinChunkTokens, cfg.MaxChunkTokens)
+	}
+	if cfg.SurrealConnectTimeoutMS <= 0 {
+		return fmt.Errorf("surreal_connect_timeout_ms must be positive, got %d", cfg.SurrealConnectTimeoutMS)
+	}
+	if cfg.SurrealConnectAttempts <= 0 {
+		return fmt.Errorf("surreal_connect_attempts must be positive, got %d", cfg.SurrealConnectAttempts)
+	}
+	if cfg.SurrealConnectRetryMS < 0 {
+		return fmt.Errorf("surreal_connect_retry_ms must not be negative, got %d", cfg.SurrealConnectRetryMS)
+	}
+	if cfg.ShutdownTimeoutSecs <= 0 {
+		return fmt.Errorf("shutdown_timeout_secs must be positive, got %d", cfg.ShutdownTimeoutSecs)
+	}
 
 	return nil
 }
 
+// Prepare creates the directories the configuration points at, so a bad
+// artifact_root fails at startup rather than on the first index run. Load
+// leaves the filesystem alone; the server calls Prepare once it has a config.
+func (c *Config) Prepare() error {
+	if err := os.MkdirAll(c.ArtifactRoot, 0o755); err != nil {
+		return fmt.Errorf("create artifact root: %w", err)
+	}
+	return nil
+}
+
+// readSecretFile returns the contents of path without its trailing newline.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
 func parseInt(v string) (int, error) {
 	var out int
 	_, err := fmt.Sscanf(v, "%d", &out)
@@ -162,5 +569,16 @@ func splitCSV(v string) []string {
 	return out
 }
 
+// trimList trims each entry of list and drops the empty ones.
+func trimList(list []string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // ErrToolMissing is returned when a required external tool is unavailable.
 var ErrToolMissing = errors.New("tool missing")
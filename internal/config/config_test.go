@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const minimalTOML = `
+surreal_url = "http://127.0.0.1:8000"
+surreal_ns = "chaos"
+surreal_db = "core"
+surreal_pass = "inline"
+embed_url = "http://127.0.0.1:1234/v1/embeddings"
+embed_model = "mock"
+embed_model_sha = "sha"
+effective_dim = 8
+transform_id = "none"
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadSurrealPassFile(t *testing.T) {
+	dir := t.TempDir()
+	passPath := writeFile(t, dir, "pass", "s3cret\n")
+	cfgPath := writeFile(t, dir, "cfg.toml", minimalTOML+"surreal_pass_file = \""+filepath.ToSlash(passPath)+"\"\n")
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.SurrealPass != "s3cret" {
+		t.Fatalf("expected password from file, got %q", cfg.SurrealPass)
+	}
+}
+
+func TestLoadSurrealPassInline(t *testing.T) {
+	cfg, err := Load(writeFile(t, t.TempDir(), "cfg.toml", minimalTOML))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.SurrealPass != "inline" {
+		t.Fatalf("expected inline password, got %q", cfg.SurrealPass)
+	}
+}
+
+func TestLoadEmbedURLsOverrideEmbedURL(t *testing.T) {
+	toml := minimalTOML + `embed_urls = ["http://a:1234/v1/embeddings", " http://b:1234/v1/embeddings "]` + "\n"
+	cfg, err := Load(writeFile(t, t.TempDir(), "cfg.toml", toml))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.EmbedURL != "http://a:1234/v1/embeddings" || len(cfg.EmbedURLs) != 2 || cfg.EmbedURLs[1] != "http://b:1234/v1/embeddings" {
+		t.Fatalf("expected embed_urls to override embed_url, got %q %q", cfg.EmbedURL, cfg.EmbedURLs)
+	}
+	if cfg.EmbedBalance != EmbedBalanceRoundRobin {
+		t.Fatalf("expected round_robin by default, got %q", cfg.EmbedBalance)
+	}
+}
+
+func TestLoadSurrealPassFileMissing(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := writeFile(t, dir, "cfg.toml", minimalTOML+"surreal_pass_file = \""+filepath.ToSlash(filepath.Join(dir, "absent"))+"\"\n")
+	if _, err := Load(cfgPath); err == nil {
+		t.Fatalf("expected error for missing password file")
+	}
+}
+
+func TestPrepareCreatesArtifactRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "var", "lib", "artifacts")
+	cfg, err := Load(writeFile(t, dir, "cfg.toml", minimalTOML+"artifact_root = \""+filepath.ToSlash(root)+"\"\n"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("Load should not touch the filesystem, stat: %v", err)
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		t.Fatalf("expected artifact root directory, stat: %v", err)
+	}
+}
+
+func TestPrepareReportsArtifactRootError(t *testing.T) {
+	dir := t.TempDir()
+	blocker := writeFile(t, dir, "file", "")
+	cfg := &Config{ArtifactRoot: filepath.Join(blocker, "artifacts")}
+	if err := cfg.Prepare(); err == nil || !strings.Contains(err.Error(), "create artifact root") {
+		t.Fatalf("expected create artifact root error, got %v", err)
+	}
+}
@@ -6,83 +6,398 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
+)
+
+// Backend kinds accepted in config.EmbedKind.
+const (
+	KindOpenAI = "openai"
+	KindOllama = "ollama"
 )
 
 // Client sends embedding requests to local executors per PCS/1.3-native.
 type Client struct {
 	Endpoint string
 	Model    string
+	// Logger receives request logs; nil uses slog.Default().
+	Logger *slog.Logger
+	// Normalize scales every returned vector to unit length.
+	Normalize bool
+	// APIKey, when set, is sent as a bearer token. It is never logged.
+	APIKey string
+	// Headers are added to every request for backends that need custom headers.
+	Headers map[string]string
+	// Fallbacks are tried in order after Endpoint when a request fails with a
+	// network error or 5xx response.
+	Fallbacks []string
+	// RoundRobin starts each request at the next endpoint in turn, spreading
+	// load across Endpoint and Fallbacks instead of always starting with
+	// Endpoint.
+	RoundRobin bool
+	// RetryAfter is how long a failed endpoint is tried only after the others;
+	// zero uses endpointCooldown.
+	RetryAfter time.Duration
 
+	kind string
 	http *http.Client
+	next atomic.Uint64
+
+	healthMu  sync.Mutex
+	downUntil map[string]time.Time
+	lastErr   map[string]string
 }
 
-// New returns a configured embedding client.
-func New(endpoint, model string) *Client {
+// LoadBalancedClient is a Client spreading requests round-robin over several
+// endpoints, as returned by NewLoadBalanced.
+type LoadBalancedClient = Client
+
+// endpointCooldown is the default for RetryAfter.
+const endpointCooldown = 30 * time.Second
+
+// EndpointHealth is the circuit-breaker state of one embedding endpoint as
+// seen by recent requests.
+type EndpointHealth struct {
+	Endpoint  string     `json:"endpoint"`
+	Healthy   bool       `json:"healthy"`
+	DownUntil *time.Time `json:"downUntil,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// DefaultTimeout bounds a request when New is given no positive timeout.
+const DefaultTimeout = 120 * time.Second
+
+// New returns a configured embedding client. timeout bounds each request whose
+// context has no deadline; a non-positive timeout uses DefaultTimeout.
+func New(endpoint, model string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
 	return &Client{
 		Endpoint: strings.TrimRight(endpoint, "/"),
 		Model:    model,
+		kind:     KindOpenAI,
 		http: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: timeout,
 		},
 	}
 }
 
-// Embed returns embeddings for each input string in order.
+// NewLoadBalanced returns a client that starts each request at the next of
+// endpoints in turn, keeping endpoints that recently failed out of rotation
+// for RetryAfter. Duplicate endpoints are dropped. Requests use
+// DefaultTimeout.
+func NewLoadBalanced(endpoints []string, model string) *LoadBalancedClient {
+	var urls []string
+	for _, u := range endpoints {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" && !slices.Contains(urls, u) {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		urls = []string{""}
+	}
+	c := New(urls[0], model, DefaultTimeout)
+	c.Fallbacks = urls[1:]
+	c.RoundRobin = true
+	return c
+}
+
+// NewFromConfig returns a client for cfg.EmbedKind with the configured
+// endpoints, model, timeout, API key and headers. cfg.EmbedURLs, when set,
+// replace cfg.EmbedURL; with more than one the client is load balanced as by
+// NewLoadBalanced unless cfg.EmbedBalance is failover, which always starts
+// with the first. Logger and Normalize are left for the caller to set.
+func NewFromConfig(cfg *config.Config) (*Client, error) {
+	endpoints := cfg.EmbedURLs
+	if len(endpoints) == 0 {
+		endpoints = []string{cfg.EmbedURL}
+	}
+	if cfg.EmbedKind == KindOllama {
+		urls := make([]string, len(endpoints))
+		for i, u := range endpoints {
+			urls[i] = ollamaURL(u)
+		}
+		endpoints = urls
+	} else if cfg.EmbedKind != "" && cfg.EmbedKind != KindOpenAI {
+		return nil, fmt.Errorf("unsupported embed_kind %q", cfg.EmbedKind)
+	}
+	c := NewLoadBalanced(endpoints, cfg.EmbedModel)
+	if cfg.EmbedKind == KindOllama {
+		c.kind = KindOllama
+	}
+	c.http.Timeout = time.Duration(cfg.EmbedTimeoutMS) * time.Millisecond
+	c.APIKey = cfg.EmbedAPIKey
+	c.Headers = cfg.EmbedHeaders
+	c.RoundRobin = cfg.EmbedBalance != config.EmbedBalanceFailover
+	c.RetryAfter = time.Duration(cfg.EmbedRetryAfterSecs) * time.Second
+	return c, nil
+}
+
+// httpClient returns the client for a request under ctx. A caller deadline is
+// honoured as-is instead of being cut short or extended by the client timeout.
+func (c *Client) httpClient(ctx context.Context) *http.Client {
+	if _, ok := ctx.Deadline(); !ok {
+		return c.http
+	}
+	hc := *c.http
+	hc.Timeout = 0
+	return &hc
+}
+
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+// Ping embeds a short probe string to confirm the executor is reachable and
+// serving the configured model.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Embed(ctx, []string{"ping"})
+	return err
+}
+
+// Embed returns embeddings for each input string in order. When Fallbacks are
+// configured, a network error or 5xx response moves on to the next endpoint.
 func (c *Client) Embed(ctx context.Context, input []string) ([][]float32, error) {
+	return c.embed(ctx, c.Model, input)
+}
+
+// EmbedWithModel is Embed with model sent in place of c.Model for this call
+// only. model may be a backend model name or a vector_model slug; the slug of
+// c.Model is treated as c.Model. Any other model is logged, since its vectors
+// will not be comparable with those stored for c.Model.
+func (c *Client) EmbedWithModel(ctx context.Context, model string, input []string) ([][]float32, error) {
+	model = strings.TrimSpace(model)
+	if model == "" || model == c.Model || model == ModelSlug(c.Model) {
+		return c.embed(ctx, c.Model, input)
+	}
+	c.logger().Warn("embedding with a model other than the configured one", "model", model, "configured", c.Model)
+	return c.embed(ctx, model, input)
+}
+
+// EmbedUsing is EmbedWithModel without the warning, for callers that chose
+// model deliberately, such as a workspace's embed_model override. An empty
+// model uses c.Model.
+func (c *Client) EmbedUsing(ctx context.Context, model string, input []string) ([][]float32, error) {
+	if model = strings.TrimSpace(model); model == "" {
+		model = c.Model
+	}
+	return c.embed(ctx, model, input)
+}
+
+func (c *Client) embed(ctx context.Context, model string, input []string) ([][]float32, error) {
 	if len(input) == 0 {
 		return nil, nil
 	}
+	// OpenAI and Ollama share the same request body.
 	payload := struct {
 		Model string   `json:"model"`
 		Input []string `json:"input"`
 	}{
-		Model: c.Model,
+		Model: model,
 		Input: input,
 	}
 	body, _ := json.Marshal(payload)
 
-	if strings.TrimSpace(os.Getenv("CS_DEBUG_EMBED")) != "" {
-		log.Printf("[EMBED] POST %s model=%s inputs=%d", c.Endpoint, c.Model, len(input))
+	var lastErr error
+	for _, endpoint := range c.endpointOrder(time.Now()) {
+		out, retry, err := c.post(ctx, endpoint, model, body, len(input))
+		if err == nil {
+			c.markHealthy(endpoint)
+			if c.Normalize {
+				for _, vec := range out {
+					L2Normalize(vec)
+				}
+			}
+			return out, nil
+		}
+		if !retry || ctx.Err() != nil {
+			return nil, err
+		}
+		c.markDown(endpoint, time.Now(), err)
+		c.logger().Warn("embed endpoint failed", "endpoint", endpoint, "err", err)
+		lastErr = err
 	}
+	return nil, lastErr
+}
+
+// post sends body to endpoint and decodes the response. retry reports whether
+// the failure was a network error or 5xx that another endpoint may not share.
+func (c *Client) post(ctx context.Context, endpoint, model string, body []byte, inputs int) (out [][]float32, retry bool, err error) {
+	c.logger().Debug("embed request", "endpoint", endpoint, "kind", c.kind, "model", model, "inputs", inputs)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("build embed request: %w", err)
+		return nil, false, fmt.Errorf("build embed request: %w", err)
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
 
-	resp, err := c.http.Do(req)
+	metrics.EmbedRequests.Inc()
+	start := time.Now()
+	defer func() { metrics.EmbedDuration.Observe(time.Since(start).Seconds()) }()
+	resp, err := c.httpClient(ctx).Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("embed http request: %w", err)
+		return nil, true, fmt.Errorf("embed http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return nil, fmt.Errorf("embed http %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+		return nil, resp.StatusCode >= 500, fmt.Errorf("embed http %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if c.kind == KindOllama {
+		out, err = decodeOllama(resp.Body)
+	} else {
+		out, err = decodeOpenAI(resp.Body)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(out) != inputs {
+		return nil, false, fmt.Errorf("embed response count mismatch: expected %d got %d", inputs, len(out))
 	}
+	return out, false, nil
+}
 
+// endpointOrder lists Endpoint then Fallbacks, or with RoundRobin the same
+// list rotated by one more place on each call. Endpoints that failed within
+// RetryAfter move to the back so a dead one is not tried first on every call;
+// they stay in the list as a last resort and rejoin the rotation once
+// RetryAfter has passed.
+func (c *Client) endpointOrder(now time.Time) []string {
+	all := append([]string{c.Endpoint}, c.Fallbacks...)
+	if len(all) == 1 {
+		return all
+	}
+	if c.RoundRobin {
+		off := int((c.next.Add(1) - 1) % uint64(len(all)))
+		all = slices.Concat(all[off:], all[:off])
+	}
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	healthy := make([]string, 0, len(all))
+	var down []string
+	for _, ep := range all {
+		if now.Before(c.downUntil[ep]) {
+			down = append(down, ep)
+			continue
+		}
+		healthy = append(healthy, ep)
+	}
+	return append(healthy, down...)
+}
+
+func (c *Client) markDown(endpoint string, now time.Time, err error) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	if c.lastErr == nil {
+		c.lastErr = make(map[string]string)
+	}
+	c.lastErr[endpoint] = err.Error()
+	if len(c.Fallbacks) == 0 {
+		return
+	}
+	if c.downUntil == nil {
+		c.downUntil = make(map[string]time.Time)
+	}
+	cooldown := c.RetryAfter
+	if cooldown <= 0 {
+		cooldown = endpointCooldown
+	}
+	c.downUntil[endpoint] = now.Add(cooldown)
+}
+
+func (c *Client) markHealthy(endpoint string) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	delete(c.downUntil, endpoint)
+	delete(c.lastErr, endpoint)
+}
+
+// Health reports each endpoint in configured order. An endpoint is unhealthy
+// while it is out of rotation after a failure, or, when it is the only
+// endpoint, while its last request failed.
+func (c *Client) Health(now time.Time) []EndpointHealth {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	all := append([]string{c.Endpoint}, c.Fallbacks...)
+	out := make([]EndpointHealth, 0, len(all))
+	for _, ep := range all {
+		h := EndpointHealth{Endpoint: ep, Healthy: true, LastError: c.lastErr[ep]}
+		if until, ok := c.downUntil[ep]; ok && now.Before(until) {
+			h.Healthy = false
+			h.DownUntil = &until
+		} else if len(all) == 1 && h.LastError != "" {
+			h.Healthy = false
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// decodeOpenAI reads a /v1/embeddings response.
+func decodeOpenAI(r io.Reader) ([][]float32, error) {
 	var decoded struct {
 		Data []struct {
 			Embedding []float32 `json:"embedding"`
 		} `json:"data"`
 		Model string `json:"model"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
 		return nil, fmt.Errorf("decode embed response: %w", err)
 	}
-	if len(decoded.Data) != len(input) {
-		return nil, fmt.Errorf("embed response count mismatch: expected %d got %d", len(input), len(decoded.Data))
-	}
 	out := make([][]float32, len(decoded.Data))
 	for i, row := range decoded.Data {
 		out[i] = row.Embedding
 	}
 	return out, nil
 }
+
+// ModelSlug returns the vector_model record id used for model: lowercased,
+// with separators collapsed to single dashes.
+func ModelSlug(model string) string {
+	slug := strings.ToLower(model)
+	replacer := strings.NewReplacer(" ", "-", "/", "-", ":", "-", "@", "-", ".", "-", "_", "-")
+	slug = replacer.Replace(slug)
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	return strings.Trim(slug, "-")
+}
+
+// L2Normalize scales vec in place to unit length and returns its original
+// norm. A zero vector is left unchanged.
+func L2Normalize(vec []float32) float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	norm := math.Sqrt(sum)
+	if norm == 0 {
+		return 0
+	}
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+	return norm
+}
@@ -0,0 +1,289 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+)
+
+func TestEmbedSendsAuthAndHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	c := New(srv.URL, "mock", DefaultTimeout)
+	c.APIKey = "sk-secret"
+	c.Headers = map[string]string{"X-Org-Id": "forge"}
+	c.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := c.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if auth := got.Get("Authorization"); auth != "Bearer sk-secret" {
+		t.Fatalf("unexpected Authorization %q", auth)
+	}
+	if org := got.Get("X-Org-Id"); org != "forge" {
+		t.Fatalf("unexpected X-Org-Id %q", org)
+	}
+	if strings.Contains(logs.String(), "sk-secret") {
+		t.Fatalf("api key leaked into logs: %s", logs.String())
+	}
+}
+
+func TestEmbedOmitsAuthWithoutKey(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	if _, err := New(srv.URL, "mock", DefaultTimeout).Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if auth != "" {
+		t.Fatalf("expected no Authorization header, got %q", auth)
+	}
+}
+
+func TestEmbedHonoursCallerDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// The client timeout is shorter than the caller's deadline; the deadline wins.
+	c := New(srv.URL, "mock", 20*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release <- struct{}{}
+	}()
+	if _, err := c.Embed(ctx, []string{"hello"}); err != nil {
+		t.Fatalf("embed under caller deadline: %v", err)
+	}
+
+	// Without a deadline the client timeout applies.
+	if _, err := c.Embed(context.Background(), []string{"hello"}); err == nil {
+		t.Fatalf("expected client timeout")
+	}
+}
+
+func TestEmbedFailsOverToNextEndpoint(t *testing.T) {
+	var deadHits, liveHits int
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadHits++
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer dead.Close()
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		liveHits++
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer live.Close()
+
+	c := New(dead.URL, "mock", DefaultTimeout)
+	c.Fallbacks = []string{live.URL}
+	c.Logger = slog.New(slog.DiscardHandler)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("embed %d: %v", i, err)
+		}
+	}
+	// The dead endpoint is skipped while cooling down after the first failure.
+	if deadHits != 1 || liveHits != 3 {
+		t.Fatalf("expected 1 dead and 3 live hits, got %d and %d", deadHits, liveHits)
+	}
+	if order := c.endpointOrder(time.Now().Add(endpointCooldown + time.Second)); order[0] != dead.URL {
+		t.Fatalf("dead endpoint should be retried first after cooldown, got %v", order)
+	}
+}
+
+func TestEmbedDoesNotFailOverOnClientError(t *testing.T) {
+	var fallbackHits int
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad input", http.StatusBadRequest)
+	}))
+	defer bad.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackHits++
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer fallback.Close()
+
+	c := New(bad.URL, "mock", DefaultTimeout)
+	c.Fallbacks = []string{fallback.URL}
+	if _, err := c.Embed(context.Background(), []string{"hello"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected 400 error, got %v", err)
+	}
+	if fallbackHits != 0 {
+		t.Fatalf("4xx responses should not fail over")
+	}
+}
+
+func TestEmbedWithModelOverridesPayloadModel(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	c := New(srv.URL, "text-embedding-nomic-embed-text-v1.5@q8_0", DefaultTimeout)
+	c.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	if _, err := c.EmbedWithModel(context.Background(), "bge-small", []string{"hello"}); err != nil {
+		t.Fatalf("embed with override: %v", err)
+	}
+	if _, err := c.EmbedWithModel(context.Background(), "text-embedding-nomic-embed-text-v1-5-q8-0", []string{"hello"}); err != nil {
+		t.Fatalf("embed with slug: %v", err)
+	}
+	if _, err := c.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	want := []string{"bge-small", c.Model, c.Model}
+	if strings.Join(models, ",") != strings.Join(want, ",") {
+		t.Fatalf("request models %v, want %v", models, want)
+	}
+	if c.Model != "text-embedding-nomic-embed-text-v1.5@q8_0" {
+		t.Fatalf("override must not mutate the client, got %q", c.Model)
+	}
+	if n := strings.Count(logs.String(), "other than the configured"); n != 1 {
+		t.Fatalf("expected one override warning, got %d: %s", n, logs.String())
+	}
+}
+
+func TestEmbedRoundRobinSkipsDownEndpoint(t *testing.T) {
+	hits := make([]int, 3)
+	var urls []string
+	for i := range hits {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[i]++
+			if i == 2 {
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+		}))
+		defer srv.Close()
+		urls = append(urls, srv.URL)
+	}
+
+	c := New(urls[0], "mock", DefaultTimeout)
+	c.Fallbacks = urls[1:]
+	c.RoundRobin = true
+	c.RetryAfter = time.Minute
+	c.Logger = slog.New(slog.DiscardHandler)
+
+	for i := 0; i < 6; i++ {
+		if _, err := c.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("embed %d: %v", i, err)
+		}
+	}
+	// Requests rotate across the live endpoints; the failing one is hit once
+	// and then kept out of rotation.
+	if hits[0] < 2 || hits[1] < 2 || hits[2] != 1 {
+		t.Fatalf("unexpected hit counts %v", hits)
+	}
+
+	health := c.Health(time.Now())
+	if len(health) != 3 || !health[0].Healthy || !health[1].Healthy {
+		t.Fatalf("expected live endpoints healthy, got %+v", health)
+	}
+	if h := health[2]; h.Healthy || h.DownUntil == nil || h.LastError == "" {
+		t.Fatalf("expected failing endpoint reported down, got %+v", h)
+	}
+	if h := c.Health(time.Now().Add(2 * time.Minute))[2]; !h.Healthy {
+		t.Fatalf("expected endpoint re-admitted after RetryAfter, got %+v", h)
+	}
+}
+
+func TestNewLoadBalancedSpreadsRequests(t *testing.T) {
+	hits := make([]int, 3)
+	var urls []string
+	for i := range hits {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[i]++
+			_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+		}))
+		defer srv.Close()
+		urls = append(urls, srv.URL)
+	}
+
+	c := NewLoadBalanced(append(urls, urls[0]+"/"), "mock")
+	for i := 0; i < 9; i++ {
+		if _, err := c.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("embed %d: %v", i, err)
+		}
+	}
+	// The duplicate endpoint is dropped and requests rotate evenly.
+	if hits[0] != 3 || hits[1] != 3 || hits[2] != 3 {
+		t.Fatalf("expected requests spread evenly, got %v", hits)
+	}
+}
+
+func TestNewFromConfigBalancesEmbedURLs(t *testing.T) {
+	cfg := &config.Config{
+		EmbedURL:       "http://primary:1234/v1/embeddings",
+		EmbedURLs:      []string{"http://a:1234/v1/embeddings", "http://b:1234/v1/embeddings"},
+		EmbedModel:     "mock",
+		EmbedTimeoutMS: 1000,
+	}
+	c, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("new from config: %v", err)
+	}
+	if c.Endpoint != cfg.EmbedURLs[0] || len(c.Fallbacks) != 1 || c.Fallbacks[0] != cfg.EmbedURLs[1] || !c.RoundRobin {
+		t.Fatalf("expected a load-balanced client over embed_urls, got %s %v round robin %v", c.Endpoint, c.Fallbacks, c.RoundRobin)
+	}
+
+	cfg.EmbedBalance = config.EmbedBalanceFailover
+	if c, err = NewFromConfig(cfg); err != nil || c.RoundRobin {
+		t.Fatalf("expected failover to disable round robin, got %+v %v", c, err)
+	}
+}
+
+func TestEmbedUsingSendsGivenModel(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.Model)
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "general", DefaultTimeout)
+	for _, model := range []string{"code-model", ""} {
+		if _, err := c.EmbedUsing(context.Background(), model, []string{"hello"}); err != nil {
+			t.Fatalf("embed: %v", err)
+		}
+	}
+	if len(got) != 2 || got[0] != "code-model" || got[1] != "general" {
+		t.Fatalf("unexpected models sent %v", got)
+	}
+}
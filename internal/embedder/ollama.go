@@ -0,0 +1,61 @@
+package embedder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ollamaEmbedPath is appended to an Ollama endpoint given without a path.
+const ollamaEmbedPath = "/api/embed"
+
+// NewOllamaClient returns a client for Ollama's POST /api/embed API. endpoint
+// may be the server root (e.g. http://localhost:11434) or the full embed URL.
+// Requests use DefaultTimeout.
+func NewOllamaClient(endpoint, model string) *Client {
+	c := New(endpoint, model, DefaultTimeout)
+	c.kind = KindOllama
+	c.Endpoint = ollamaURL(c.Endpoint)
+	return c
+}
+
+// ollamaURL appends ollamaEmbedPath to endpoint when it has no path.
+func ollamaURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") == "" {
+		return endpoint + ollamaEmbedPath
+	}
+	return endpoint
+}
+
+// decodeOllama reads an /api/embed response. Ollama may stream the reply as
+// several newline-delimited JSON objects, so embeddings from every object are
+// collected in order until the body ends. An object carrying "error" fails
+// the request even when the HTTP status was 200.
+func decodeOllama(r io.Reader) ([][]float32, error) {
+	dec := json.NewDecoder(r)
+	var out [][]float32
+	for {
+		var msg struct {
+			Embeddings [][]float32 `json:"embeddings"`
+			Error      string      `json:"error"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decode embed response: %w", err)
+		}
+		if msg.Error != "" {
+			return nil, fmt.Errorf("ollama embed: %s", msg.Error)
+		}
+		out = append(out, msg.Embeddings...)
+	}
+	if out == nil {
+		return nil, fmt.Errorf("decode embed response: no embeddings")
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+)
+
+func TestOllamaRoundTrip(t *testing.T) {
+	var gotPath string
+	var gotBody struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"model":"nomic","embeddings":[[3,4],[1,0]]}`))
+	}))
+	defer srv.Close()
+
+	c := NewOllamaClient(srv.URL, "nomic")
+	c.Normalize = true
+	vecs, err := c.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if gotPath != "/api/embed" {
+		t.Fatalf("unexpected path %q", gotPath)
+	}
+	if gotBody.Model != "nomic" || len(gotBody.Input) != 2 || gotBody.Input[1] != "b" {
+		t.Fatalf("unexpected request body %+v", gotBody)
+	}
+	if len(vecs) != 2 || vecs[0][0] != 0.6 || vecs[0][1] != 0.8 || vecs[1][0] != 1 {
+		t.Fatalf("unexpected vectors %v", vecs)
+	}
+}
+
+func TestOllamaStreamedAndErrorResponses(t *testing.T) {
+	body := "{\"embeddings\":[[1,2]]}\n{\"embeddings\":[[3,4]]}\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := NewOllamaClient(srv.URL+"/api/embed", "nomic")
+	vecs, err := c.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed streamed: %v", err)
+	}
+	if len(vecs) != 2 || vecs[1][1] != 4 {
+		t.Fatalf("unexpected vectors %v", vecs)
+	}
+
+	body = "{\"error\":\"model \\\"nomic\\\" not found\"}\n"
+	if _, err := c.Embed(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected ollama error, got %v", err)
+	}
+}
+
+func TestNewFromConfigDispatchesOnKind(t *testing.T) {
+	cfg := &config.Config{
+		EmbedKind:      KindOllama,
+		EmbedURL:       "http://localhost:11434",
+		EmbedURLs:      []string{"http://localhost:11434", "http://backup:11434/"},
+		EmbedModel:     "nomic",
+		EmbedTimeoutMS: 1000,
+	}
+	c, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("new from config: %v", err)
+	}
+	if c.kind != KindOllama || c.Endpoint != "http://localhost:11434/api/embed" || c.http.Timeout.Milliseconds() != 1000 {
+		t.Fatalf("unexpected ollama client %+v", c)
+	}
+	if len(c.Fallbacks) != 1 || c.Fallbacks[0] != "http://backup:11434/api/embed" {
+		t.Fatalf("unexpected fallbacks %v", c.Fallbacks)
+	}
+
+	cfg.EmbedKind = ""
+	if c, err = NewFromConfig(cfg); err != nil || c.kind != KindOpenAI {
+		t.Fatalf("expected openai default, got %+v %v", c, err)
+	}
+
+	cfg.EmbedKind = "bogus"
+	if _, err := NewFromConfig(cfg); err == nil {
+		t.Fatalf("expected error for unknown kind")
+	}
+}
@@ -78,15 +78,15 @@ func TestEndToEnd_Workspace_Bloodseeker(t *testing.T) {
 		EmbedModel:    "unit-test-model",
 		EmbedModelSHA: "sha256-unit",
 		EffectiveDim:  4,
-		TransformID:   "pca-unit@deadbeef",
+		TransformID:   "none",
 		TokenizerID:   "tiktoken/cl100k_base",
 		ArtifactRoot:  t.TempDir(),
 	}
-	client, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB)
+	client, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB, 30*time.Second)
 	if err != nil {
 		t.Fatalf("surreal client: %v", err)
 	}
-	ix, err := New(cfg, client)
+	ix, err := New(cfg, client, nil)
 	if err != nil {
 		t.Fatalf("indexer init: %v", err)
 	}
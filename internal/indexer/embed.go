@@ -3,15 +3,20 @@ package indexer
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"io/fs"
-	"log"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
 	"github.com/CryingSurrogate/chaosmith-core/internal/runctx"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
 	surrealmodels "github.com/surrealdb/surrealdb.go/pkg/models"
 	"github.com/zeebo/blake3"
 )
@@ -23,6 +28,10 @@ const (
 
 type embedResult struct {
 	Artifacts []string
+	Stats     embedStats
+	// DroppedShortChunks counts chunks dropped for having fewer than
+	// cfg.MinChunkTokens tokens.
+	DroppedShortChunks int
 }
 
 type embedChunk struct {
@@ -35,203 +44,689 @@ type embedChunk struct {
 	ContentSHA string    `json:"content_sha"`
 	Size       int64     `json:"size"`
 	Vector     []float32 `json:"vector"`
+	Norm       float64   `json:"norm,omitempty"`
 	NativeDim  int       `json:"native_dim"`
 }
 
-func (ix *Indexer) performEmbedding(ctx context.Context, run *runctx.Run) (*embedResult, error) {
-	root := run.WorkspaceRoot
-
-	chunks, err := ix.collectEmbedChunks(ctx, root)
+// performEmbedding streams chunks from the workspace walk through the embedder
+// and into SurrealDB. At most cfg.MaxChunksInFlight chunks wait between the
+// walk and the embedder, so memory stays bounded regardless of workspace size.
+func (ix *Indexer) performEmbedding(ctx context.Context, run *runctx.Run, prog *progressReporter) (*embedResult, error) {
+	model, err := ix.workspaceEmbedModel(ctx, run.WorkspaceID)
 	if err != nil {
 		return &embedResult{}, err
 	}
-	if len(chunks) == 0 {
-		return &embedResult{}, fmt.Errorf("no embeddable files discovered")
+	run.Fingerprint.EmbedModel = model
+	run.Fingerprint.EmbedModelSHA = ix.modelSHA(model)
+	if model != ix.cfg.EmbedModel {
+		ix.runLogger(run).Info("embedding with workspace model override", "model", model, "configured", ix.cfg.EmbedModel)
 	}
-
-	if err := ix.populateVectors(ctx, chunks); err != nil {
+	xf, err := ix.runTransform(ctx, run, model)
+	if err != nil {
 		return &embedResult{}, err
 	}
 
-	if err := ix.storeEmbeddings(ctx, run, chunks); err != nil {
-		log.Printf("index.embed surreal ops failed (workspace=%s): %v", run.WorkspaceID, err)
-		return &embedResult{}, fmt.Errorf("surreal ops (embed) workspace %s: %w", run.WorkspaceID, err)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
 	}
 
-	artifact, err := ix.writeNDJSON(run.ArtifactDir, "vectors.ndjson", chunks)
+	chunkCh := make(chan *embedChunk, ix.maxChunksInFlight())
+	batchCh := make(chan []*embedChunk)
+
+	var skippedFiles, droppedChunks int
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(chunkCh)
+		var err error
+		if skippedFiles, droppedChunks, err = ix.collectEmbedChunks(ctx, run.WorkspaceRoot, chunkCh); err != nil {
+			fail(err)
+		}
+	}()
+	var stats embedStats
+	go func() {
+		defer wg.Done()
+		defer close(batchCh)
+		var err error
+		if stats, err = ix.populateVectors(ctx, model, xf, chunkCh, batchCh, prog); err != nil {
+			fail(err)
+		}
+	}()
+
+	stored, artifact, err := ix.storeEmbeddings(ctx, run, model, batchCh, prog)
 	if err != nil {
-		return &embedResult{}, err
+		ix.runLogger(run).Error("surreal ops failed", "err", err)
+		fail(fmt.Errorf("surreal ops (embed) workspace %s: %w", run.WorkspaceID, err))
 	}
-	run.AddArtifact(artifact)
+	wg.Wait()
+	stats.SkippedFiles = skippedFiles
 
-	return &embedResult{Artifacts: []string{artifact}}, nil
+	var artifacts []string
+	if artifact != "" {
+		run.AddArtifact(artifact)
+		artifacts = append(artifacts, artifact)
+	}
+	if firstErr != nil {
+		return &embedResult{Artifacts: artifacts}, firstErr
+	}
+	if stored == 0 {
+		return &embedResult{}, fmt.Errorf("no embeddable files discovered")
+	}
+	return &embedResult{Artifacts: artifacts, Stats: stats, DroppedShortChunks: droppedChunks}, nil
+}
+
+// runTransform loads the transform applied to vectors of model and records its
+// id on run. Transforms are built from the configured model's embeddings, so a
+// workspace overriding the model stores its vectors untransformed.
+func (ix *Indexer) runTransform(ctx context.Context, run *runctx.Run, model string) (*embxform.Transform, error) {
+	if model != ix.cfg.EmbedModel {
+		run.Fingerprint.TransformID = "none"
+		return nil, nil
+	}
+	xf, err := embxform.Resolve(ctx, ix.cfg.TransformPath, ix.surreal, ix.cfg.TransformID)
+	if err != nil {
+		return nil, err
+	}
+	if xf != nil && xf.Dim != ix.cfg.EffectiveDim {
+		return nil, fmt.Errorf("transform %s outputs %d dims, effective_dim is %d", ix.cfg.TransformID, xf.Dim, ix.cfg.EffectiveDim)
+	}
+	return xf, nil
 }
 
-func (ix *Indexer) collectEmbedChunks(ctx context.Context, root string) ([]*embedChunk, error) {
-	var chunks []*embedChunk
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
+// workspaceEmbedModel returns the embed_model set on the workspace record, or
+// cfg.EmbedModel when the workspace has none.
+func (ix *Indexer) workspaceEmbedModel(ctx context.Context, wsID string) (string, error) {
+	if ix.surreal == nil {
+		return ix.cfg.EmbedModel, nil
+	}
+	type row struct {
+		EmbedModel *string `json:"embed_model"`
+	}
+	rows, err := surreal.Query[row](ctx, ix.surreal, "SELECT embed_model FROM type::thing('workspace', $ws_id)", map[string]any{"ws_id": wsID})
+	if err != nil {
+		return "", fmt.Errorf("load workspace embed_model: %w", err)
+	}
+	if len(rows) > 0 && rows[0].EmbedModel != nil {
+		if model := strings.TrimSpace(*rows[0].EmbedModel); model != "" {
+			return model, nil
 		}
-		if d.IsDir() {
-			if shouldSkipDir(d.Name()) {
-				return filepath.SkipDir
-			}
+	}
+	return ix.cfg.EmbedModel, nil
+}
+
+// modelSHA returns cfg.EmbedModelSHA for the configured model. A workspace
+// override has no recorded hash, so it gets none.
+func (ix *Indexer) modelSHA(model string) string {
+	if model == ix.cfg.EmbedModel {
+		return ix.cfg.EmbedModelSHA
+	}
+	return ""
+}
+
+func (ix *Indexer) maxChunksInFlight() int {
+	if ix.cfg != nil && ix.cfg.MaxChunksInFlight > 0 {
+		return ix.cfg.MaxChunksInFlight
+	}
+	return 1
+}
+
+// collectEmbedChunks walks root as the scan does, following the same symlinks,
+// and sends each chunk on out in walk order.
+// Text in other encodings is converted to UTF-8 before chunking; files that
+// cannot be decoded as text are skipped and counted, as are chunks dropped for
+// being too short. The caller owns out and closes it once collectEmbedChunks
+// returns.
+func (ix *Indexer) collectEmbedChunks(ctx context.Context, root string, out chan<- *embedChunk) (skipped, dropped int, err error) {
+	_, err = ix.walkTree(ctx, root, nil, func(e treeEntry) error {
+		if statSkipReason(e.info) != "" || ix.skipFile(filepath.Base(e.logical)) {
 			return nil
 		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-		info, err := d.Info()
+		undecodable, n, err := ix.chunkFile(ctx, e.physical, e.rel, e.info.Size(), out)
 		if err != nil {
 			return err
 		}
-		if !info.Mode().IsRegular() {
-			return nil
+		dropped += n
+		if undecodable {
+			ix.log().Debug("skipping file that is not decodable text", "path", e.rel)
+			skipped++
 		}
-		if info.Size() == 0 || info.Size() > maxEmbedFileBytes {
-			return nil
+		return nil
+	})
+	return skipped, dropped, err
+}
+
+// chunkFile sends the chunks of one file on out and reports whether it was
+// skipped as undecodable text and how many short chunks were dropped. Files no larger than cfg.ChunkerReadBufferBytes
+// are read whole; larger ones are streamed through chunkReader in slabs of that
+// size, so peak memory per file does not grow with maxEmbedFileBytes.
+func (ix *Indexer) chunkFile(ctx context.Context, path, rel string, size int64, out chan<- *embedChunk) (bool, int, error) {
+	index := 0
+	emit := func(seg tokenChunk) error {
+		ch := &embedChunk{
+			RelPath:    rel,
+			Index:      index,
+			Start:      seg.Start,
+			End:        seg.End,
+			TokenCount: seg.TokenCount,
+			Text:       seg.Text,
+			ContentSHA: hashBytes([]byte(seg.Text)),
+			Size:       int64(len(seg.Text)),
 		}
-		rel := normalizeRelPath(root, path)
-		if rel == "" {
-			rel = filepath.Base(path)
+		index++
+		select {
+		case out <- ch:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	}
+
+	readBuf, overlap := ix.chunkerBuffers()
+	if size <= int64(readBuf) {
 		content, err := os.ReadFile(path)
 		if err != nil {
-			return err
+			return false, 0, err
 		}
-		if isBinary(content) {
-			return nil
+		if mimeType, _ := detectMimeType(content); !strings.HasPrefix(mimeType, "text/") {
+			return false, 0, nil
 		}
-		segments, err := ix.chunker.chunk(string(content))
+		text, _, err := detectAndConvertEncoding(content)
 		if err != nil {
-			return fmt.Errorf("chunk file %s: %w", rel, err)
-		}
-		for i, seg := range segments {
-			chunkText := seg.Text
-			chunks = append(chunks, &embedChunk{
-				RelPath:    rel,
-				Index:      i,
-				Start:      seg.Start,
-				End:        seg.End,
-				TokenCount: seg.TokenCount,
-				Text:       chunkText,
-				ContentSHA: hashBytes([]byte(chunkText)),
-				Size:       int64(len(chunkText)),
-			})
+			return true, 0, nil
 		}
-		return nil
-	})
+		segments, dropped, err := ix.chunkerFor(path).chunk(string(text))
+		if err != nil {
+			return false, 0, fmt.Errorf("chunk file %s: %w", rel, err)
+		}
+		for _, seg := range segments {
+			if err := emit(seg); err != nil {
+				return false, 0, err
+			}
+		}
+		return false, dropped, nil
+	}
+
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return false, 0, err
 	}
-	return chunks, nil
+	defer f.Close()
+	buf := make([]byte, readBuf)
+	n, err := io.ReadFull(f, buf[:min(sniffLen, len(buf))])
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false, 0, err
+	}
+	if mimeType, _ := detectMimeType(buf[:n]); !strings.HasPrefix(mimeType, "text/") {
+		return false, 0, nil
+	}
+	// Each pass rereads the file from the start: one to pick the encoding, one
+	// to reject control characters before any chunk is sent, one to chunk.
+	rewind := func() error {
+		_, err := f.Seek(0, io.SeekStart)
+		return err
+	}
+	if err := rewind(); err != nil {
+		return false, 0, err
+	}
+	enc, err := detectStreamEncoding(f, buf)
+	if errors.Is(err, errNotText) {
+		return true, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+	if err := rewind(); err != nil {
+		return false, 0, err
+	}
+	err = checkTextStream(encodingFor(enc).NewDecoder().Reader(f), buf, enc)
+	if errors.Is(err, errNotText) {
+		return true, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+	if err := rewind(); err != nil {
+		return false, 0, err
+	}
+	dropped, err := chunkReader(encodingFor(enc).NewDecoder().Reader(f), buf, overlap, ix.chunkerFor(path), emit)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, 0, err
+		}
+		return false, 0, fmt.Errorf("chunk file %s: %w", rel, err)
+	}
+	return false, dropped, nil
 }
 
-func (ix *Indexer) populateVectors(ctx context.Context, chunks []*embedChunk) error {
-	for i := 0; i < len(chunks); i += embedBatchSize {
-		j := i + embedBatchSize
-		if j > len(chunks) {
-			j = len(chunks)
+// chunkerFor picks the chunker for a file according to cfg.ChunkerKind: auto
+// uses the paragraph chunker for Markdown and plain text and the token
+// chunker for everything else.
+func (ix *Indexer) chunkerFor(path string) textChunker {
+	if ix.paragraphs != nil && ix.cfg != nil {
+		switch ix.cfg.ChunkerKind {
+		case "paragraph":
+			return ix.paragraphs
+		case "auto":
+			if lang := detectLanguage(path); lang == "markdown" || lang == "text" {
+				return ix.paragraphs
+			}
+		}
+	}
+	return ix.chunker
+}
+
+// chunkerBuffers returns the slab size and overlap chunkFile streams with.
+func (ix *Indexer) chunkerBuffers() (readBuf, overlap int) {
+	readBuf, overlap = 64*1024, 512
+	if ix.cfg != nil && ix.cfg.ChunkerReadBufferBytes > 0 {
+		readBuf, overlap = ix.cfg.ChunkerReadBufferBytes, ix.cfg.ChunkerOverlapBytes
+	}
+	return readBuf, overlap
+}
+
+// embedStats counts chunks seen by populateVectors and how many of them were
+// sent to the embedder after deduplicating by content sha. Skipped describes
+// each chunk the embedder rejected when cfg.EmbedSkipFailedChunks is set, and
+// SkippedFiles counts files that could not be decoded as text.
+type embedStats struct {
+	Chunks       int
+	Embedded     int
+	Skipped      []string
+	SkippedFiles int
+}
+
+// sharedVector holds the embedding for one content sha so that duplicate chunks
+// within a run reuse it instead of being embedded again.
+type sharedVector struct {
+	vec       []float32
+	norm      float64
+	nativeDim int
+}
+
+// populateVectors groups chunks from in into batches of embedBatchSize and embeds
+// up to cfg.EmbedConcurrency batches at once. Only the first chunk with a given
+// content sha is embedded; later duplicates receive the same vector. Completed
+// batches are sent on out in the order they were read, so chunk ordering matches
+// a sequential run. With cfg.NormalizeEmbeddings each vector is scaled to unit
+// length and its original norm kept on the chunk. A non-nil xf then projects
+// each vector, which is normalized again. Chunks left without a vector
+// by a skipped input are dropped along with their duplicates, but the run
+// fails if every input is skipped. The first error cancels outstanding batches. The caller closes out. Chunks are embedded with
+// model; empty means the embedder's configured model.
+func (ix *Indexer) populateVectors(ctx context.Context, model string, xf *embxform.Transform, in <-chan *embedChunk, out chan<- []*embedChunk, prog *progressReporter) (embedStats, error) {
+	concurrency := 1
+	if ix.cfg != nil && ix.cfg.EmbedConcurrency > 0 {
+		concurrency = ix.cfg.EmbedConcurrency
+	}
+	normalize := ix.cfg != nil && ix.cfg.NormalizeEmbeddings
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type pendingBatch struct {
+		chunks []*embedChunk   // all chunks in read order
+		refs   []*sharedVector // parallel to chunks
+		unique []*embedChunk   // first occurrences sent to the embedder
+		done   chan error
+
+		skipped []string // set by embedBatch before done is sent
+	}
+
+	var stats embedStats
+
+	// The queue holds submitted batches in read order. Together with the batch
+	// being awaited below, its capacity caps in-flight requests at concurrency.
+	queue := make(chan *pendingBatch, concurrency-1)
+	go func() {
+		defer close(queue)
+		shared := make(map[string]*sharedVector)
+		submit := func(p *pendingBatch) bool {
+			select {
+			case queue <- p:
+			case <-ctx.Done():
+				return false
+			}
+			go func() {
+				var err error
+				p.skipped, err = ix.embedBatch(ctx, model, p.unique)
+				p.done <- err
+			}()
+			return true
+		}
+		newBatch := func() *pendingBatch {
+			return &pendingBatch{
+				chunks: make([]*embedChunk, 0, embedBatchSize),
+				refs:   make([]*sharedVector, 0, embedBatchSize),
+				done:   make(chan error, 1),
+			}
+		}
+		batch := newBatch()
+		for ch := range in {
+			ref, seen := shared[ch.ContentSHA]
+			if !seen {
+				ref = &sharedVector{}
+				shared[ch.ContentSHA] = ref
+				batch.unique = append(batch.unique, ch)
+				stats.Embedded++
+			}
+			stats.Chunks++
+			batch.chunks = append(batch.chunks, ch)
+			batch.refs = append(batch.refs, ref)
+			if len(batch.chunks) == embedBatchSize {
+				if !submit(batch) {
+					return
+				}
+				batch = newBatch()
+			}
 		}
-		batch := chunks[i:j]
-		inputs := make([]string, len(batch))
-		for k, ch := range batch {
-			inputs[k] = ch.Text
+		if len(batch.chunks) > 0 {
+			submit(batch)
+		}
+	}()
+
+	for p := range queue {
+		var err error
+		select {
+		case err = <-p.done:
+		case <-ctx.Done():
+			err = ctx.Err()
 		}
-		vectors, err := ix.embed.Embed(ctx, inputs)
 		if err != nil {
-			return err
+			return embedStats{}, err
 		}
-		for k, vec := range vectors {
-			if len(vec) == 0 {
-				return fmt.Errorf("embedding returned empty vector for %s", batch[k].RelPath)
+		stats.Skipped = append(stats.Skipped, p.skipped...)
+		// A duplicate always follows its first occurrence, either earlier in this
+		// batch or in a batch that was already emitted, so the vector is ready.
+		kept := p.chunks[:0]
+		for i, ch := range p.chunks {
+			if len(ch.Vector) > 0 {
+				if normalize {
+					ch.Norm = embedder.L2Normalize(ch.Vector)
+				}
+				if xf != nil {
+					if ch.Vector = xf.Apply(ch.Vector); ch.Vector == nil {
+						return embedStats{}, fmt.Errorf("transform %s expects native dim %d, embedder returned %d", ix.cfg.TransformID, xf.NativeDim(), ch.NativeDim)
+					}
+					if normalize {
+						embedder.L2Normalize(ch.Vector)
+					}
+				}
+				p.refs[i].vec = ch.Vector
+				p.refs[i].norm = ch.Norm
+				p.refs[i].nativeDim = ch.NativeDim
+				kept = append(kept, ch)
+				continue
+			}
+			if p.refs[i].vec == nil {
+				continue
 			}
-			batch[k].Vector = vec
-			batch[k].NativeDim = len(vec)
+			ch.Vector = p.refs[i].vec
+			ch.Norm = p.refs[i].norm
+			ch.NativeDim = p.refs[i].nativeDim
+			kept = append(kept, ch)
+		}
+		prog.add(ctx, "chunks embedded", len(p.chunks))
+		if len(kept) == 0 {
+			continue
+		}
+		select {
+		case out <- kept:
+		case <-ctx.Done():
+			return embedStats{}, ctx.Err()
 		}
 	}
-	return nil
+	if err := ctx.Err(); err != nil {
+		return embedStats{}, err
+	}
+	if n := len(stats.Skipped); n > 0 && n == stats.Embedded {
+		return embedStats{}, fmt.Errorf("embedder rejected all %d chunks; first: %s", n, stats.Skipped[0])
+	}
+	return stats, nil
 }
 
-func (ix *Indexer) storeEmbeddings(ctx context.Context, run *runctx.Run, chunks []*embedChunk) error {
-	wsID := run.WorkspaceID
-	modelSlug := modelIdentifier(ix.cfg.EmbedModel)
-	family, version := splitModel(ix.cfg.EmbedModel)
+// checkModelDim fails when the stored vector_model for slug already records a
+// native_dim other than dim, since chunks of mixed dimensions cannot be
+// searched together. cfg.AllowEmbedDimChange lets the run overwrite it.
+func (ix *Indexer) checkModelDim(ctx context.Context, slug string, dim int) error {
+	if ix.cfg.AllowEmbedDimChange {
+		return nil
+	}
+	existing, err := surreal.SelectRecord[storedModel](ctx, ix.surreal, "vector_model", slug)
+	if err != nil {
+		return fmt.Errorf("load vector_model %s: %w", slug, err)
+	}
+	return modelDimConflict(slug, existing, dim)
+}
 
-	// Determine model native dim
-	nativeDim := 0
-	for _, ch := range chunks {
-		if n := len(ch.Vector); n > 0 {
-			nativeDim = n
-			break
-		}
+// storedModel is the part of a vector_model record checkModelDim reads.
+type storedModel struct {
+	NativeDim int `json:"native_dim"`
+}
+
+// modelDimConflict reports an error when existing, the stored record for slug
+// if there is one, has a native_dim other than dim.
+func modelDimConflict(slug string, existing *storedModel, dim int) error {
+	if existing != nil && existing.NativeDim != 0 && existing.NativeDim != dim {
+		return fmt.Errorf("embedding dimension changed for model %s: stored chunks have native_dim %d, embedder returned %d; delete the model with vector_model_delete or set allow_embed_dim_change", slug, existing.NativeDim, dim)
 	}
-	if nativeDim == 0 {
-		return fmt.Errorf("no vectors available to determine native dim")
+	return nil
+}
+
+// embedBatch embeds batch in a single request. If that request fails, each
+// chunk is retried on its own so one input the backend rejects does not sink
+// the rest. A chunk that still fails is left without a vector and described in
+// the returned list when cfg.EmbedSkipFailedChunks is set; otherwise it fails
+// the batch with the chunk identified.
+func (ix *Indexer) embedBatch(ctx context.Context, model string, batch []*embedChunk) ([]string, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	err := ix.embedInputs(ctx, model, batch)
+	if err == nil || ctx.Err() != nil {
+		return nil, err
+	}
+	if len(batch) > 1 {
+		ix.log().Warn("embed batch failed; retrying inputs individually", "chunks", len(batch), "err", err)
+	}
+	skip := ix.cfg != nil && ix.cfg.EmbedSkipFailedChunks
+	var skipped []string
+	for _, ch := range batch {
+		if len(batch) > 1 {
+			if err = ix.embedInputs(ctx, model, []*embedChunk{ch}); err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil, err
+			}
+		}
+		if !skip {
+			return nil, fmt.Errorf("embed %s chunk %d: %w", ch.RelPath, ch.Index, err)
+		}
+		ix.log().Warn("skipping chunk the embedder rejected", "path", ch.RelPath, "chunk", ch.Index, "err", err)
+		skipped = append(skipped, fmt.Sprintf("embedding skipped for %s chunk %d: %s", ch.RelPath, ch.Index, err))
 	}
+	return skipped, nil
+}
 
-	// Upsert model metadata
-	if err := ix.surreal.UpsertRecord(ctx, "vector_model", modelSlug, map[string]any{
-		"id_slug":    modelSlug,
-		"family":     family,
-		"version":    version,
-		"native_dim": nativeDim,
-		"model_sha":  ix.cfg.EmbedModelSHA,
-		"notes":      "generated via chaosmith-core",
-	}); err != nil {
-		return fmt.Errorf("upsert vector_model: %w", err)
+// embedInputs sends batch to the embedder as one request and stores the
+// returned vectors on the chunks.
+func (ix *Indexer) embedInputs(ctx context.Context, model string, batch []*embedChunk) error {
+	inputs := make([]string, len(batch))
+	for k, ch := range batch {
+		inputs[k] = ch.Text
+	}
+	vectors, err := ix.embed.EmbedUsing(ctx, model, inputs)
+	if err != nil {
+		return err
+	}
+	for k, vec := range vectors {
+		if len(vec) == 0 {
+			return fmt.Errorf("embedding returned empty vector for %s", batch[k].RelPath)
+		}
 	}
+	for k, vec := range vectors {
+		batch[k].Vector = vec
+		batch[k].NativeDim = len(vec)
+	}
+	return nil
+}
 
-	// Upsert chunks and relate
+// storeEmbeddings writes each embedded batch to SurrealDB and the vectors.ndjson
+// artifact as it arrives, grouping up to cfg.SurrealBatchSize chunk upserts per
+// round-trip, folding vectors into the workspace centroid so no batch
+// is retained after it is stored. It returns the number of chunks stored and the
+// artifact path, if one was created.
+func (ix *Indexer) storeEmbeddings(ctx context.Context, run *runctx.Run, model string, batches <-chan []*embedChunk, prog *progressReporter) (int, string, error) {
+	wsID := run.WorkspaceID
+	modelSlug := embedder.ModelSlug(model)
+	family, version := splitModel(model)
+	modelSHA := ix.modelSHA(model)
 	now := time.Now().UTC()
-	for _, ch := range chunks {
-		if len(ch.Vector) == 0 {
-			return fmt.Errorf("missing embedding for %s chunk %d", ch.RelPath, ch.Index)
-		}
-		fileRecID := fileID(wsID, ch.RelPath)
-		vecID := vectorChunkID(wsID, fileRecID, "chunk", ch.Index)
-		if err := ix.surreal.UpsertRecord(ctx, "vector_chunk", vecID, map[string]any{
-			"ws":            surrealmodels.NewRecordID("workspace", wsID),
-			"file":          surrealmodels.NewRecordID("file", fileRecID),
-			"symbol":        surrealmodels.None,
-			"granularity":   "file_chunk",
-			"chunk_index":   ch.Index,
-			"start":         ch.Start,
-			"end":           ch.End,
-			"token_count":   ch.TokenCount,
-			"content_sha":   ch.ContentSHA,
-			"model":         surrealmodels.NewRecordID("vector_model", modelSlug),
-			"model_sha":     ix.cfg.EmbedModelSHA,
-			"native_dim":    ch.NativeDim,
-			"effective_dim": ix.cfg.EffectiveDim,
-			"transform_id":  ix.cfg.TransformID,
-			"vector":        ch.Vector,
-			"ts":            now,
-		}); err != nil {
-			return fmt.Errorf("upsert vector_chunk %s: %w", ch.RelPath, err)
+
+	var (
+		nativeDim int
+		vecDim    int // len of stored vectors: effective_dim when transformed
+		centroid  []float32
+		sample    int
+		stored    int
+		artifact  *ndjsonWriter
+		pending   []surreal.BatchRecord
+		relations []string
+	)
+	batchSize := ix.cfg.SurrealBatchSize
+	if batchSize <= 0 {
+		batchSize = surreal.DefaultBatchSize
+	}
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if err := ix.surreal.UpsertBatch(ctx, "vector_chunk", pending); err != nil {
+			return fmt.Errorf("upsert vector_chunk batch: %w", err)
 		}
-		if err := ix.surreal.Relate(ctx, "file", fileRecID, "file_has_vector", "vector_chunk", vecID, nil); err != nil {
-			return fmt.Errorf("relate file->vector %s: %w", ch.RelPath, err)
+		if err := ix.surreal.ExecBatch(ctx, relations); err != nil {
+			return fmt.Errorf("relate file->vector batch: %w", err)
 		}
+		pending = pending[:0]
+		relations = relations[:0]
+		prog.add(ctx, "batches stored", 1)
+		return nil
+	}
+	defer func() {
+		if artifact != nil {
+			_ = artifact.Close()
+		}
+	}()
+	artifactPath := func() string {
+		if artifact == nil {
+			return ""
+		}
+		return artifact.Path()
 	}
 
-	// Compute and upsert workspace centroid vector and relate
-	centroid := make([]float32, nativeDim)
-	sample := 0
-	for _, ch := range chunks {
-		if len(ch.Vector) != nativeDim {
-			continue
+	for batch := range batches {
+		if nativeDim == 0 {
+			// Determine model native dim from the first vector and upsert model metadata
+			for _, ch := range batch {
+				if n := len(ch.Vector); n > 0 {
+					nativeDim, vecDim = ch.NativeDim, n
+					break
+				}
+			}
+			if nativeDim == 0 {
+				return stored, artifactPath(), fmt.Errorf("no vectors available to determine native dim")
+			}
+			if err := ix.checkModelDim(ctx, modelSlug, nativeDim); err != nil {
+				return stored, artifactPath(), err
+			}
+			if err := ix.surreal.UpsertRecord(ctx, "vector_model", modelSlug, map[string]any{
+				"id_slug":    modelSlug,
+				"name":       model,
+				"family":     family,
+				"version":    version,
+				"native_dim": nativeDim,
+				"model_sha":  modelSHA,
+				"notes":      "generated via chaosmith-core",
+			}); err != nil {
+				return stored, artifactPath(), fmt.Errorf("upsert vector_model: %w", err)
+			}
+			centroid = make([]float32, vecDim)
+
+			w, err := newNDJSONWriter(run.ArtifactDir, "vectors.ndjson", ix.compressArtifacts())
+			if err != nil {
+				return stored, "", err
+			}
+			artifact = w
 		}
-		for i := 0; i < nativeDim; i++ {
-			centroid[i] += ch.Vector[i]
+
+		// Queue chunk upserts and relations; they are flushed in batches
+		for _, ch := range batch {
+			if len(ch.Vector) == 0 {
+				return stored, artifactPath(), fmt.Errorf("missing embedding for %s chunk %d", ch.RelPath, ch.Index)
+			}
+			if len(ch.Vector) != vecDim {
+				return stored, artifactPath(), fmt.Errorf("embedding for %s chunk %d has dim %d, expected %d", ch.RelPath, ch.Index, len(ch.Vector), vecDim)
+			}
+			fileRecID := fileID(wsID, ch.RelPath)
+			vecID := vectorChunkID(wsID, fileRecID, "chunk", ch.Index)
+			pending = append(pending, surreal.BatchRecord{ID: vecID, Content: map[string]any{
+				"ws":            surrealmodels.NewRecordID("workspace", wsID),
+				"file":          surrealmodels.NewRecordID("file", fileRecID),
+				"symbol":        surrealmodels.None,
+				"granularity":   "file_chunk",
+				"chunk_index":   ch.Index,
+				"start":         ch.Start,
+				"end":           ch.End,
+				"token_count":   ch.TokenCount,
+				"content_sha":   ch.ContentSHA,
+				"model":         surrealmodels.NewRecordID("vector_model", modelSlug),
+				"model_sha":     modelSHA,
+				"native_dim":    ch.NativeDim,
+				"effective_dim": vecDim,
+				"transform_id":  run.Fingerprint.TransformID,
+				"vector":        ch.Vector,
+				"norm":          chunkNorm(ch),
+				"ts":            now,
+			}})
+			relations = append(relations, relateStatement("file", fileRecID, "file_has_vector", "vector_chunk", vecID))
+			if err := artifact.Encode(ch); err != nil {
+				return stored, artifactPath(), err
+			}
+			if len(ch.Vector) == vecDim {
+				for i := 0; i < vecDim; i++ {
+					centroid[i] += ch.Vector[i]
+				}
+				sample++
+			}
+			stored++
 		}
-		sample++
+		if len(pending) >= batchSize {
+			if err := flush(); err != nil {
+				return stored, artifactPath(), err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		// An upstream stage failed or the run was cancelled; it reports the cause.
+		return stored, artifactPath(), nil
 	}
+
+	if err := flush(); err != nil {
+		return stored, artifactPath(), err
+	}
+
+	// Upsert workspace centroid vector and relate
 	if sample > 0 {
-		for i := 0; i < nativeDim; i++ {
+		for i := 0; i < vecDim; i++ {
 			centroid[i] /= float32(sample)
 		}
 		wsVecID := hexID("wsv", wsID, modelSlug, "centroid@file")
@@ -243,27 +738,94 @@ func (ix *Indexer) storeEmbeddings(ctx context.Context, run *runctx.Run, chunks
 			"sample": sample,
 			"ts":     now,
 		}); err != nil {
-			return fmt.Errorf("upsert workspace_vector: %w", err)
+			return stored, artifactPath(), fmt.Errorf("upsert workspace_vector: %w", err)
 		}
 		if err := ix.surreal.Relate(ctx, "workspace", wsID, "workspace_has_vector", "workspace_vector", wsVecID, nil); err != nil {
-			return fmt.Errorf("relate workspace->workspace_vector: %w", err)
+			return stored, artifactPath(), fmt.Errorf("relate workspace->workspace_vector: %w", err)
 		}
 	}
-	return nil
+	if artifact != nil {
+		if err := artifact.Close(); err != nil {
+			return stored, artifactPath(), err
+		}
+	}
+	prog.flush(ctx)
+	return stored, artifactPath(), nil
 }
 
-func isBinary(content []byte) bool {
-	const sample = 1024
-	n := len(content)
-	if n > sample {
-		n = sample
+// chunkNorm returns the pre-normalization norm for storage, or NONE when the
+// vector was stored as returned by the embedder.
+func chunkNorm(ch *embedChunk) any {
+	if ch.Norm == 0 {
+		return surrealmodels.None
 	}
-	for i := 0; i < n; i++ {
-		if content[i] == 0 {
-			return true
-		}
+	return ch.Norm
+}
+
+// Reasons the embed step skips a file, as reported by EmbedSkipReason.
+const (
+	SkipNotRegular = "not_regular"
+	SkipEmpty      = "empty"
+	SkipTooLarge   = "too_large"
+	SkipBinary     = "binary"
+	SkipExcluded   = "excluded"
+)
+
+// EmbedSkipReason stats path and reports why the embed step would skip it,
+// or "" if the file is embeddable. Names excluded by skip_file_patterns are
+// not known here and are not reported.
+func EmbedSkipReason(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if reason := statSkipReason(info); reason != "" {
+		return reason, nil
+	}
+	if shouldSkipFile(filepath.Base(path)) {
+		return SkipExcluded, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
 	}
-	return false
+	defer f.Close()
+	head := make([]byte, 1024)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, binary := detectMimeType(head[:n]); binary {
+		return SkipBinary, nil
+	}
+	return "", nil
+}
+
+func statSkipReason(info os.FileInfo) string {
+	switch {
+	case !info.Mode().IsRegular():
+		return SkipNotRegular
+	case info.Size() == 0:
+		return SkipEmpty
+	case info.Size() > maxEmbedFileBytes:
+		return SkipTooLarge
+	}
+	return ""
+}
+
+// sniffLen is how many leading bytes detectMimeType inspects.
+const sniffLen = 512
+
+// detectMimeType sniffs the MIME type of content from its first sniffLen
+// bytes, without parameters such as charset. Anything other than text/* is
+// reported as binary.
+func detectMimeType(content []byte) (mimeType string, isBinary bool) {
+	if len(content) > sniffLen {
+		content = content[:sniffLen]
+	}
+	mimeType, _, _ = strings.Cut(http.DetectContentType(content), ";")
+	mimeType = strings.TrimSpace(mimeType)
+	return mimeType, !strings.HasPrefix(mimeType, "text/")
 }
 
 func hashBytes(b []byte) string {
@@ -285,20 +847,10 @@ func vectorToSurreal(vec []float32) string {
 	return sb.String()
 }
 
-func modelIdentifier(model string) string {
-	slug := strings.ToLower(model)
-	replacer := strings.NewReplacer(" ", "-", "/", "-", ":", "-", "@", "-", ".", "-", "_", "-")
-	slug = replacer.Replace(slug)
-	for strings.Contains(slug, "--") {
-		slug = strings.ReplaceAll(slug, "--", "-")
-	}
-	return strings.Trim(slug, "-")
-}
-
 func splitModel(model string) (string, string) {
 	parts := strings.Split(model, "-")
 	if len(parts) < 2 {
-		return modelIdentifier(model), "base"
+		return embedder.ModelSlug(model), "base"
 	}
 	family := parts[0]
 	version := strings.Join(parts[1:], "-")
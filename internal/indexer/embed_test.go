@@ -0,0 +1,388 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
+)
+
+// newMockEmbedServer returns a server that encodes each input's numeric suffix
+// into the first vector component after sleeping for up to maxDelay.
+func newMockEmbedServer(t *testing.T, maxDelay time.Duration) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if maxDelay > 0 {
+			time.Sleep(time.Duration(rand.Int63n(int64(maxDelay))))
+		}
+		type row struct {
+			Embedding []float32 `json:"embedding"`
+		}
+		resp := struct {
+			Data []row `json:"data"`
+		}{}
+		for _, in := range req.Input {
+			n, err := strconv.Atoi(strings.TrimPrefix(in, "chunk-"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp.Data = append(resp.Data, row{Embedding: []float32{float32(n), 1}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+// runPopulateVectors streams chunks through populateVectors and returns the
+// batches in the order they were emitted.
+func runPopulateVectors(ix *Indexer, chunks []*embedChunk) ([][]*embedChunk, error) {
+	batches, _, err := runPopulateVectorsStats(ix, chunks)
+	return batches, err
+}
+
+func runPopulateVectorsStats(ix *Indexer, chunks []*embedChunk) ([][]*embedChunk, embedStats, error) {
+	return runPopulateVectorsWith(ix, nil, chunks)
+}
+
+func runPopulateVectorsWith(ix *Indexer, xf *embxform.Transform, chunks []*embedChunk) ([][]*embedChunk, embedStats, error) {
+	in := make(chan *embedChunk, 1)
+	out := make(chan []*embedChunk)
+	errCh := make(chan error, 1)
+	var stats embedStats
+	go func() {
+		defer close(in)
+		for _, ch := range chunks {
+			in <- ch
+		}
+	}()
+	go func() {
+		defer close(out)
+		var err error
+		stats, err = ix.populateVectors(context.Background(), "", xf, in, out, nil)
+		errCh <- err
+	}()
+	var batches [][]*embedChunk
+	for b := range out {
+		batches = append(batches, b)
+	}
+	err := <-errCh
+	return batches, stats, err
+}
+
+func TestPopulateVectorsPreservesOrderUnderConcurrency(t *testing.T) {
+	srv := newMockEmbedServer(t, 20*time.Millisecond)
+	defer srv.Close()
+
+	ix := &Indexer{
+		cfg:   &config.Config{EmbedConcurrency: 4},
+		embed: embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+	}
+
+	chunks := make([]*embedChunk, embedBatchSize*10+3)
+	for i := range chunks {
+		text := fmt.Sprintf("chunk-%d", i)
+		chunks[i] = &embedChunk{RelPath: "f.txt", Index: i, Text: text, ContentSHA: hashBytes([]byte(text))}
+	}
+
+	batches, err := runPopulateVectors(ix, chunks)
+	if err != nil {
+		t.Fatalf("populate vectors: %v", err)
+	}
+	var emitted []*embedChunk
+	for _, b := range batches {
+		if len(b) > embedBatchSize {
+			t.Fatalf("batch exceeds size limit: %d", len(b))
+		}
+		emitted = append(emitted, b...)
+	}
+	if len(emitted) != len(chunks) {
+		t.Fatalf("expected %d chunks emitted, got %d", len(chunks), len(emitted))
+	}
+	for i, ch := range emitted {
+		if len(ch.Vector) != 2 {
+			t.Fatalf("chunk %d: expected 2-dim vector, got %d", i, len(ch.Vector))
+		}
+		if int(ch.Vector[0]) != i {
+			t.Fatalf("chunk %d: vector out of order, got marker %v", i, ch.Vector[0])
+		}
+		if ch.NativeDim != 2 {
+			t.Fatalf("chunk %d: native dim %d", i, ch.NativeDim)
+		}
+	}
+}
+
+func TestPopulateVectorsAppliesTransform(t *testing.T) {
+	srv := newMockEmbedServer(t, 0)
+	defer srv.Close()
+
+	ix := &Indexer{
+		cfg:   &config.Config{EmbedConcurrency: 2, NormalizeEmbeddings: true},
+		embed: embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+	}
+	// Drops the constant second component, so every projected vector is
+	// [±1] after normalizing again.
+	xf := &embxform.Transform{Mean: []float32{0, 0}, Components: [][]float32{{1}, {0}}, Dim: 1}
+
+	var chunks []*embedChunk
+	for _, text := range []string{"chunk-3", "chunk-4", "chunk-3"} {
+		chunks = append(chunks, &embedChunk{RelPath: "f.txt", Index: len(chunks), Text: text, ContentSHA: hashBytes([]byte(text))})
+	}
+	batches, _, err := runPopulateVectorsWith(ix, xf, chunks)
+	if err != nil {
+		t.Fatalf("populate vectors: %v", err)
+	}
+	if len(batches) != 1 || len(batches[0]) != len(chunks) {
+		t.Fatalf("unexpected batches %v", batches)
+	}
+	for _, ch := range batches[0] {
+		if len(ch.Vector) != 1 || math.Abs(float64(ch.Vector[0])-1) > 1e-6 {
+			t.Fatalf("chunk %d: expected projected unit vector, got %v", ch.Index, ch.Vector)
+		}
+		if ch.NativeDim != 2 || ch.Norm == 0 {
+			t.Fatalf("chunk %d: expected native dim 2 and its norm, got %d %v", ch.Index, ch.NativeDim, ch.Norm)
+		}
+	}
+
+	wrong := &embxform.Transform{Mean: []float32{0, 0, 0}, Components: [][]float32{{1}, {0}, {0}}, Dim: 1}
+	if _, _, err := runPopulateVectorsWith(ix, wrong, chunks[:1]); err == nil || !strings.Contains(err.Error(), "native dim 3") {
+		t.Fatalf("expected a native dim mismatch, got %v", err)
+	}
+}
+
+func TestPopulateVectorsReturnsFirstError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ix := &Indexer{
+		cfg:   &config.Config{EmbedConcurrency: 4},
+		embed: embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+	}
+
+	chunks := make([]*embedChunk, embedBatchSize*4)
+	for i := range chunks {
+		text := fmt.Sprintf("chunk-%d", i)
+		chunks[i] = &embedChunk{RelPath: "f.txt", Index: i, Text: text, ContentSHA: hashBytes([]byte(text))}
+	}
+
+	_, err := runPopulateVectors(ix, chunks)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected embed error, got %v", err)
+	}
+}
+
+func TestPopulateVectorsReusesDuplicateContent(t *testing.T) {
+	var inputs int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		inputs += len(req.Input)
+		mu.Unlock()
+		type row struct {
+			Embedding []float32 `json:"embedding"`
+		}
+		var resp struct {
+			Data []row `json:"data"`
+		}
+		for _, in := range req.Input {
+			n, _ := strconv.Atoi(strings.TrimPrefix(in, "chunk-"))
+			resp.Data = append(resp.Data, row{Embedding: []float32{float32(n), 1}})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	ix := &Indexer{
+		cfg:   &config.Config{EmbedConcurrency: 3},
+		embed: embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+	}
+
+	// Five distinct texts repeated across many files.
+	chunks := make([]*embedChunk, embedBatchSize*3)
+	for i := range chunks {
+		text := fmt.Sprintf("chunk-%d", i%5)
+		chunks[i] = &embedChunk{RelPath: fmt.Sprintf("f%d.txt", i), Text: text, ContentSHA: hashBytes([]byte(text))}
+	}
+
+	batches, stats, err := runPopulateVectorsStats(ix, chunks)
+	if err != nil {
+		t.Fatalf("populate vectors: %v", err)
+	}
+	if inputs != 5 {
+		t.Fatalf("expected 5 embed inputs, got %d", inputs)
+	}
+	if stats.Chunks != len(chunks) || stats.Embedded != 5 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	i := 0
+	for _, b := range batches {
+		for _, ch := range b {
+			if ch != chunks[i] {
+				t.Fatalf("chunk %d emitted out of order", i)
+			}
+			if len(ch.Vector) != 2 || int(ch.Vector[0]) != i%5 || ch.NativeDim != 2 {
+				t.Fatalf("chunk %d: unexpected vector %v", i, ch.Vector)
+			}
+			i++
+		}
+	}
+}
+
+func TestPopulateVectorsNormalizesEmbeddings(t *testing.T) {
+	srv := newMockEmbedServer(t, 0)
+	defer srv.Close()
+
+	ix := &Indexer{
+		cfg:   &config.Config{EmbedConcurrency: 2, NormalizeEmbeddings: true},
+		embed: embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+	}
+
+	chunks := make([]*embedChunk, embedBatchSize+2)
+	for i := range chunks {
+		text := fmt.Sprintf("chunk-%d", i%7)
+		chunks[i] = &embedChunk{RelPath: "f.txt", Index: i, Text: text, ContentSHA: hashBytes([]byte(text))}
+	}
+
+	if _, err := runPopulateVectors(ix, chunks); err != nil {
+		t.Fatalf("populate vectors: %v", err)
+	}
+	for i, ch := range chunks {
+		var sum float64
+		for _, v := range ch.Vector {
+			sum += float64(v) * float64(v)
+		}
+		if math.Abs(math.Sqrt(sum)-1) > 1e-6 {
+			t.Fatalf("chunk %d: vector length %v, want 1", i, math.Sqrt(sum))
+		}
+		// The mock embeds chunk-n as (n, 1).
+		n := float64(i % 7)
+		if want := math.Sqrt(n*n + 1); math.Abs(ch.Norm-want) > 1e-6 {
+			t.Fatalf("chunk %d: norm %v, want %v", i, ch.Norm, want)
+		}
+	}
+}
+
+func TestPopulateVectorsIsolatesRejectedChunk(t *testing.T) {
+	srv := newMockEmbedServer(t, 0)
+	defer srv.Close()
+
+	chunks := func() []*embedChunk {
+		out := make([]*embedChunk, embedBatchSize+2)
+		for i := range out {
+			text := fmt.Sprintf("chunk-%d", i)
+			if i == 3 {
+				text = "chunk-bad"
+			}
+			out[i] = &embedChunk{RelPath: "f.txt", Index: i, Text: text, ContentSHA: hashBytes([]byte(text))}
+		}
+		return out
+	}
+
+	ix := &Indexer{
+		cfg:    &config.Config{EmbedConcurrency: 2},
+		embed:  embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+		logger: slog.New(slog.DiscardHandler),
+	}
+	if _, err := runPopulateVectors(ix, chunks()); err == nil || !strings.Contains(err.Error(), "f.txt chunk 3") {
+		t.Fatalf("expected error naming the rejected chunk, got %v", err)
+	}
+
+	ix.cfg.EmbedSkipFailedChunks = true
+	batches, stats, err := runPopulateVectorsStats(ix, chunks())
+	if err != nil {
+		t.Fatalf("populate vectors: %v", err)
+	}
+	var emitted []*embedChunk
+	for _, b := range batches {
+		emitted = append(emitted, b...)
+	}
+	if len(emitted) != embedBatchSize+1 {
+		t.Fatalf("expected %d chunks emitted, got %d", embedBatchSize+1, len(emitted))
+	}
+	for _, ch := range emitted {
+		if ch.Index == 3 || len(ch.Vector) != 2 || int(ch.Vector[0]) != ch.Index {
+			t.Fatalf("unexpected chunk %d with vector %v", ch.Index, ch.Vector)
+		}
+	}
+	if len(stats.Skipped) != 1 || !strings.Contains(stats.Skipped[0], "f.txt chunk 3") {
+		t.Fatalf("expected one skipped chunk, got %v", stats.Skipped)
+	}
+}
+
+func TestPopulateVectorsFailsWhenEveryChunkIsSkipped(t *testing.T) {
+	srv := newMockEmbedServer(t, 0)
+	defer srv.Close()
+
+	chunks := make([]*embedChunk, 3)
+	for i := range chunks {
+		text := fmt.Sprintf("chunk-bad-%d", i)
+		chunks[i] = &embedChunk{RelPath: "f.txt", Index: i, Text: text, ContentSHA: hashBytes([]byte(text))}
+	}
+	ix := &Indexer{
+		cfg:    &config.Config{EmbedSkipFailedChunks: true},
+		embed:  embedder.New(srv.URL, "mock", embedder.DefaultTimeout),
+		logger: slog.New(slog.DiscardHandler),
+	}
+	if _, err := runPopulateVectors(ix, chunks); err == nil || !strings.Contains(err.Error(), "rejected all 3 chunks") {
+		t.Fatalf("expected error for a run with every chunk skipped, got %v", err)
+	}
+}
+
+func TestModelDimConflict(t *testing.T) {
+	cases := []struct {
+		name     string
+		existing *storedModel
+		dim      int
+		wantErr  bool
+	}{
+		{"missing model record", nil, 768, false},
+		{"record without native_dim", &storedModel{}, 768, false},
+		{"match", &storedModel{NativeDim: 768}, 768, false},
+		{"mismatch", &storedModel{NativeDim: 768}, 1024, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := modelDimConflict("nomic", tc.existing, tc.dim)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("modelDimConflict = %v, want error %v", err, tc.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "native_dim 768, embedder returned 1024") {
+				t.Fatalf("error does not name both dimensions: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckModelDimAllowsChange(t *testing.T) {
+	// With allow_embed_dim_change the stored record is not consulted at all.
+	ix := &Indexer{cfg: &config.Config{AllowEmbedDimChange: true}}
+	if err := ix.checkModelDim(context.Background(), "nomic", 1024); err != nil {
+		t.Fatalf("checkModelDim: %v", err)
+	}
+}
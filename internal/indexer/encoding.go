@@ -0,0 +1,239 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encodings reported by detectAndConvertEncoding.
+const (
+	encUTF8        = "utf-8"
+	encUTF16LE     = "utf-16le"
+	encUTF16BE     = "utf-16be"
+	encWindows1252 = "windows-1252"
+	encLatin1      = "iso-8859-1"
+)
+
+// errNotText is returned for content that decodes to control characters in
+// every supported encoding.
+var errNotText = errors.New("content is not text in any supported encoding")
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectAndConvertEncoding returns content as UTF-8 along with the encoding it
+// was read as. Valid UTF-8 (with any BOM removed) is returned as-is. UTF-16 is
+// recognised by its BOM. Anything else is treated as a single-byte Western
+// encoding: Windows-1252 when bytes 0x80-0x9F occur, since those are control
+// codes in ISO-8859-1, and ISO-8859-1 otherwise. Content holding NUL, or
+// guessed single-byte content that decodes to control characters, is rejected
+// with errNotText.
+func detectAndConvertEncoding(content []byte) ([]byte, string, error) {
+	var name string
+	switch {
+	case bytes.HasPrefix(content, utf8BOM):
+		content = content[len(utf8BOM):]
+		if !utf8.Valid(content) {
+			return nil, "", errNotText
+		}
+		return content, encUTF8, checkText(content)
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		name = encUTF16LE
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		name = encUTF16BE
+	case utf8.Valid(content):
+		return content, encUTF8, checkText(content)
+	case hasC1Bytes(content):
+		name = encWindows1252
+	default:
+		name = encLatin1
+	}
+	out, err := encodingFor(name).NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := textCheckFor(name)(out); err != nil {
+		return nil, "", err
+	}
+	return out, name, nil
+}
+
+// DecodeText returns content as the embedder chunks it, so stored chunk
+// offsets and content hashes apply to the result rather than to the raw
+// bytes: UTF-8 without its BOM, transcoded from UTF-16 or a single-byte
+// Western encoding when content is not UTF-8.
+func DecodeText(content []byte) ([]byte, error) {
+	text, _, err := detectAndConvertEncoding(content)
+	return text, err
+}
+
+// encodingFor returns the decoder source for an encoding name reported by
+// detectAndConvertEncoding. UTF-8 maps to a decoder that drops a leading BOM.
+func encodingFor(name string) encoding.Encoding {
+	switch name {
+	case encUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case encUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case encWindows1252:
+		return charmap.Windows1252
+	case encLatin1:
+		return charmap.ISO8859_1
+	}
+	return unicode.UTF8BOM
+}
+
+// detectStreamEncoding picks the same encoding as detectAndConvertEncoding
+// while reading r through buf, so that at most len(buf) bytes are held at once.
+// buf must be at least utf8.UTFMax bytes long.
+func detectStreamEncoding(r io.Reader, buf []byte) (string, error) {
+	var (
+		first   = true
+		bom     bool
+		valid   = true
+		c1Bytes bool
+		name    string
+	)
+	err := readSlabs(r, buf, func(slab []byte, _ bool) error {
+		if first {
+			first = false
+			switch {
+			case bytes.HasPrefix(slab, utf8BOM):
+				bom = true
+			case bytes.HasPrefix(slab, []byte{0xFF, 0xFE}):
+				name = encUTF16LE
+				return errStopSlabs
+			case bytes.HasPrefix(slab, []byte{0xFE, 0xFF}):
+				name = encUTF16BE
+				return errStopSlabs
+			}
+		}
+		valid = valid && utf8.Valid(slab)
+		c1Bytes = c1Bytes || hasC1Bytes(slab)
+		if !valid && (bom || c1Bytes) {
+			return errStopSlabs
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return "", err
+	case name != "":
+		return name, nil
+	case valid:
+		return encUTF8, nil
+	case bom:
+		return "", errNotText
+	case c1Bytes:
+		return encWindows1252, nil
+	}
+	return encLatin1, nil
+}
+
+// checkTextStream runs the check for encoding name over UTF-8 text read from
+// r through buf.
+func checkTextStream(r io.Reader, buf []byte, name string) error {
+	check := textCheckFor(name)
+	return readSlabs(r, buf, func(slab []byte, _ bool) error {
+		return check(slab)
+	})
+}
+
+// errStopSlabs ends readSlabs early without reporting an error.
+var errStopSlabs = errors.New("stop reading slabs")
+
+// readSlabs fills buf from r and passes each slab to fn until r is exhausted.
+// A multi-byte UTF-8 sequence cut off at the end of a slab is carried over to
+// the start of the next one, so fn only sees complete runes unless the input
+// is not UTF-8. eof is set on the last call, whose slab may be empty. fn may
+// return errStopSlabs to stop early.
+func readSlabs(r io.Reader, buf []byte, fn func(slab []byte, eof bool) error) error {
+	keep := 0
+	for {
+		n, err := io.ReadFull(r, buf[keep:])
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return err
+		}
+		data := buf[:keep+n]
+		end := len(data)
+		if !eof {
+			end -= incompleteRuneTail(data)
+		}
+		if end > 0 || eof {
+			if err := fn(data[:end], eof); err != nil {
+				if err == errStopSlabs {
+					return nil
+				}
+				return err
+			}
+		}
+		if eof {
+			return nil
+		}
+		keep = copy(buf, data[end:])
+	}
+}
+
+// incompleteRuneTail returns how many bytes at the end of b start a UTF-8
+// sequence that b does not hold in full.
+func incompleteRuneTail(b []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if utf8.FullRune(b[len(b)-i:]) {
+				return 0
+			}
+			return i
+		}
+	}
+	return 0
+}
+
+// hasC1Bytes reports whether content has a byte in 0x80-0x9F.
+func hasC1Bytes(content []byte) bool {
+	for _, b := range content {
+		if b >= 0x80 && b <= 0x9F {
+			return true
+		}
+	}
+	return false
+}
+
+// textCheckFor returns the check for text decoded from encoding name.
+func textCheckFor(name string) func([]byte) error {
+	if name == encWindows1252 || name == encLatin1 {
+		return checkGuessedText
+	}
+	return checkText
+}
+
+// checkText fails on invalid UTF-8 and on NUL, which text never contains.
+// Other control characters (vertical tab, BEL, SUB, C1 codes) are allowed.
+func checkText(content []byte) error {
+	if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+		return errNotText
+	}
+	return nil
+}
+
+// checkGuessedText is checkText for content decoded from a guessed single-byte
+// encoding. Every byte decodes there, so control characters other than
+// whitespace and ESC are rejected too, or binary files would pass as Latin-1.
+func checkGuessedText(content []byte) error {
+	if err := checkText(content); err != nil {
+		return err
+	}
+	for _, r := range string(content) {
+		switch {
+		case r == '\t', r == '\n', r == '\r', r == '\f', r == '\v', r == 0x1B:
+		case r < 0x20, r == 0x7F, r >= 0x80 && r <= 0x9F:
+			return errNotText
+		}
+	}
+	return nil
+}
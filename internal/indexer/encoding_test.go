@@ -0,0 +1,64 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDetectAndConvertEncoding(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       []byte
+		want     string
+		encoding string
+	}{
+		{"utf-8", []byte("café\n"), "café\n", encUTF8},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFcafé"), "café", encUTF8},
+		{"latin-1", []byte("caf\xE9 cr\xE8me"), "café crème", encLatin1},
+		{"windows-1252", []byte("\x93quoted\x94 \x80 caf\xE9"), "“quoted” € café", encWindows1252},
+		{"utf-16le", []byte("\xFF\xFEh\x00i\x00"), "hi", encUTF16LE},
+	}
+	for _, tc := range cases {
+		out, enc, err := detectAndConvertEncoding(tc.in)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if string(out) != tc.want || enc != tc.encoding {
+			t.Fatalf("%s: got (%q, %s), want (%q, %s)", tc.name, out, enc, tc.want, tc.encoding)
+		}
+	}
+
+	if _, _, err := detectAndConvertEncoding([]byte("\x01\x02\xE9\x03")); !errors.Is(err, errNotText) {
+		t.Fatalf("expected errNotText for control bytes, got %v", err)
+	}
+	if _, _, err := detectAndConvertEncoding([]byte("text\x00more")); !errors.Is(err, errNotText) {
+		t.Fatalf("expected errNotText for NUL, got %v", err)
+	}
+	// Valid UTF-8 keeps its control characters.
+	in := "tab\v bell\x07 eof\x1a next\u0085line"
+	if out, enc, err := detectAndConvertEncoding([]byte(in)); err != nil || string(out) != in || enc != encUTF8 {
+		t.Fatalf("expected control characters accepted in UTF-8, got (%q, %s, %v)", out, enc, err)
+	}
+}
+
+func TestDetectStreamEncodingMatchesWholeContent(t *testing.T) {
+	inputs := [][]byte{
+		[]byte(strings.Repeat("café ", 100)),
+		append([]byte("\xEF\xBB\xBF"), strings.Repeat("é", 100)...),
+		[]byte(strings.Repeat("caf\xE9 ", 100)),
+		[]byte(strings.Repeat("plain ", 100) + "\x93quoted\x94"),
+		[]byte("\xFE\xFF\x00h\x00i"),
+	}
+	for _, in := range inputs {
+		_, want, _ := detectAndConvertEncoding(in)
+		got, err := detectStreamEncoding(bytes.NewReader(in), make([]byte, 7))
+		if err != nil {
+			t.Fatalf("detectStreamEncoding(%q): %v", in[:10], err)
+		}
+		if got != want {
+			t.Fatalf("detectStreamEncoding(%q) = %s, want %s", in[:10], got, want)
+		}
+	}
+}
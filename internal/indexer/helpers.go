@@ -78,3 +78,8 @@ func relationEndpoint(record string) string {
 	}
 	return "(" + record + ")"
 }
+
+// relateStatement builds a RELATE statement for use in a multi-statement batch.
+func relateStatement(inTable, inID, relation, outTable, outID string) string {
+	return "RELATE " + relationEndpoint(record(inTable, inID)) + "->" + relation + "->" + relationEndpoint(record(outTable, outID))
+}
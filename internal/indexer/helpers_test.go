@@ -0,0 +1,21 @@
+package indexer
+
+import "testing"
+
+func TestVectorChunkIDIsStable(t *testing.T) {
+	a := vectorChunkID("ws1", "file-abc", "chunk", 3)
+	if b := vectorChunkID("ws1", "file-abc", "chunk", 3); a != b {
+		t.Fatalf("same arguments gave %q and %q", a, b)
+	}
+	seen := map[string]int{a: 3}
+	for _, i := range []int{0, 1, 2, 4, 30, 300} {
+		id := vectorChunkID("ws1", "file-abc", "chunk", i)
+		if prev, ok := seen[id]; ok {
+			t.Fatalf("indices %d and %d share id %q", prev, i, id)
+		}
+		seen[id] = i
+	}
+	if id := vectorChunkID("ws1", "file-xyz", "chunk", 3); id == a {
+		t.Fatalf("different files share id %q", id)
+	}
+}
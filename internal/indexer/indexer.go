@@ -3,15 +3,18 @@ package indexer
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/CryingSurrogate/chaosmith-core/internal/config"
 	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
 	"github.com/CryingSurrogate/chaosmith-core/internal/runctx"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // Step identifiers used for run IDs and reporting.
@@ -28,6 +31,10 @@ type WorkspaceRequest struct {
 	WorkspaceID   string `json:"workspaceId"`
 	RunID         string `json:"runId,omitempty"`
 	NodeID        string `json:"nodeId,omitempty"`
+
+	// Request is the originating tool call, used to send progress
+	// notifications when the client supplied a progress token.
+	Request *mcp.CallToolRequest `json:"-"`
 }
 
 // RunReport summarises execution for the orchestrator per PCS/INST/1.0 style guide.
@@ -47,118 +54,138 @@ type Indexer struct {
 	cfg     *config.Config
 	surreal *surreal.Client
 	embed   *embedder.Client
-	chunker *tokenChunker
+	chunker textChunker
+	// paragraphs is set when cfg.ChunkerKind is paragraph or auto.
+	paragraphs *paragraphChunker
+	logger     *slog.Logger
+
+	runsMu sync.Mutex
+	runs   map[string]*activeRun
 }
 
-// New builds an Indexer from configuration and Surreal client.
-func New(cfg *config.Config, surrealClient *surreal.Client) (*Indexer, error) {
+// New builds an Indexer from configuration and Surreal client. A nil logger
+// uses slog.Default().
+func New(cfg *config.Config, surrealClient *surreal.Client, logger *slog.Logger) (*Indexer, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config is required")
 	}
 	if surrealClient == nil {
 		return nil, fmt.Errorf("surreal client is required")
 	}
-	embedClient := embedder.New(cfg.EmbedURL, cfg.EmbedModel)
-	chunker, err := newTokenChunker(cfg.TokenizerID)
+	if logger == nil {
+		logger = slog.Default()
+	}
+	embedClient, err := embedder.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("embedder init: %w", err)
+	}
+	embedClient.Logger = logger
+	chunker, err := newTokenChunker(cfg.TokenizerID, cfg.MinChunkTokens, cfg.MaxChunkTokens)
 	if err != nil {
 		return nil, fmt.Errorf("tokenizer init: %w", err)
 	}
+	var paragraphs *paragraphChunker
+	if cfg.ChunkerKind == "paragraph" || cfg.ChunkerKind == "auto" {
+		if paragraphs, err = newParagraphChunker(cfg.TokenizerID, cfg.MinChunkTokens, cfg.MaxChunkTokens); err != nil {
+			return nil, fmt.Errorf("tokenizer init: %w", err)
+		}
+	}
 	return &Indexer{
-		cfg:     cfg,
-		surreal: surrealClient,
-		embed:   embedClient,
-		chunker: chunker,
+		cfg:        cfg,
+		surreal:    surrealClient,
+		embed:      embedClient,
+		chunker:    chunker,
+		paragraphs: paragraphs,
+		logger:     logger,
 	}, nil
 }
 
 // Scan indexes directories and files into SurrealDB.
 func (ix *Indexer) Scan(ctx context.Context, req WorkspaceRequest) (*RunReport, error) {
-	if err := validateWorkspaceRequest(req); err != nil {
-		return nil, err
-	}
-	run, err := runctx.New(ix.cfg.ArtifactRoot, req.RunID, req.WorkspaceID, req.WorkspaceRoot, StepScan, time.Now().UTC())
+	run, report, err := ix.prepareRun(ctx, &req, StepScan)
 	if err != nil {
-		return nil, err
+		return report, err
 	}
-	report := &RunReport{
-		RunID:   run.RunID,
-		Step:    StepScan,
-		Started: run.Started,
-		Risks:   []string{},
-		Notes:   []string{},
+	prog := newProgressReporter(req.Request, ix.runLogger(run))
+	ctx, done, err := ix.startRun(ctx, run, report)
+	if err != nil {
+		failRun(ctx, report, err.Error())
+		return report, err
 	}
+	defer done()
 
-	scanRes, err := ix.performScan(ctx, run)
+	scanRes, err := ix.performScan(ctx, run, prog)
 	if err != nil {
-		report.Acceptance = "fail"
-		report.Risks = append(report.Risks, err.Error())
+		failRun(ctx, report, err.Error())
 		return report, err
 	}
 
 	report.Finished = time.Now().UTC()
 	report.Acceptance = "pass"
 	report.ArtifactPaths = append(report.ArtifactPaths, scanRes.Artifacts...)
+	if scanRes.SymlinksFollowed > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("scan followed %d symlinks", scanRes.SymlinksFollowed))
+	}
 	return report, nil
 }
 
 // Embed produces vectors for the workspace and stores them.
 func (ix *Indexer) Embed(ctx context.Context, req WorkspaceRequest) (*RunReport, error) {
-	if err := validateWorkspaceRequest(req); err != nil {
-		return nil, err
-	}
-	run, err := runctx.New(ix.cfg.ArtifactRoot, req.RunID, req.WorkspaceID, req.WorkspaceRoot, StepEmbed, time.Now().UTC())
+	run, report, err := ix.prepareRun(ctx, &req, StepEmbed)
 	if err != nil {
-		return nil, err
+		return report, err
 	}
-	report := &RunReport{
-		RunID:   run.RunID,
-		Step:    StepEmbed,
-		Started: run.Started,
-		Risks:   []string{},
-		Notes:   []string{},
+	prog := newProgressReporter(req.Request, ix.runLogger(run))
+	ctx, done, err := ix.startRun(ctx, run, report)
+	if err != nil {
+		failRun(ctx, report, err.Error())
+		return report, err
 	}
+	defer done()
 
-	embedRes, err := ix.performEmbedding(ctx, run)
+	embedRes, err := ix.performEmbedding(ctx, run, prog)
 	if err != nil {
-		report.Acceptance = "fail"
-		report.Risks = append(report.Risks, err.Error())
+		failRun(ctx, report, err.Error())
 		return report, err
 	}
 
 	report.Finished = time.Now().UTC()
 	report.Acceptance = "pass"
 	report.ArtifactPaths = append(report.ArtifactPaths, embedRes.Artifacts...)
+	report.Risks = append(report.Risks, embedRes.Stats.Skipped...)
+	if n := embedRes.Stats.SkippedFiles; n > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("embed skipped %d files that are not decodable text", n))
+	}
+	if n := embedRes.DroppedShortChunks; n > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("embed dropped %d chunks shorter than %d tokens", n, ix.cfg.MinChunkTokens))
+	}
+	report.Notes = append(report.Notes, dedupNote(embedRes.Stats))
 	return report, nil
 }
 
 // All runs scan then embed sequentially.
 func (ix *Indexer) All(ctx context.Context, req WorkspaceRequest) (*RunReport, error) {
-	if err := validateWorkspaceRequest(req); err != nil {
-		return nil, err
-	}
-	run, err := runctx.New(ix.cfg.ArtifactRoot, req.RunID, req.WorkspaceID, req.WorkspaceRoot, StepAll, time.Now().UTC())
+	run, report, err := ix.prepareRun(ctx, &req, StepAll)
 	if err != nil {
-		return nil, err
+		return report, err
 	}
-	report := &RunReport{
-		RunID:   run.RunID,
-		Step:    StepAll,
-		Started: run.Started,
-		Risks:   []string{},
-		Notes:   []string{},
+	prog := newProgressReporter(req.Request, ix.runLogger(run))
+	ctx, done, err := ix.startRun(ctx, run, report)
+	if err != nil {
+		failRun(ctx, report, err.Error())
+		return report, err
 	}
+	defer done()
 
-	scanRes, err := ix.performScan(ctx, run)
+	scanRes, err := ix.performScan(ctx, run, prog)
 	if err != nil {
-		report.Acceptance = "fail"
-		report.Risks = append(report.Risks, fmt.Sprintf("scan failed: %s", err))
+		failRun(ctx, report, fmt.Sprintf("scan failed: %s", err))
 		report.ArtifactPaths = append(report.ArtifactPaths, scanRes.Artifacts...)
 		return report, err
 	}
-	embedRes, err := ix.performEmbedding(ctx, run)
+	embedRes, err := ix.performEmbedding(ctx, run, prog)
 	if err != nil {
-		report.Acceptance = "fail"
-		report.Risks = append(report.Risks, fmt.Sprintf("embedding failed: %s", err))
+		failRun(ctx, report, fmt.Sprintf("embedding failed: %s", err))
 		report.ArtifactPaths = append(report.ArtifactPaths, append(scanRes.Artifacts, embedRes.Artifacts...)...)
 		return report, err
 	}
@@ -166,10 +193,84 @@ func (ix *Indexer) All(ctx context.Context, req WorkspaceRequest) (*RunReport, e
 	report.Finished = time.Now().UTC()
 	report.Acceptance = "pass"
 	report.ArtifactPaths = append(report.ArtifactPaths, append(scanRes.Artifacts, embedRes.Artifacts...)...)
+	report.Risks = append(report.Risks, embedRes.Stats.Skipped...)
+	if scanRes.SymlinksFollowed > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("scan followed %d symlinks", scanRes.SymlinksFollowed))
+	}
+	if n := embedRes.Stats.SkippedFiles; n > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("embed skipped %d files that are not decodable text", n))
+	}
+	if n := embedRes.DroppedShortChunks; n > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("embed dropped %d chunks shorter than %d tokens", n, ix.cfg.MinChunkTokens))
+	}
+	report.Notes = append(report.Notes, dedupNote(embedRes.Stats))
 	return report, nil
 }
 
-func validateWorkspaceRequest(req WorkspaceRequest) error {
+// prepareRun validates req and creates the run for step along with its
+// report. When either fails the report is still returned, marked as failed
+// with Finished set, so callers always get a report to pass on.
+func (ix *Indexer) prepareRun(ctx context.Context, req *WorkspaceRequest, step string) (*runctx.Run, *RunReport, error) {
+	started := time.Now().UTC()
+	runID := req.RunID
+	if runID == "" {
+		runID = runctx.GenerateRunID(req.WorkspaceID, step, started)
+	}
+	report := &RunReport{
+		RunID:   runID,
+		Step:    step,
+		Started: started,
+		Risks:   []string{},
+		Notes:   []string{},
+	}
+	if err := validateWorkspaceRequest(req); err != nil {
+		failRun(ctx, report, err.Error())
+		return nil, report, err
+	}
+	run, err := runctx.New(ix.cfg.ArtifactRoot, runID, req.WorkspaceID, req.WorkspaceRoot, step, started)
+	if err != nil {
+		failRun(ctx, report, err.Error())
+		return nil, report, err
+	}
+	run.Fingerprint = ix.fingerprint()
+	return run, report, nil
+}
+
+// fingerprint describes the embedding configuration for run manifests.
+func (ix *Indexer) fingerprint() runctx.Fingerprint {
+	return runctx.Fingerprint{
+		EmbedModel:    ix.cfg.EmbedModel,
+		EmbedModelSHA: ix.cfg.EmbedModelSHA,
+		TransformID:   ix.cfg.TransformID,
+		TokenizerID:   ix.cfg.TokenizerID,
+	}
+}
+
+func (ix *Indexer) log() *slog.Logger {
+	if ix.logger != nil {
+		return ix.logger
+	}
+	return slog.Default()
+}
+
+// runLogger returns a logger annotated with the run's identifying fields.
+func (ix *Indexer) runLogger(run *runctx.Run) *slog.Logger {
+	return ix.log().With("run_id", run.RunID, "workspace", run.WorkspaceID, "step", run.Step)
+}
+
+// dedupNote summarises how many chunks reused a vector from an identical chunk.
+func dedupNote(stats embedStats) string {
+	reused := stats.Chunks - stats.Embedded
+	ratio := 0.0
+	if stats.Chunks > 0 {
+		ratio = float64(reused) / float64(stats.Chunks)
+	}
+	return fmt.Sprintf("embed dedup: %d/%d chunks reused an existing vector (ratio %.3f); %d embedded", reused, stats.Chunks, ratio, stats.Embedded)
+}
+
+// validateWorkspaceRequest checks req and makes its WorkspaceRoot absolute, so
+// run records and artifacts never depend on the server's working directory.
+func validateWorkspaceRequest(req *WorkspaceRequest) error {
 	if strings.TrimSpace(req.WorkspaceRoot) == "" {
 		return fmt.Errorf("workspaceRoot is required")
 	}
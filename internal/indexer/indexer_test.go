@@ -0,0 +1,53 @@
+package indexer
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+)
+
+func TestValidateWorkspaceRequestMakesRootAbsolute(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	req := WorkspaceRequest{WorkspaceRoot: ".", WorkspaceID: "ws1"}
+	if err := validateWorkspaceRequest(&req); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !filepath.IsAbs(req.WorkspaceRoot) {
+		t.Fatalf("expected an absolute root, got %q", req.WorkspaceRoot)
+	}
+	got, _ := os.Stat(req.WorkspaceRoot)
+	want, _ := os.Stat(dir)
+	if !os.SameFile(got, want) {
+		t.Fatalf("root %q is not %q", req.WorkspaceRoot, dir)
+	}
+}
+
+func TestMissingWorkspaceRootFinishesReport(t *testing.T) {
+	ix := &Indexer{cfg: &config.Config{ArtifactRoot: t.TempDir()}, logger: slog.New(slog.DiscardHandler)}
+	req := WorkspaceRequest{WorkspaceRoot: filepath.Join(t.TempDir(), "missing"), WorkspaceID: "ws1"}
+	steps := map[string]func(context.Context, WorkspaceRequest) (*RunReport, error){
+		StepScan:  ix.Scan,
+		StepEmbed: ix.Embed,
+		StepAll:   ix.All,
+	}
+	for step, run := range steps {
+		report, err := run(context.Background(), req)
+		if err == nil {
+			t.Fatalf("%s: expected an error for a missing workspace root", step)
+		}
+		if report == nil {
+			t.Fatalf("%s: expected a report alongside the error", step)
+		}
+		if report.Finished.IsZero() || report.Finished.Before(report.Started) {
+			t.Fatalf("%s: expected finished at or after %v, got %v", step, report.Started, report.Finished)
+		}
+		if report.Acceptance != "fail" || report.Step != step || report.RunID == "" || len(report.Risks) != 1 {
+			t.Fatalf("%s: unexpected report %+v", step, report)
+		}
+	}
+}
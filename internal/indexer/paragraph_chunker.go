@@ -0,0 +1,107 @@
+package indexer
+
+import "fmt"
+
+// paragraphChunker keeps prose together: it splits text on blank lines, merges
+// adjacent paragraphs while they fit in maxTokens, and only cuts a paragraph by
+// token count when it alone exceeds the budget.
+type paragraphChunker struct {
+	tok *tokenChunker
+}
+
+func newParagraphChunker(tokenizerID string, minTokens, maxTokens int) (*paragraphChunker, error) {
+	tok, err := newTokenChunker(tokenizerID, minTokens, maxTokens)
+	if err != nil {
+		return nil, err
+	}
+	return &paragraphChunker{tok: tok}, nil
+}
+
+// chunk returns the chunks of text and how many were dropped for having fewer
+// than minTokens tokens.
+func (c *paragraphChunker) chunk(text string) ([]tokenChunk, int, error) {
+	segments, err := c.split(text)
+	if err != nil {
+		return nil, 0, err
+	}
+	kept, dropped := dropShortChunks(segments, c.tok.minTokens)
+	return kept, dropped, nil
+}
+
+func (c *paragraphChunker) minChunkTokens() int { return c.tok.minTokens }
+
+// split groups whole paragraphs into chunks. A chunk's token count is the sum
+// of its paragraphs' counts.
+func (c *paragraphChunker) split(text string) ([]tokenChunk, error) {
+	if c == nil || c.tok == nil || c.tok.enc == nil {
+		return nil, fmt.Errorf("paragraph chunker not initialised")
+	}
+	var (
+		chunks     []tokenChunk
+		start, end int // bytes of the chunk being built
+		tokens     int
+	)
+	flush := func() {
+		if end > start {
+			chunks = append(chunks, tokenChunk{
+				Text:       text[start:end],
+				Start:      start,
+				End:        end,
+				TokenCount: tokens,
+			})
+		}
+		start, tokens = end, 0
+	}
+	for _, para := range paragraphs(text) {
+		n := len(c.tok.enc.Encode(text[para[0]:para[1]], nil, nil))
+		if n > c.tok.maxTokens {
+			flush()
+			parts, err := c.tok.split(text[para[0]:para[1]])
+			if err != nil {
+				return nil, err
+			}
+			for _, part := range parts {
+				part.Start += para[0]
+				part.End += para[0]
+				chunks = append(chunks, part)
+			}
+			start, end = para[1], para[1]
+			continue
+		}
+		if tokens > 0 && tokens+n > c.tok.maxTokens {
+			flush()
+		}
+		end = para[1]
+		tokens += n
+	}
+	flush()
+	return chunks, nil
+}
+
+// paragraphs returns the byte ranges of the paragraphs in text. Each range
+// includes the blank lines after it, so together they cover text exactly.
+func paragraphs(text string) [][2]int {
+	var out [][2]int
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\n' {
+			continue
+		}
+		j := i + 1
+		if j < len(text) && text[j] == '\r' {
+			j++
+		}
+		if j >= len(text) || text[j] != '\n' {
+			continue
+		}
+		for j < len(text) && (text[j] == '\n' || text[j] == '\r') {
+			j++
+		}
+		out = append(out, [2]int{start, j})
+		start, i = j, j-1
+	}
+	if start < len(text) {
+		out = append(out, [2]int{start, len(text)})
+	}
+	return out
+}
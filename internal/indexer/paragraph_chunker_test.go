@@ -0,0 +1,54 @@
+package indexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParagraphsCoverText(t *testing.T) {
+	text := "# Title\n\nFirst paragraph\nstill first.\n\n\nSecond.\r\n\r\nThird"
+	var got []string
+	prevEnd := 0
+	for _, p := range paragraphs(text) {
+		if p[0] != prevEnd {
+			t.Fatalf("paragraph starts at %d, want %d", p[0], prevEnd)
+		}
+		got = append(got, text[p[0]:p[1]])
+		prevEnd = p[1]
+	}
+	want := []string{"# Title\n\n", "First paragraph\nstill first.\n\n\n", "Second.\r\n\r\n", "Third"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("paragraphs = %q, want %q", got, want)
+	}
+}
+
+func TestParagraphChunkerKeepsParagraphsWhole(t *testing.T) {
+	chunker, err := newParagraphChunker("tiktoken/cl100k_base", 0, 50)
+	if err != nil {
+		t.Fatalf("new paragraph chunker: %v", err)
+	}
+	para := strings.Repeat("word ", 20) + "\n\n" // about 21 tokens
+	long := strings.Repeat("long ", 120)         // more than the budget on its own
+	input := para + para + para + long
+
+	segments, err := chunker.split(input)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if len(segments) < 3 {
+		t.Fatalf("expected at least 3 chunks, got %d", len(segments))
+	}
+	if segments[0].Text != para+para || segments[1].Text != para {
+		t.Fatalf("paragraphs were not merged greedily: %q, %q", segments[0].Text, segments[1].Text)
+	}
+	var rebuilt strings.Builder
+	for i, seg := range segments {
+		if seg.TokenCount > 50 {
+			t.Fatalf("segment %d exceeds token budget: %d", i, seg.TokenCount)
+		}
+		rebuilt.WriteString(seg.Text)
+	}
+	if rebuilt.String() != input {
+		t.Fatalf("rebuilt text mismatch")
+	}
+}
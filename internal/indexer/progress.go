@@ -0,0 +1,91 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressInterval throttles progress notifications so large runs do not flood
+// the client.
+const progressInterval = 500 * time.Millisecond
+
+// progressReporter emits MCP progress notifications for a tool call. A nil
+// reporter is valid and does nothing, which is what newProgressReporter returns
+// when the request carries no progress token.
+type progressReporter struct {
+	session *mcp.ServerSession
+	token   any
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	progress float64
+	order    []string
+	counts   map[string]int
+	last     time.Time
+}
+
+func newProgressReporter(req *mcp.CallToolRequest, logger *slog.Logger) *progressReporter {
+	if req == nil || req.Session == nil || req.Params == nil {
+		return nil
+	}
+	token := req.Params.GetProgressToken()
+	if token == nil {
+		return nil
+	}
+	return &progressReporter{
+		session: req.Session,
+		token:   token,
+		logger:  logger,
+		counts:  make(map[string]int),
+	}
+}
+
+// add records n more units of work for counter (e.g. "files walked") and sends
+// a notification if the throttle interval has elapsed.
+func (p *progressReporter) add(ctx context.Context, counter string, n int) {
+	if p == nil || n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.counts[counter]; !ok {
+		p.order = append(p.order, counter)
+	}
+	p.counts[counter] += n
+	p.progress += float64(n)
+	if time.Since(p.last) >= progressInterval {
+		p.notifyLocked(ctx)
+	}
+}
+
+// flush sends the current counters regardless of the throttle, so each stage
+// ends with an accurate notification.
+func (p *progressReporter) flush(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notifyLocked(ctx)
+}
+
+func (p *progressReporter) notifyLocked(ctx context.Context) {
+	parts := make([]string, 0, len(p.order))
+	for _, name := range p.order {
+		parts = append(parts, fmt.Sprintf("%s: %d", name, p.counts[name]))
+	}
+	p.last = time.Now()
+	if err := p.session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: p.token,
+		Message:       strings.Join(parts, ", "),
+		Progress:      p.progress,
+	}); err != nil {
+		p.logger.Warn("progress notification failed", "err", err)
+	}
+}
@@ -0,0 +1,178 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
+	"github.com/CryingSurrogate/chaosmith-core/internal/runctx"
+)
+
+// noteCancelled is added to a RunReport when the run stopped because it was
+// cancelled through CancelRun or by the caller.
+const noteCancelled = "cancelled"
+
+// activeRun is an in-flight run as tracked by the Indexer.
+type activeRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startRun registers run as in flight and returns a context that CancelRun
+// can cancel. The returned func saves report, deregisters the run and records
+// its outcome; it must be called when the run finishes.
+func (ix *Indexer) startRun(ctx context.Context, run *runctx.Run, report *RunReport) (context.Context, func(), error) {
+	runID := run.RunID
+	ctx, cancel := context.WithCancel(ctx)
+	ix.runsMu.Lock()
+	defer ix.runsMu.Unlock()
+	if ix.runs == nil {
+		ix.runs = make(map[string]*activeRun)
+	}
+	if _, exists := ix.runs[runID]; exists {
+		cancel()
+		return nil, nil, fmt.Errorf("run %s is already in progress", runID)
+	}
+	active := &activeRun{cancel: cancel, done: make(chan struct{})}
+	ix.runs[runID] = active
+	ix.runLogger(run).Info("index run started")
+	parent := ctx
+	return ctx, func() {
+		ix.saveReport(parent, run, report)
+		ix.runsMu.Lock()
+		delete(ix.runs, runID)
+		ix.runsMu.Unlock()
+		cancel()
+		close(active.done)
+		metrics.IndexRuns.WithLabelValues(run.WorkspaceID, run.Step, report.Acceptance).Inc()
+	}, nil
+}
+
+// ActiveRuns returns the number of index runs in progress.
+func (ix *Indexer) ActiveRuns() int {
+	ix.runsMu.Lock()
+	defer ix.runsMu.Unlock()
+	return len(ix.runs)
+}
+
+// CancelRun cancels the in-flight run with the given id. It reports false when
+// no such run is active.
+func (ix *Indexer) CancelRun(runID string) bool {
+	ix.runsMu.Lock()
+	active, ok := ix.runs[runID]
+	ix.runsMu.Unlock()
+	if ok {
+		active.cancel()
+	}
+	return ok
+}
+
+// drainUnwindTimeout bounds how long Drain waits for a cancelled run to
+// unwind, so a run stuck outside its context cannot hold up shutdown.
+var drainUnwindTimeout = 10 * time.Second
+
+// Drain waits for in-flight runs to finish on their own until ctx is done,
+// then cancels whatever is still running and waits up to drainUnwindTimeout
+// for those runs to unwind. It returns the number of runs that had to be
+// cancelled; runs still unwinding when the timeout expires are logged and
+// left behind.
+func (ix *Indexer) Drain(ctx context.Context) int {
+	ix.runsMu.Lock()
+	active := make(map[string]*activeRun, len(ix.runs))
+	for id, run := range ix.runs {
+		active[id] = run
+	}
+	ix.runsMu.Unlock()
+
+	cancelled := 0
+	var unwind context.Context
+	for id, run := range active {
+		select {
+		case <-run.done:
+			continue
+		case <-ctx.Done():
+		}
+		// Once the deadline has passed select picks at random between ready
+		// cases, so check again before counting a finished run as cancelled.
+		select {
+		case <-run.done:
+			continue
+		default:
+		}
+		run.cancel()
+		cancelled++
+		if unwind == nil {
+			var cancel context.CancelFunc
+			unwind, cancel = context.WithTimeout(context.Background(), drainUnwindTimeout)
+			defer cancel()
+		}
+		select {
+		case <-run.done:
+		case <-unwind.Done():
+			ix.log().Warn("cancelled index run did not stop in time", "run_id", id)
+		}
+	}
+	return cancelled
+}
+
+// failRun marks report as failed with risk, stamps when it finished and notes
+// cancellation when ctx was cancelled.
+func failRun(ctx context.Context, report *RunReport, risk string) {
+	report.Finished = time.Now().UTC()
+	report.Acceptance = "fail"
+	report.Risks = append(report.Risks, risk)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		report.Notes = append(report.Notes, noteCancelled)
+	}
+}
+
+// saveReportTimeout bounds persisting a report once its run has finished,
+// which may be because the run's context was cancelled.
+const saveReportTimeout = 10 * time.Second
+
+// saveReport writes the run manifest, including report, to the run's artifact
+// directory and upserts report into the run table, which is what run_list and
+// run_get read.
+// Failures are logged rather than returned so they cannot change the outcome
+// of a run that has already finished.
+func (ix *Indexer) saveReport(ctx context.Context, run *runctx.Run, report *RunReport) {
+	if report.Finished.IsZero() {
+		report.Finished = time.Now().UTC()
+	}
+	logger := ix.runLogger(run)
+	if run.ArtifactDir != "" {
+		if _, err := run.WriteManifest(report); err != nil {
+			logger.Warn("write run manifest failed", "err", err)
+		}
+	}
+	if ix.surreal == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveReportTimeout)
+	defer cancel()
+	err := ix.surreal.UpsertRecord(ctx, "run", report.RunID, map[string]any{
+		"run_id":         report.RunID,
+		"workspace_id":   run.WorkspaceID,
+		"step":           report.Step,
+		"started":        report.Started,
+		"finished":       report.Finished,
+		"acceptance":     report.Acceptance,
+		"risks":          nonNil(report.Risks),
+		"notes":          nonNil(report.Notes),
+		"artifact_paths": nonNil(report.ArtifactPaths),
+	})
+	if err != nil {
+		logger.Warn("store run report failed", "err", err)
+	}
+}
+
+// nonNil returns s, or an empty slice when s is nil, since the run table's
+// array fields reject NONE.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
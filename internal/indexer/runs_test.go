@@ -0,0 +1,116 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/runctx"
+)
+
+func TestDrainWaitsThenCancels(t *testing.T) {
+	ix := &Indexer{logger: slog.New(slog.DiscardHandler)}
+
+	// A run that finishes on its own within the drain window is not cancelled.
+	_, finishQuick, err := ix.startRun(context.Background(), &runctx.Run{RunID: "quick"}, &RunReport{Acceptance: "pass"})
+	if err != nil {
+		t.Fatalf("start quick run: %v", err)
+	}
+	// A run that only stops when its context is cancelled.
+	slowCtx, finishSlow, err := ix.startRun(context.Background(), &runctx.Run{RunID: "slow"}, &RunReport{Acceptance: "fail"})
+	if err != nil {
+		t.Fatalf("start slow run: %v", err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		finishQuick()
+	}()
+	go func() {
+		<-slowCtx.Done()
+		finishSlow()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if n := ix.Drain(ctx); n != 1 {
+		t.Fatalf("expected 1 cancelled run, got %d", n)
+	}
+	if len(ix.runs) != 0 {
+		t.Fatalf("expected no runs after drain, got %d", len(ix.runs))
+	}
+	if ix.CancelRun("slow") {
+		t.Fatalf("drained run should be deregistered")
+	}
+}
+
+func TestDrainGivesUpOnRunsThatIgnoreCancel(t *testing.T) {
+	defer func(d time.Duration) { drainUnwindTimeout = d }(drainUnwindTimeout)
+	drainUnwindTimeout = 20 * time.Millisecond
+
+	ix := &Indexer{logger: slog.New(slog.DiscardHandler)}
+	// Two runs that never finish, even once cancelled.
+	for _, id := range []string{"stuck-1", "stuck-2"} {
+		if _, _, err := ix.startRun(context.Background(), &runctx.Run{RunID: id}, &RunReport{}); err != nil {
+			t.Fatalf("start run %s: %v", id, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan int, 1)
+	go func() { done <- ix.Drain(ctx) }()
+	select {
+	case n := <-done:
+		if n != 2 {
+			t.Fatalf("expected 2 cancelled runs, got %d", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("drain blocked on runs that ignore cancellation")
+	}
+}
+
+func TestSaveReportWritesManifest(t *testing.T) {
+	ix := &Indexer{logger: slog.New(slog.DiscardHandler)}
+	run := &runctx.Run{RunID: "RUN-1", ArtifactDir: t.TempDir(), Fingerprint: runctx.Fingerprint{TokenizerID: "tiktoken/cl100k_base"}}
+	report := &RunReport{RunID: "RUN-1", Step: StepScan, Acceptance: "fail", Risks: []string{"boom"}}
+
+	ix.saveReport(context.Background(), run, report)
+
+	data, err := os.ReadFile(filepath.Join(run.ArtifactDir, runctx.ManifestName))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var got struct {
+		Fingerprint runctx.Fingerprint `json:"fingerprint"`
+		Report      RunReport          `json:"report"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if got.Fingerprint.TokenizerID != "tiktoken/cl100k_base" {
+		t.Fatalf("unexpected fingerprint %+v", got.Fingerprint)
+	}
+	if r := got.Report; r.RunID != "RUN-1" || r.Acceptance != "fail" || len(r.Risks) != 1 || r.Finished.IsZero() {
+		t.Fatalf("unexpected report %+v", r)
+	}
+}
+
+func TestFailRunSetsFinished(t *testing.T) {
+	started := time.Now().UTC()
+	report := &RunReport{Started: started}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	failRun(ctx, report, "scan failed: boom")
+
+	if report.Finished.IsZero() || report.Finished.Before(started) {
+		t.Fatalf("expected finished at or after %v, got %v", started, report.Finished)
+	}
+	if report.Acceptance != "fail" || len(report.Risks) != 1 || len(report.Notes) != 1 || report.Notes[0] != noteCancelled {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
@@ -1,13 +1,17 @@
 package indexer
 
 import (
+	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -16,8 +20,13 @@ import (
 	"github.com/zeebo/blake3"
 )
 
+// maxStoredContentBytes bounds the text files whose content is stored on the
+// file record for full-text search.
+const maxStoredContentBytes = 1 << 20
+
 type scanResult struct {
-	Artifacts []string
+	Artifacts        []string
+	SymlinksFollowed int
 }
 
 type dirMeta struct {
@@ -27,14 +36,15 @@ type dirMeta struct {
 }
 
 type fileMeta struct {
-	RelPath string    `json:"relpath"`
-	Size    int64     `json:"size"`
-	MTime   time.Time `json:"mtime"`
-	Hash    string    `json:"hash"`
-	Lang    string    `json:"lang"`
+	RelPath  string    `json:"relpath"`
+	Size     int64     `json:"size"`
+	MTime    time.Time `json:"mtime"`
+	Hash     string    `json:"hash"`
+	Lang     string    `json:"lang"`
+	MimeType string    `json:"mime_type"`
 }
 
-func (ix *Indexer) performScan(ctx context.Context, run *runctx.Run) (*scanResult, error) {
+func (ix *Indexer) performScan(ctx context.Context, run *runctx.Run, prog *progressReporter) (*scanResult, error) {
 	root := run.WorkspaceRoot
 	wsID := run.WorkspaceID
 
@@ -48,58 +58,11 @@ func (ix *Indexer) performScan(ctx context.Context, run *runctx.Run) (*scanResul
 		return &scanResult{}, fmt.Errorf("surreal merge workspace %s: %w", wsID, err)
 	}
 
-	var dirs []dirMeta
-	var files []fileMeta
-
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		if d.IsDir() && shouldSkipDir(d.Name()) {
-			return filepath.SkipDir
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return err
-		}
-
-		rel := normalizeRelPath(root, path)
-		if d.IsDir() {
-			dHash := hashString(path)
-			dirs = append(dirs, dirMeta{
-				RelPath: rel,
-				Hash:    dHash,
-				ModTime: info.ModTime().UTC(),
-			})
-			return nil
-		}
-
-		if !info.Mode().IsRegular() {
-			return nil
-		}
-		hash, err := hashFile(path)
-		if err != nil {
-			return fmt.Errorf("hash file %s: %w", path, err)
-		}
-		files = append(files, fileMeta{
-			RelPath: rel,
-			Size:    info.Size(),
-			MTime:   info.ModTime().UTC(),
-			Hash:    hash,
-			Lang:    detectLanguage(path),
-		})
-		return nil
-	})
+	walk, err := ix.walkWorkspace(ctx, root, prog)
 	if err != nil {
 		return &scanResult{}, err
 	}
+	dirs, files := walk.dirs, walk.files
 
 	// Upsert directories and relations using SDK helpers
 	for _, dir := range dirs {
@@ -125,14 +88,20 @@ func (ix *Indexer) performScan(ctx context.Context, run *runctx.Run) (*scanResul
 	// Upsert files and relate to parent directory
 	for _, file := range files {
 		fileRecID := fileID(wsID, file.RelPath)
-		if err := ix.surreal.UpsertRecord(ctx, "file", fileRecID, map[string]any{
-			"ws":      surrealmodels.NewRecordID("workspace", wsID),
-			"relpath": file.RelPath,
-			"lang":    file.Lang,
-			"size":    file.Size,
-			"mtime":   file.MTime,
-			"sha":     file.Hash,
-		}); err != nil {
+		record := map[string]any{
+			"ws":        surrealmodels.NewRecordID("workspace", wsID),
+			"relpath":   file.RelPath,
+			"lang":      file.Lang,
+			"size":      file.Size,
+			"mtime":     file.MTime,
+			"sha":       file.Hash,
+			"mime_type": file.MimeType,
+		}
+		if content, encoding, ok := readTextContent(filepath.Join(root, filepath.FromSlash(file.RelPath)), file.Size); ok {
+			record["content"] = content
+			record["encoding"] = encoding
+		}
+		if err := ix.surreal.UpsertRecord(ctx, "file", fileRecID, record); err != nil {
 			return &scanResult{}, fmt.Errorf("upsert file %s: %w", file.RelPath, err)
 		}
 		dirRel := parentDirRel(file.RelPath)
@@ -140,7 +109,9 @@ func (ix *Indexer) performScan(ctx context.Context, run *runctx.Run) (*scanResul
 		if err := ix.surreal.Relate(ctx, "directory", dirRecID, "dir_contains_file", "file", fileRecID, nil); err != nil {
 			return &scanResult{}, fmt.Errorf("relate dir->file %s: %w", file.RelPath, err)
 		}
+		prog.add(ctx, "files stored", 1)
 	}
+	prog.flush(ctx)
 
 	var artifacts []string
 	filesArtifact, err := ix.writeNDJSON(run.ArtifactDir, "files.ndjson", files)
@@ -157,50 +128,345 @@ func (ix *Indexer) performScan(ctx context.Context, run *runctx.Run) (*scanResul
 	run.AddArtifact(dirsArtifact)
 	artifacts = append(artifacts, dirsArtifact)
 
-	return &scanResult{Artifacts: artifacts}, nil
+	return &scanResult{Artifacts: artifacts, SymlinksFollowed: walk.symlinksFollowed}, nil
+}
+
+// workspaceWalk is what walkWorkspace found under a workspace root.
+type workspaceWalk struct {
+	dirs             []dirMeta
+	files            []fileMeta
+	symlinksFollowed int
+}
+
+// walkWorkspace lists the directories and regular files under root, following
+// symlinks as walkTree does.
+func (ix *Indexer) walkWorkspace(ctx context.Context, root string, prog *progressReporter) (*workspaceWalk, error) {
+	w := &workspaceWalk{}
+	followed, err := ix.walkTree(ctx, root, func(e treeEntry) error {
+		w.dirs = append(w.dirs, dirMeta{
+			RelPath: e.rel,
+			Hash:    hashString(e.logical),
+			ModTime: e.info.ModTime().UTC(),
+		})
+		return nil
+	}, func(e treeEntry) error {
+		return w.addFile(ctx, e.physical, e.logical, e.rel, e.info, prog)
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.symlinksFollowed = followed
+	return w, nil
+}
+
+// treeEntry is a directory or regular file found by walkTree. physical is
+// where it really is, logical and rel where it appears in the workspace.
+type treeEntry struct {
+	physical, logical, rel string
+	info                   os.FileInfo
+}
+
+// walkTree calls dir, which may be nil, for root and each directory under it
+// and file for each regular file, skipping ix.skipDir names. Scans and embed
+// runs both walk through it so they agree on which files exist. Symlinks are
+// resolved and followed when their target lies inside root: a link to a file
+// appears at the link's path, and a link to a directory is walked as if that
+// directory sat there. Links that are broken, point outside root, or point at
+// root itself or a directory already walked are skipped. It returns how many
+// symlinks were followed.
+func (ix *Indexer) walkTree(ctx context.Context, root string, dir, file func(treeEntry) error) (int, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return 0, fmt.Errorf("resolve workspace root %s: %w", root, err)
+	}
+	followed := 0
+	visited := make(map[string]bool)
+
+	var walkDir func(physical, relBase string) error
+	walkDir = func(physical, relBase string) error {
+		return filepath.WalkDir(physical, func(path string, d os.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			// The root itself is walked whatever its name, e.g. a checkout in "build".
+			if d.IsDir() && path != physical && ix.skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+
+			rel := joinRel(relBase, normalizeRelPath(physical, path))
+			logical := filepath.Join(root, filepath.FromSlash(rel))
+
+			if d.Type()&fs.ModeSymlink != 0 {
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					ix.log().Debug("skipping broken symlink", "path", rel, "err", err)
+					return nil
+				}
+				if !isPathPrefix(realRoot, target) || target == realRoot {
+					ix.log().Debug("skipping symlink outside the workspace or to its root", "path", rel, "target", target)
+					return nil
+				}
+				info, err := os.Stat(target)
+				if err != nil {
+					ix.log().Debug("skipping unreadable symlink target", "path", rel, "target", target, "err", err)
+					return nil
+				}
+				switch {
+				case info.IsDir():
+					if visited[target] || ix.skipDir(d.Name()) {
+						return nil
+					}
+					followed++
+					return walkDir(target, rel)
+				case info.Mode().IsRegular():
+					followed++
+					return file(treeEntry{physical: target, logical: logical, rel: rel, info: info})
+				}
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				visited[path] = true
+				if dir == nil {
+					return nil
+				}
+				return dir(treeEntry{physical: path, logical: logical, rel: rel, info: info})
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			return file(treeEntry{physical: path, logical: logical, rel: rel, info: info})
+		})
+	}
+	if err := walkDir(realRoot, ""); err != nil {
+		return 0, err
+	}
+	return followed, nil
+}
+
+// addFile hashes the file at physical and records it at rel.
+func (w *workspaceWalk) addFile(ctx context.Context, physical, logical, rel string, info os.FileInfo, prog *progressReporter) error {
+	hash, head, err := hashFile(physical)
+	if err != nil {
+		return fmt.Errorf("hash file %s: %w", logical, err)
+	}
+	w.files = append(w.files, fileMeta{
+		RelPath:  rel,
+		Size:     info.Size(),
+		MTime:    info.ModTime().UTC(),
+		Hash:     hash,
+		Lang:     detectLanguage(logical),
+		MimeType: fileMimeType(logical, head),
+	})
+	prog.add(ctx, "files walked", 1)
+	return nil
+}
+
+// joinRel joins slash-separated relative paths, either of which may be empty.
+func joinRel(base, rel string) string {
+	switch {
+	case base == "":
+		return rel
+	case rel == "":
+		return base
+	}
+	return base + "/" + rel
+}
+
+// isPathPrefix reports whether dir is path or one of its ancestors.
+func isPathPrefix(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// foldNames is set where the default filesystem compares names without
+// regard to case, so "Node_Modules" is the same directory as "node_modules".
+const foldNames = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// nameKey returns name in the form used to compare file and directory names.
+func nameKey(name string) string {
+	if foldNames {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func nameSet(names ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		set[nameKey(n)] = struct{}{}
+	}
+	return set
 }
 
+// skipDirNames are directories never indexed: VCS metadata, editor state,
+// dependencies and build or cache output.
+var skipDirNames = nameSet(
+	".git", ".hg", ".svn", "node_modules", ".idea", ".vscode",
+	"build", "dist", "out", "target", "__pycache__", ".cache", ".terraform",
+	".next", ".nuxt", "vendor", ".bundle", "coverage", "tmp", ".tmp", ".turbo",
+)
+
+// skipFileNames and skipFileSuffixes match OS metadata and minified bundles,
+// which are not worth embedding.
+var (
+	skipFileNames    = nameSet(".DS_Store", "Thumbs.db")
+	skipFileSuffixes = []string{".min.js"}
+)
+
 func shouldSkipDir(name string) bool {
-	switch strings.ToLower(name) {
-	case ".git", ".hg", ".svn", "node_modules", ".idea", ".vscode":
+	_, ok := skipDirNames[nameKey(name)]
+	return ok
+}
+
+func shouldSkipFile(name string) bool {
+	key := nameKey(name)
+	if _, ok := skipFileNames[key]; ok {
 		return true
-	default:
+	}
+	for _, suffix := range skipFileSuffixes {
+		if strings.HasSuffix(key, nameKey(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir reports whether a directory is built in or listed in cfg.SkipDirs.
+func (ix *Indexer) skipDir(name string) bool {
+	if shouldSkipDir(name) {
+		return true
+	}
+	if ix.cfg == nil {
 		return false
 	}
+	key := nameKey(name)
+	for _, d := range ix.cfg.SkipDirs {
+		if nameKey(d) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// skipFile reports whether a file is left out of embed runs, either built in
+// or matching one of cfg.SkipFilePatterns.
+func (ix *Indexer) skipFile(name string) bool {
+	if shouldSkipFile(name) {
+		return true
+	}
+	if ix.cfg == nil {
+		return false
+	}
+	key := nameKey(name)
+	for _, p := range ix.cfg.SkipFilePatterns {
+		if ok, _ := filepath.Match(nameKey(p), key); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (ix *Indexer) writeNDJSON(dir, name string, data any) (string, error) {
-	path := filepath.Join(dir, name)
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	w, err := newNDJSONWriter(dir, name, ix.compressArtifacts())
 	if err != nil {
-		return "", fmt.Errorf("write artifact %s: %w", path, err)
+		return "", err
 	}
-	defer f.Close()
+	defer w.Close()
 
-	enc := json.NewEncoder(f)
 	switch v := data.(type) {
 	case []fileMeta:
 		for _, row := range v {
-			if err := enc.Encode(row); err != nil {
+			if err := w.Encode(row); err != nil {
 				return "", err
 			}
 		}
 	case []dirMeta:
 		for _, row := range v {
-			if err := enc.Encode(row); err != nil {
-				return "", err
-			}
-		}
-	case []*embedChunk:
-		for _, row := range v {
-			if err := enc.Encode(row); err != nil {
+			if err := w.Encode(row); err != nil {
 				return "", err
 			}
 		}
 	default:
 		return "", fmt.Errorf("unsupported artifact type %T", data)
 	}
-	return path, nil
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return w.Path(), nil
+}
+
+func (ix *Indexer) compressArtifacts() bool {
+	return ix.cfg != nil && ix.cfg.CompressArtifacts
+}
+
+// ndjsonWriter appends JSON rows to an artifact file one at a time so callers
+// can stream rows without holding them all in memory. Compressed artifacts get
+// a .gz suffix.
+type ndjsonWriter struct {
+	path string
+	f    *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+func newNDJSONWriter(dir, name string, compress bool) (*ndjsonWriter, error) {
+	path := filepath.Join(dir, name)
+	if compress {
+		path += ".gz"
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("write artifact %s: %w", path, err)
+	}
+	w := &ndjsonWriter{path: path, f: f}
+	if compress {
+		w.gz = gzip.NewWriter(f)
+		w.enc = json.NewEncoder(w.gz)
+	} else {
+		w.enc = json.NewEncoder(f)
+	}
+	return w, nil
+}
+
+func (w *ndjsonWriter) Encode(row any) error {
+	if w.f == nil {
+		return fmt.Errorf("write artifact %s: writer closed", w.path)
+	}
+	return w.enc.Encode(row)
+}
+
+func (w *ndjsonWriter) Path() string {
+	return w.path
+}
+
+// Close flushes and closes the artifact file. It is safe to call more than once.
+func (w *ndjsonWriter) Close() error {
+	if w.f == nil {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		err = w.gz.Close()
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	w.f = nil
+	if err != nil {
+		return fmt.Errorf("close artifact %s: %w", w.path, err)
+	}
+	return nil
 }
 
 // buildScanStatements is replaced by direct SDK calls via surreal.Client
@@ -219,19 +485,81 @@ func parentDirRel(rel string) string {
 	return strings.TrimPrefix(parent, "./")
 }
 
-func hashFile(path string) (string, error) {
+// hashFile returns the blake3 hash of the file at path and up to sniffLen of
+// its leading bytes for MIME detection.
+func hashFile(path string) (string, []byte, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer f.Close()
 
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
 	hasher := blake3.New()
+	hasher.Write(head)
 	if _, err := io.Copy(hasher, f); err != nil {
-		return "", err
+		return "", nil, err
 	}
 	sum := hasher.Sum(nil)
-	return hex.EncodeToString(sum), nil
+	return hex.EncodeToString(sum), head, nil
+}
+
+// textMimeTypes refines text/plain for source files by extension.
+var textMimeTypes = map[string]string{
+	".go":   "text/x-go",
+	".py":   "text/x-python",
+	".rs":   "text/x-rust",
+	".js":   "text/javascript",
+	".jsx":  "text/javascript",
+	".ts":   "text/x-typescript",
+	".tsx":  "text/x-typescript",
+	".sh":   "text/x-shellscript",
+	".bash": "text/x-shellscript",
+	".ps1":  "text/x-powershell",
+	".md":   "text/markdown",
+	".json": "text/x-json",
+	".yaml": "text/yaml",
+	".yml":  "text/yaml",
+	".toml": "text/x-toml",
+}
+
+// fileMimeType sniffs head and, for plain text, refines the type from the
+// extension of path.
+func fileMimeType(path string, head []byte) string {
+	mimeType, _ := detectMimeType(head)
+	if mimeType == "text/plain" {
+		if t, ok := textMimeTypes[strings.ToLower(filepath.Ext(path))]; ok {
+			return t
+		}
+	}
+	return mimeType
+}
+
+// readTextContent returns the content of a text file no larger than
+// maxStoredContentBytes, converted to UTF-8, and the encoding it was read as.
+// It is read after the walk so contents are not held in memory for the whole
+// scan.
+func readTextContent(path string, size int64) (string, string, bool) {
+	if size <= 0 || size > maxStoredContentBytes {
+		return "", "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) > maxStoredContentBytes {
+		return "", "", false
+	}
+	if _, binary := detectMimeType(data); binary {
+		return "", "", false
+	}
+	text, encoding, err := detectAndConvertEncoding(data)
+	if err != nil {
+		return "", "", false
+	}
+	return string(text), encoding, true
 }
 
 func hashString(v string) string {
@@ -240,9 +568,14 @@ func hashString(v string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// detectLanguage returns a language hint from path's extension. Files without
+// an extension are identified by their shebang line when they have one.
 func detectLanguage(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
 	if ext == "" {
+		if lang := shebangLanguage(path); lang != "" {
+			return lang
+		}
 		return "text"
 	}
 	switch ext {
@@ -252,7 +585,7 @@ func detectLanguage(path string) string {
 		return "python"
 	case ".rs":
 		return "rust"
-	case ".js":
+	case ".js", ".mjs", ".cjs":
 		return "javascript"
 	case ".ts":
 		return "typescript"
@@ -260,12 +593,46 @@ func detectLanguage(path string) string {
 		return "tsx"
 	case ".jsx":
 		return "jsx"
+	case ".c", ".h":
+		return "c"
+	case ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx":
+		return "cpp"
+	case ".cs":
+		return "csharp"
+	case ".java":
+		return "java"
+	case ".rb":
+		return "ruby"
+	case ".php":
+		return "php"
+	case ".swift":
+		return "swift"
+	case ".kt", ".kts":
+		return "kotlin"
+	case ".zig":
+		return "zig"
+	case ".sql":
+		return "sql"
+	case ".proto":
+		return "protobuf"
+	case ".tf", ".tfvars":
+		return "terraform"
+	case ".nix":
+		return "nix"
+	case ".lua":
+		return "lua"
+	case ".r":
+		return "r"
+	case ".ex", ".exs":
+		return "elixir"
 	case ".sh", ".bash":
 		return "shell"
 	case ".ps1":
 		return "powershell"
 	case ".md":
 		return "markdown"
+	case ".txt":
+		return "text"
 	case ".json":
 		return "json"
 	case ".yaml", ".yml":
@@ -276,3 +643,70 @@ func detectLanguage(path string) string {
 		return strings.TrimPrefix(ext, ".")
 	}
 }
+
+// shebangLen is how much of a file shebangLanguage reads looking for "#!".
+const shebangLen = 128
+
+// shebangInterpreters maps interpreter names, with any version suffix
+// removed, to language hints.
+var shebangInterpreters = map[string]string{
+	"python":  "python",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"deno":    "typescript",
+	"ts-node": "typescript",
+	"sh":      "shell",
+	"bash":    "shell",
+	"dash":    "shell",
+	"zsh":     "shell",
+	"ksh":     "shell",
+	"pwsh":    "powershell",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"rscript": "r",
+	"elixir":  "elixir",
+}
+
+// shebangLanguage returns the language named by the "#!" line at the start of
+// the file at path, or "" when there is none or it names an unknown
+// interpreter. Only the first shebangLen bytes are read.
+func shebangLanguage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head, err := bufio.NewReaderSize(f, shebangLen).Peek(shebangLen)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	return parseShebang(string(head))
+}
+
+// parseShebang extracts the language from a shebang such as "#!/bin/bash" or
+// "#!/usr/bin/env python3".
+func parseShebang(head string) string {
+	if !strings.HasPrefix(head, "#!") {
+		return ""
+	}
+	line, _, _ := strings.Cut(head[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := filepath.Base(fields[0])
+	if name == "env" {
+		// Skip env's own flags, e.g. "#!/usr/bin/env -S deno run".
+		name = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				name = filepath.Base(f)
+				break
+			}
+		}
+	}
+	name = strings.TrimRight(strings.ToLower(name), "0123456789.")
+	return shebangInterpreters[name]
+}
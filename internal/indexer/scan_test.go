@@ -0,0 +1,241 @@
+package indexer
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+)
+
+func TestWalkWorkspaceHandlesSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need elevated privileges on windows")
+	}
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "shared.go"), []byte("package shared\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "src"))
+	if err := os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustSymlink(t, "..", filepath.Join(root, "src", "up"))                           // loop back to root
+	mustSymlink(t, ".", filepath.Join(root, "src", "self"))                          // loop to its own dir
+	mustSymlink(t, filepath.Join(root, "missing"), filepath.Join(root, "broken"))    // broken
+	mustSymlink(t, filepath.Join(root, "src", "main.go"), filepath.Join(root, "m"))  // file
+	mustSymlink(t, outside, filepath.Join(root, "vendored"))                         // directory outside root
+	mustSymlink(t, filepath.Join(outside, "shared.go"), filepath.Join(root, "s.go")) // file outside root
+
+	ix := &Indexer{logger: slog.New(slog.DiscardHandler)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := ix.walkWorkspace(ctx, root, nil)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	var files []string
+	for _, f := range w.files {
+		files = append(files, f.RelPath)
+	}
+	sort.Strings(files)
+	want := []string{"m", "src/main.go"}
+	if len(files) != len(want) {
+		t.Fatalf("files %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("files %v, want %v", files, want)
+		}
+	}
+	if w.symlinksFollowed != 1 {
+		t.Fatalf("expected 1 symlink followed, got %d", w.symlinksFollowed)
+	}
+
+	// Embed runs walk the same tree, so every scanned file can get chunks.
+	ix.chunker = byteChunker{}
+	out := make(chan *embedChunk, 16)
+	if _, _, err := ix.collectEmbedChunks(ctx, root, out); err != nil {
+		t.Fatalf("collect chunks: %v", err)
+	}
+	close(out)
+	var chunked []string
+	for ch := range out {
+		chunked = append(chunked, ch.RelPath)
+	}
+	sort.Strings(chunked)
+	if strings.Join(chunked, ",") != strings.Join(want, ",") {
+		t.Fatalf("chunked files %v, want %v", chunked, want)
+	}
+}
+
+func mustMkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkWorkspaceSkipsDirs(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "build") // a skipped name is still walked as the root
+	for _, dir := range []string{"src", "node_modules/pkg", "dist", "generated"} {
+		mustMkdir(t, filepath.Join(root, dir))
+		if err := os.WriteFile(filepath.Join(root, dir, "a.js"), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ix := &Indexer{logger: slog.New(slog.DiscardHandler), cfg: &config.Config{SkipDirs: []string{"generated"}}}
+	w, err := ix.walkWorkspace(context.Background(), root, nil)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if len(w.files) != 1 || w.files[0].RelPath != "src/a.js" {
+		t.Fatalf("expected only src/a.js, got %+v", w.files)
+	}
+}
+
+func TestSkipFile(t *testing.T) {
+	ix := &Indexer{cfg: &config.Config{SkipFilePatterns: []string{"*.lock"}}}
+	for name, want := range map[string]bool{
+		".DS_Store":   true,
+		"Thumbs.db":   true,
+		"app.min.js":  true,
+		"yarn.lock":   true,
+		"app.js":      false,
+		"min.js.map":  false,
+		"lockfile.go": false,
+	} {
+		if got := ix.skipFile(name); got != want {
+			t.Errorf("skipFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestDetectMimeType(t *testing.T) {
+	cases := []struct {
+		name    string
+		content []byte
+		mime    string
+		binary  bool
+	}{
+		{"text", []byte("hello world\n"), "text/plain", false},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png", true},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf", true},
+		{"nul", []byte("abc\x00def"), "application/octet-stream", true},
+	}
+	for _, tc := range cases {
+		mime, binary := detectMimeType(tc.content)
+		if mime != tc.mime || binary != tc.binary {
+			t.Fatalf("%s: got (%q, %v), want (%q, %v)", tc.name, mime, binary, tc.mime, tc.binary)
+		}
+	}
+	if got := fileMimeType("cmd/main.go", []byte("package main\n")); got != "text/x-go" {
+		t.Fatalf("expected text/x-go for Go source, got %q", got)
+	}
+	if got := fileMimeType("logo.go", []byte("\x89PNG\r\n\x1a\n")); got != "image/png" {
+		t.Fatalf("sniffed binary type should win over extension, got %q", got)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cases := []struct{ path, want string }{
+		{"src/Main.CS", "csharp"},
+		{"infra/main.tf", "terraform"},
+		{"lib/app.ex", "elixir"},
+		{"notes.md", "markdown"},
+		{write("deploy", "#!/usr/bin/env python3\nprint(1)\n"), "python"},
+		{write("serve", "#!/usr/bin/node\n"), "javascript"},
+		{write("run", "#!/usr/bin/env -S bash -e\n"), "shell"},
+		{write("README", "plain words\n"), "text"},
+		{write("tool", "#!/opt/bin/unknown\n"), "text"},
+		{filepath.Join(dir, "missing"), "text"},
+	}
+	for _, tc := range cases {
+		if got := detectLanguage(tc.path); got != tc.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", filepath.Base(tc.path), got, tc.want)
+		}
+	}
+}
+
+func TestNDJSONArtifactRoundTrip(t *testing.T) {
+	rows := []dirMeta{{RelPath: "", Hash: "root"}, {RelPath: "cmd", Hash: "cmd"}}
+	for _, compress := range []bool{false, true} {
+		ix := &Indexer{cfg: &config.Config{CompressArtifacts: compress}}
+		path, err := ix.writeNDJSON(t.TempDir(), "dirs.ndjson", rows)
+		if err != nil {
+			t.Fatalf("compress=%v: write: %v", compress, err)
+		}
+		if got := strings.HasSuffix(path, ".ndjson.gz"); got != compress {
+			t.Fatalf("compress=%v: unexpected artifact path %s", compress, path)
+		}
+		var read []dirMeta
+		if err := readNDJSON(path, func(row dirMeta) error {
+			read = append(read, row)
+			return nil
+		}); err != nil {
+			t.Fatalf("compress=%v: read: %v", compress, err)
+		}
+		if len(read) != len(rows) || read[1].RelPath != "cmd" || read[1].Hash != "cmd" {
+			t.Fatalf("compress=%v: round trip mismatch: %+v", compress, read)
+		}
+	}
+}
+
+// readNDJSON decodes each row of the artifact at path into a T and passes it
+// to fn. Paths ending in .gz are decompressed.
+func readNDJSON[T any](path string, fn func(T) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read artifact %s: %w", path, err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("read artifact %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	dec := json.NewDecoder(r)
+	for {
+		var row T
+		if err := dec.Decode(&row); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read artifact %s: %w", path, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
@@ -2,12 +2,17 @@ package indexer
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	tiktoken "github.com/pkoukk/tiktoken-go"
 )
 
-const maxTokensPerChunk = 768
+// Chunk size bounds used when the config leaves them unset.
+const (
+	maxTokensPerChunk = 768
+	minTokensPerChunk = 20
+)
 
 type tokenChunk struct {
 	Text       string
@@ -16,11 +21,15 @@ type tokenChunk struct {
 	TokenCount int
 }
 
+// tokenChunker splits text into chunks of maxTokens tokens and drops any chunk
+// shorter than minTokens, such as a trailing closing brace.
 type tokenChunker struct {
-	enc *tiktoken.Tiktoken
+	enc       *tiktoken.Tiktoken
+	minTokens int
+	maxTokens int
 }
 
-func newTokenChunker(tokenizerID string) (*tokenChunker, error) {
+func newTokenChunker(tokenizerID string, minTokens, maxTokens int) (*tokenChunker, error) {
 	id := strings.TrimSpace(tokenizerID)
 	if id == "" {
 		return nil, fmt.Errorf("tokenizer id is required")
@@ -34,10 +43,36 @@ func newTokenChunker(tokenizerID string) (*tokenChunker, error) {
 			return nil, fmt.Errorf("load tokenizer %s: %w", tokenizerID, err)
 		}
 	}
-	return &tokenChunker{enc: enc}, nil
+	if maxTokens <= 0 {
+		maxTokens = maxTokensPerChunk
+	}
+	return &tokenChunker{enc: enc, minTokens: minTokens, maxTokens: maxTokens}, nil
+}
+
+// textChunker is implemented by tokenChunker and paragraphChunker. split
+// covers the whole text in order; chunk also drops chunks shorter than
+// minTokens and reports how many it dropped.
+type textChunker interface {
+	split(text string) ([]tokenChunk, error)
+	chunk(text string) ([]tokenChunk, int, error)
+	minChunkTokens() int
 }
 
-func (c *tokenChunker) chunk(text string) ([]tokenChunk, error) {
+// chunk returns the chunks of text and how many were dropped for having fewer
+// than minTokens tokens.
+func (c *tokenChunker) chunk(text string) ([]tokenChunk, int, error) {
+	segments, err := c.split(text)
+	if err != nil {
+		return nil, 0, err
+	}
+	kept, dropped := dropShortChunks(segments, c.minTokens)
+	return kept, dropped, nil
+}
+
+func (c *tokenChunker) minChunkTokens() int { return c.minTokens }
+
+// split cuts text every maxTokens tokens.
+func (c *tokenChunker) split(text string) ([]tokenChunk, error) {
 	if c == nil || c.enc == nil {
 		return nil, fmt.Errorf("token chunker not initialised")
 	}
@@ -46,10 +81,10 @@ func (c *tokenChunker) chunk(text string) ([]tokenChunk, error) {
 		return nil, nil
 	}
 
-	chunks := make([]tokenChunk, 0, (len(tokens)+maxTokensPerChunk-1)/maxTokensPerChunk)
+	chunks := make([]tokenChunk, 0, (len(tokens)+c.maxTokens-1)/c.maxTokens)
 	byteCursor := 0
-	for start := 0; start < len(tokens); start += maxTokensPerChunk {
-		end := start + maxTokensPerChunk
+	for start := 0; start < len(tokens); start += c.maxTokens {
+		end := start + c.maxTokens
 		if end > len(tokens) {
 			end = len(tokens)
 		}
@@ -81,3 +116,62 @@ func (c *tokenChunker) chunk(text string) ([]tokenChunk, error) {
 
 	return chunks, nil
 }
+
+// dropShortChunks filters out chunks with fewer than minTokens tokens in place
+// and returns the rest with how many were dropped.
+func dropShortChunks(chunks []tokenChunk, minTokens int) ([]tokenChunk, int) {
+	kept := chunks[:0]
+	for _, ch := range chunks {
+		if ch.TokenCount >= minTokens {
+			kept = append(kept, ch)
+		}
+	}
+	return kept, len(chunks) - len(kept)
+}
+
+// chunkReader chunks UTF-8 text read from r through buf with c and passes each
+// chunk of at least c's minimum token count to emit, with offsets relative to
+// the whole text. Only one slab plus the text after the last emitted chunk is
+// held at a time. Before the end of the text, a chunk is emitted only if
+// another chunk follows it and it ends at least overlap bytes before the end
+// of the text read so far, so a token straddling a slab boundary is never cut;
+// the rest is carried into the next slab and split again from the chunk
+// boundary. Text shorter than buf is read in one slab and yields exactly the
+// chunks of c.chunk. chunkReader returns how many short chunks it dropped.
+func chunkReader(r io.Reader, buf []byte, overlap int, c textChunker, emit func(tokenChunk) error) (int, error) {
+	var (
+		pending string
+		base    int // offset of pending within the whole text
+		dropped int
+	)
+	flush := func(eof bool) error {
+		segments, err := c.split(pending)
+		if err != nil {
+			return err
+		}
+		consumed := 0
+		for i, seg := range segments {
+			if !eof && (i == len(segments)-1 || seg.End > len(pending)-overlap) {
+				break
+			}
+			consumed = seg.End
+			if seg.TokenCount < c.minChunkTokens() {
+				dropped++
+				continue
+			}
+			seg.Start += base
+			seg.End += base
+			if err := emit(seg); err != nil {
+				return err
+			}
+		}
+		pending = pending[consumed:]
+		base += consumed
+		return nil
+	}
+	err := readSlabs(r, buf, func(slab []byte, eof bool) error {
+		pending += string(slab)
+		return flush(eof)
+	})
+	return dropped, err
+}
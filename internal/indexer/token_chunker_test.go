@@ -6,13 +6,13 @@ import (
 )
 
 func TestTokenChunkerSplitsByTokenLimit(t *testing.T) {
-	chunker, err := newTokenChunker("tiktoken/cl100k_base")
+	chunker, err := newTokenChunker("tiktoken/cl100k_base", 0, maxTokensPerChunk)
 	if err != nil {
 		t.Fatalf("new token chunker: %v", err)
 	}
 
 	input := strings.Repeat("hello world ", 3000)
-	segments, err := chunker.chunk(input)
+	segments, _, err := chunker.chunk(input)
 	if err != nil {
 		t.Fatalf("chunk: %v", err)
 	}
@@ -42,3 +42,82 @@ func TestTokenChunkerSplitsByTokenLimit(t *testing.T) {
 		t.Fatalf("rebuilt text mismatch")
 	}
 }
+
+func TestTokenChunkerDropsShortChunks(t *testing.T) {
+	const input = "hello world foo bar baz" // 5 tokens in cl100k_base
+	for _, tc := range []struct {
+		minTokens int
+		chunks    int
+		dropped   int
+	}{
+		{minTokens: 20, chunks: 0, dropped: 1},
+		{minTokens: 5, chunks: 1, dropped: 0},
+	} {
+		chunker, err := newTokenChunker("tiktoken/cl100k_base", tc.minTokens, maxTokensPerChunk)
+		if err != nil {
+			t.Fatalf("new token chunker: %v", err)
+		}
+		segments, dropped, err := chunker.chunk(input)
+		if err != nil {
+			t.Fatalf("chunk: %v", err)
+		}
+		if len(segments) != tc.chunks || dropped != tc.dropped {
+			t.Fatalf("minTokens=%d: got %d chunks and %d dropped, want %d and %d", tc.minTokens, len(segments), dropped, tc.chunks, tc.dropped)
+		}
+	}
+}
+
+// byteChunker splits text with one token per byte, so it needs no tokenizer.
+type byteChunker struct{ minTokens int }
+
+func (c byteChunker) split(text string) ([]tokenChunk, error) {
+	var chunks []tokenChunk
+	for start := 0; start < len(text); start += maxTokensPerChunk {
+		end := min(start+maxTokensPerChunk, len(text))
+		chunks = append(chunks, tokenChunk{Text: text[start:end], Start: start, End: end, TokenCount: end - start})
+	}
+	return chunks, nil
+}
+
+func (c byteChunker) chunk(text string) ([]tokenChunk, int, error) {
+	segments, _ := c.split(text)
+	kept, dropped := dropShortChunks(segments, c.minTokens)
+	return kept, dropped, nil
+}
+
+func (c byteChunker) minChunkTokens() int { return c.minTokens }
+
+func TestChunkReaderMatchesWholeText(t *testing.T) {
+	input := strings.Repeat("naïve café ", 2000)
+	want, _, _ := byteChunker{}.chunk(input)
+
+	for _, bufSize := range []int{len(input) + 1, 4096, 1000} {
+		var got []tokenChunk
+		_, err := chunkReader(strings.NewReader(input), make([]byte, bufSize), 64, byteChunker{}, func(seg tokenChunk) error {
+			got = append(got, seg)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("buffer %d: chunkReader: %v", bufSize, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("buffer %d: got %d chunks, want %d", bufSize, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("buffer %d: chunk %d = [%d,%d), want [%d,%d)", bufSize, i, got[i].Start, got[i].End, want[i].Start, want[i].End)
+			}
+		}
+	}
+}
+
+func TestChunkReaderCountsDroppedChunksOnce(t *testing.T) {
+	input := strings.Repeat("x", 3*maxTokensPerChunk+10)
+	dropped, err := chunkReader(strings.NewReader(input), make([]byte, 1000), 64, byteChunker{minTokens: 20}, func(tokenChunk) error { return nil })
+	if err != nil {
+		t.Fatalf("chunkReader: %v", err)
+	}
+	if dropped != 1 {
+		t.Fatalf("expected the 10-byte tail to be dropped once, got %d", dropped)
+	}
+}
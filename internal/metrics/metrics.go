@@ -0,0 +1,100 @@
+// Package metrics defines the Prometheus collectors exported by the server and
+// the listener that serves them.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// IndexRuns counts finished index runs by workspace, step and acceptance.
+	IndexRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chaosmith_index_runs_total",
+		Help: "Index runs finished, by workspace, step and status.",
+	}, []string{"workspace", "step", "status"})
+
+	// EmbedRequests counts HTTP requests sent to the embedding executor.
+	EmbedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chaosmith_embed_requests_total",
+		Help: "Requests sent to the embedding executor.",
+	})
+
+	// EmbedDuration observes embedding request latency.
+	EmbedDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "chaosmith_embed_duration_seconds",
+		Help:    "Embedding request latency in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+
+	// SearchRequests counts calls to the search tools.
+	SearchRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chaosmith_search_requests_total",
+		Help: "Search tool calls, by tool name.",
+	}, []string{"tool"})
+
+	// PTYSessionsActive tracks open term_pty sessions.
+	PTYSessionsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "chaosmith_pty_sessions_active",
+		Help: "Open interactive PTY sessions.",
+	})
+
+	// SurrealQueryDuration observes SurrealDB round-trip latency.
+	SurrealQueryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "chaosmith_surreal_query_duration_seconds",
+		Help:    "SurrealDB query latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// AuditDropped counts audit log entries dropped because the writer fell behind.
+	AuditDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chaosmith_audit_dropped_total",
+		Help: "Audit log entries dropped under backpressure.",
+	})
+)
+
+// Handler returns the HTTP handler that exposes all registered metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve exposes /metrics on addr until ctx is cancelled, then shuts the
+// listener down. It is separate from the MCP listener so it can be firewalled
+// on its own.
+func Serve(ctx context.Context, addr string, logger *slog.Logger) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 15 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("metrics listening", "addr", addr, "path", "/metrics")
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerExposesCollectors(t *testing.T) {
+	EmbedRequests.Inc()
+	SearchRequests.WithLabelValues("workspace_vector_search").Inc()
+	IndexRuns.WithLabelValues("ws", "scan", "pass").Inc()
+
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		"chaosmith_embed_requests_total",
+		`chaosmith_search_requests_total{tool="workspace_vector_search"}`,
+		`chaosmith_index_runs_total{status="pass",step="scan",workspace="ws"}`,
+		"chaosmith_pty_sessions_active",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
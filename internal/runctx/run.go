@@ -1,6 +1,8 @@
 package runctx
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,10 +20,25 @@ type Run struct {
 	Step          string
 	Started       time.Time
 	ArtifactDir   string
+	// Fingerprint records the configuration the run's artifacts depend on;
+	// it is written to the manifest.
+	Fingerprint Fingerprint
 
 	artifacts []string
 }
 
+// Fingerprint identifies the embedding configuration a run used, so that
+// artifacts from different models or tokenizers can be told apart.
+type Fingerprint struct {
+	EmbedModel    string `json:"embed_model,omitempty"`
+	EmbedModelSHA string `json:"embed_model_sha,omitempty"`
+	TransformID   string `json:"transform_id,omitempty"`
+	TokenizerID   string `json:"tokenizer_id,omitempty"`
+}
+
+// ManifestName is the file WriteManifest creates in the artifact directory.
+const ManifestName = "run.json"
+
 // New constructs a Run, creating the artifact directory under artifactRoot/runID.
 // If runID is empty a deterministic id derived from workspace, step, and start time is generated.
 func New(artifactRoot, runID, workspaceID, workspaceRoot, step string, started time.Time) (*Run, error) {
@@ -79,3 +96,42 @@ func (r *Run) Artifacts() []string {
 	copy(out, r.artifacts)
 	return out
 }
+
+// WriteManifest writes run.json into the artifact directory, describing the
+// run, its configuration fingerprint and report, so the directory can be
+// understood without the database. An existing manifest with the same content
+// is left untouched. It returns the manifest path.
+func (r *Run) WriteManifest(report any) (string, error) {
+	manifest := struct {
+		RunID         string      `json:"run_id"`
+		WorkspaceID   string      `json:"workspace_id"`
+		WorkspaceRoot string      `json:"workspace_root"`
+		Step          string      `json:"step"`
+		Started       time.Time   `json:"started"`
+		Fingerprint   Fingerprint `json:"fingerprint"`
+		Artifacts     []string    `json:"artifacts"`
+		Report        any         `json:"report"`
+	}{
+		RunID:         r.RunID,
+		WorkspaceID:   r.WorkspaceID,
+		WorkspaceRoot: r.WorkspaceRoot,
+		Step:          r.Step,
+		Started:       r.Started,
+		Fingerprint:   r.Fingerprint,
+		Artifacts:     r.Artifacts(),
+		Report:        report,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode run manifest: %w", err)
+	}
+	data = append(data, '\n')
+	path := filepath.Join(r.ArtifactDir, ManifestName)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return path, nil
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write run manifest %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -1,6 +1,9 @@
 package runctx
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -17,3 +20,68 @@ func TestGenerateRunIDDeterministic(t *testing.T) {
 		t.Fatalf("expected different step to yield different run id, got %q", c)
 	}
 }
+
+func TestWriteManifest(t *testing.T) {
+	run, err := New(t.TempDir(), "RUN-1", "ws", "/src/ws", "index.scan", time.Time{})
+	if err != nil {
+		t.Fatalf("new run: %v", err)
+	}
+	run.Fingerprint = Fingerprint{EmbedModel: "nomic", TokenizerID: "tiktoken/cl100k_base"}
+	run.AddArtifact(filepath.Join(run.ArtifactDir, "files.ndjson"))
+
+	path, err := run.WriteManifest(map[string]string{"acceptance": "pass"})
+	if err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var got struct {
+		RunID       string            `json:"run_id"`
+		Fingerprint Fingerprint       `json:"fingerprint"`
+		Artifacts   []string          `json:"artifacts"`
+		Report      map[string]string `json:"report"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if got.RunID != "RUN-1" || got.Fingerprint.EmbedModel != "nomic" || len(got.Artifacts) != 1 || got.Report["acceptance"] != "pass" {
+		t.Fatalf("unexpected manifest %+v", got)
+	}
+}
+
+func TestWriteManifestSkipsUnchangedContent(t *testing.T) {
+	run, err := New(t.TempDir(), "RUN-1", "ws", "/src/ws", "index.scan", time.Time{})
+	if err != nil {
+		t.Fatalf("new run: %v", err)
+	}
+	path, err := run.WriteManifest(map[string]string{"acceptance": "pass"})
+	if err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	modTime := func() time.Time {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat manifest: %v", err)
+		}
+		return info.ModTime()
+	}
+
+	if _, err := run.WriteManifest(map[string]string{"acceptance": "pass"}); err != nil {
+		t.Fatalf("rewrite manifest: %v", err)
+	}
+	if !modTime().Equal(old) {
+		t.Fatalf("unchanged manifest was rewritten")
+	}
+	if _, err := run.WriteManifest(map[string]string{"acceptance": "fail"}); err != nil {
+		t.Fatalf("rewrite manifest: %v", err)
+	}
+	if modTime().Equal(old) {
+		t.Fatalf("changed manifest was not rewritten")
+	}
+}
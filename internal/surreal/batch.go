@@ -0,0 +1,181 @@
+package surreal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/surrealdb/surrealdb.go/pkg/models"
+)
+
+// DefaultBatchSize bounds the number of statements sent in one Exec round-trip
+// by UpsertBatch and ExecBatch when Client.BatchSize is unset.
+const DefaultBatchSize = 500
+
+// BatchRecord is a single record upserted by UpsertBatch.
+type BatchRecord struct {
+	ID      string
+	Content map[string]any
+}
+
+// UpsertBatch upserts records into table using multi-statement queries, sending
+// at most BatchSize statements per round-trip.
+func (c *Client) UpsertBatch(ctx context.Context, table string, records []BatchRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	stmts := make([]string, 0, len(records))
+	for _, rec := range records {
+		stmt, err := upsertStatement(table, rec)
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	if err := c.ExecBatch(ctx, stmts); err != nil {
+		return fmt.Errorf("upsert batch %s: %w", table, err)
+	}
+	return nil
+}
+
+// ExecBatch runs stmts through Exec, sending at most BatchSize statements per
+// round-trip.
+func (c *Client) ExecBatch(ctx context.Context, stmts []string) error {
+	size := c.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	for i := 0; i < len(stmts); i += size {
+		j := min(i+size, len(stmts))
+		if err := c.Exec(ctx, stmts[i:j]); err != nil {
+			return fmt.Errorf("exec batch [%d:%d]: %w", i, j, err)
+		}
+	}
+	return nil
+}
+
+func upsertStatement(table string, rec BatchRecord) (string, error) {
+	if strings.TrimSpace(rec.ID) == "" {
+		return "", fmt.Errorf("upsert batch %s: record id is required", table)
+	}
+	content, err := formatValue(rec.Content)
+	if err != nil {
+		return "", fmt.Errorf("upsert batch %s:%s: %w", table, rec.ID, err)
+	}
+	return "UPSERT " + thing(table, rec.ID) + " CONTENT " + content, nil
+}
+
+// thing renders a record id as a type::thing() call.
+func thing(table, id string) string {
+	return "type::thing(" + stringLiteral(table) + ", " + stringLiteral(id) + ")"
+}
+
+// stringLiteral renders v as a single-quoted SurrealQL string.
+func stringLiteral(v string) string {
+	v = strings.ReplaceAll(v, "\\", "\\\\")
+	v = strings.ReplaceAll(v, "'", "\\'")
+	return "'" + v + "'"
+}
+
+// formatValue renders a Go value as a SurrealQL literal. It supports the value
+// shapes the indexer stores: scalars, strings, times, record ids, NONE, slices
+// and string-keyed maps.
+func formatValue(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "NULL", nil
+	case models.CustomNil:
+		return "NONE", nil
+	case models.RecordID:
+		return thing(val.Table, fmt.Sprint(val.ID)), nil
+	case *models.RecordID:
+		if val == nil {
+			return "NULL", nil
+		}
+		return thing(val.Table, fmt.Sprint(val.ID)), nil
+	case string:
+		return stringLiteral(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case time.Time:
+		return "type::datetime(" + stringLiteral(val.UTC().Format(time.RFC3339Nano)) + ")", nil
+	case float32:
+		return formatFloat(float64(val), 32)
+	case float64:
+		return formatFloat(val, 64)
+	case []float32:
+		var sb strings.Builder
+		sb.Grow(len(val) * 10)
+		sb.WriteByte('[')
+		for i, f := range val {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			s, err := formatFloat(float64(f), 32)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+		}
+		sb.WriteByte(']')
+		return sb.String(), nil
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			s, err := formatValue(val[k])
+			if err != nil {
+				return "", fmt.Errorf("field %s: %w", k, err)
+			}
+			sb.WriteString(stringLiteral(k))
+			sb.WriteString(": ")
+			sb.WriteString(s)
+		}
+		sb.WriteByte('}')
+		return sb.String(), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, rv.Len())
+		for i := range parts {
+			s, err := formatValue(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return "[" + strings.Join(parts, ",") + "]", nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", v)
+}
+
+func formatFloat(f float64, bits int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("non-finite float %v", f)
+	}
+	s := strconv.FormatFloat(f, 'f', -1, bits)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s, nil
+}
@@ -0,0 +1,136 @@
+package surreal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/surrealdb.go/pkg/models"
+)
+
+func TestUpsertBatchChunksStatements(t *testing.T) {
+	f := &fakeRunner{}
+	client := &Client{ns: "chaos", dbName: "smith", runner: f, BatchSize: 2}
+
+	records := make([]BatchRecord, 5)
+	for i := range records {
+		records[i] = BatchRecord{ID: fmt.Sprintf("vec-%d", i), Content: map[string]any{"idx": i}}
+	}
+	if err := client.UpsertBatch(context.Background(), "vector_chunk", records); err != nil {
+		t.Fatalf("upsert batch: %v", err)
+	}
+	if len(f.batches) != 3 {
+		t.Fatalf("expected 3 round-trips, got %d", len(f.batches))
+	}
+	if got := strings.Count(f.batches[0], "UPSERT "); got != 2 {
+		t.Fatalf("expected 2 upserts in first batch, got %d", got)
+	}
+	if !strings.Contains(f.batches[2], "UPSERT type::thing('vector_chunk', 'vec-4') CONTENT {'idx': 4}") {
+		t.Fatalf("unexpected final batch: %s", f.batches[2])
+	}
+}
+
+func TestExecBatchChunksStatements(t *testing.T) {
+	f := &fakeRunner{}
+	client := &Client{ns: "chaos", dbName: "smith", runner: f, BatchSize: 2}
+
+	stmts := benchmarkRelations(benchmarkRecords(5))
+	if err := client.ExecBatch(context.Background(), stmts); err != nil {
+		t.Fatalf("exec batch: %v", err)
+	}
+	if len(f.batches) != 3 {
+		t.Fatalf("expected 3 round-trips, got %d", len(f.batches))
+	}
+	if got := strings.Count(f.batches[0], "RELATE "); got != 2 {
+		t.Fatalf("expected 2 relations in first batch, got %d", got)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	ts := time.Date(2025, 7, 10, 12, 30, 0, 0, time.UTC)
+	got, err := formatValue(map[string]any{
+		"file":   models.NewRecordID("file", "file-abc"),
+		"symbol": models.None,
+		"name":   "it's",
+		"vector": []float32{1, 0.5},
+		"ts":     ts,
+		"n":      int64(3),
+	})
+	if err != nil {
+		t.Fatalf("format value: %v", err)
+	}
+	want := "{'file': type::thing('file', 'file-abc'), 'n': 3, 'name': 'it\\'s', 'symbol': NONE, " +
+		"'ts': type::datetime('2025-07-10T12:30:00Z'), 'vector': [1.0,0.5]}"
+	if got != want {
+		t.Fatalf("formatValue:\n got %s\nwant %s", got, want)
+	}
+
+	if _, err := formatValue(struct{}{}); err == nil {
+		t.Fatalf("expected error for unsupported type")
+	}
+}
+
+func benchmarkRecords(n int) []BatchRecord {
+	vec := make([]float32, 768)
+	for i := range vec {
+		vec[i] = float32(i) / 768
+	}
+	records := make([]BatchRecord, n)
+	for i := range records {
+		records[i] = BatchRecord{ID: fmt.Sprintf("vec-%d", i), Content: map[string]any{
+			"chunk_index": i,
+			"vector":      vec,
+		}}
+	}
+	return records
+}
+
+func benchmarkRelations(records []BatchRecord) []string {
+	stmts := make([]string, len(records))
+	for i, rec := range records {
+		stmts[i] = "RELATE " + thing("file", "file-1") + "->file_has_vector->" + thing("vector_chunk", rec.ID)
+	}
+	return stmts
+}
+
+// BenchmarkStorePerRecord and BenchmarkStoreBatched store a 1000-chunk
+// workspace and its file->chunk relations over HTTP RPC with a 200µs
+// round-trip. PerRecord is the path storeEmbeddings took before batching: one
+// UpsertRecord and one Relate per chunk.
+func BenchmarkStorePerRecord(b *testing.B) {
+	records := benchmarkRecords(1000)
+	client := (&fakeRPC{delay: 200 * time.Microsecond}).client(b)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, rec := range records {
+			if err := client.UpsertRecord(ctx, "vector_chunk", rec.ID, rec.Content); err != nil {
+				b.Fatal(err)
+			}
+			if err := client.Relate(ctx, "file", "file-1", "file_has_vector", "vector_chunk", rec.ID, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkStoreBatched(b *testing.B) {
+	records := benchmarkRecords(1000)
+	relations := benchmarkRelations(records)
+	ok := []any{map[string]any{"status": "OK", "time": "1µs", "result": []any{}}}
+	client := (&fakeRPC{delay: 200 * time.Microsecond, results: map[string]any{"query": ok}}).client(b)
+	client.runner = sdkRunner{}
+	client.BatchSize = DefaultBatchSize
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.UpsertBatch(ctx, "vector_chunk", records); err != nil {
+			b.Fatal(err)
+		}
+		if err := client.ExecBatch(ctx, relations); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -3,12 +3,17 @@ package surreal
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	surrealdb "github.com/surrealdb/surrealdb.go"
 	"github.com/surrealdb/surrealdb.go/pkg/models"
 )
@@ -27,21 +32,54 @@ func (sdkRunner) Run(ctx context.Context, db *surrealdb.DB, sql string, vars map
 	return err
 }
 
+// Reconnect policy for dropped WebSocket connections.
+const (
+	reconnectAttempts   = 10
+	reconnectBaseDelay  = 1 * time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
 // Client wraps the SurrealDB Go SDK for PCS/1.3-native usage.
 type Client struct {
 	ns     string
 	dbName string
 
-	Db     *surrealdb.DB
+	mu     sync.RWMutex
+	db     *surrealdb.DB
 	runner queryRunner
+	// redial is closed when the reconnect in progress ends, with its error in
+	// redialErr; it is nil when no reconnect is running. Both are guarded by mu.
+	redial    chan struct{}
+	redialErr error
+
+	// dial opens a fresh authenticated connection with the namespace and
+	// database selected. It is nil for clients built without NewClient.
+	dial         func(ctx context.Context) (*surrealdb.DB, error)
+	backoffBase  time.Duration
+	backoffLimit time.Duration
+
+	// BatchSize caps statements per round-trip in UpsertBatch; zero uses DefaultBatchSize.
+	BatchSize int
+	// Logger receives query and connection logs; nil uses slog.Default().
+	Logger *slog.Logger
+	// QueryTimeout bounds each operation whose context has no deadline; zero
+	// leaves operations unbounded.
+	QueryTimeout time.Duration
 }
 
+// ErrQueryTimeout is returned when an operation exceeds Client.QueryTimeout.
+var ErrQueryTimeout = errors.New("surreal query timed out")
+
 // NewClient constructs a Surreal client using the official SDK.
 // urlStr may be http/https/ws/wss. It will be normalized to ws(s)://.../rpc for the SDK.
-func NewClient(urlStr, user, pass, ns, db string) (*Client, error) {
+// connectTimeout bounds the initial dial, signin and namespace selection.
+func NewClient(urlStr, user, pass, ns, db string, connectTimeout time.Duration) (*Client, error) {
 	if strings.TrimSpace(urlStr) == "" {
 		return nil, fmt.Errorf("surreal url is required")
 	}
+	if connectTimeout <= 0 {
+		return nil, fmt.Errorf("surreal connect timeout must be positive, got %s", connectTimeout)
+	}
 	u, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid surreal url: %w", err)
@@ -53,33 +91,214 @@ func NewClient(urlStr, user, pass, ns, db string) (*Client, error) {
 	case "https":
 		u.Scheme = "wss"
 	}
+	endpoint := u.Scheme + "://" + u.Host + u.Path
+
+	dial := func(ctx context.Context) (*surrealdb.DB, error) {
+		// Connect SDK client using the endpoint URL (supports ws/wss directly)
+		sdk, err := surrealdb.FromEndpointURLString(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("connect surreal sdk: %w", err)
+		}
+
+		// Authenticate if credentials provided
+		if strings.TrimSpace(user) != "" || strings.TrimSpace(pass) != "" {
+			if _, err := sdk.SignIn(ctx, surrealdb.Auth{Username: user, Password: pass}); err != nil {
+				_ = sdk.Close(ctx)
+				return nil, fmt.Errorf("surreal signin: %w", err)
+			}
+		}
 
-	// Connect SDK client using the endpoint URL (supports ws/wss directly)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// Select namespace and database
+		if err := sdk.Use(ctx, ns, db); err != nil {
+			_ = sdk.Close(ctx)
+			return nil, fmt.Errorf("surreal use ns/db: %w", err)
+		}
+		return sdk, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
 	defer cancel()
-	sdk, err := surrealdb.FromEndpointURLString(ctx, u.Scheme+"://"+u.Host+u.Path)
+	sdk, err := dial(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("connect surreal sdk: %w", err)
+		return nil, err
+	}
+
+	return &Client{
+		ns:           ns,
+		dbName:       db,
+		db:           sdk,
+		runner:       sdkRunner{},
+		dial:         dial,
+		backoffBase:  reconnectBaseDelay,
+		backoffLimit: reconnectMaxBackoff,
+	}, nil
+}
+
+// DB returns the current SDK connection. Callers that run SDK functions
+// directly should fetch it per call so they pick up reconnected sessions.
+func (c *Client) DB() *surrealdb.DB {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.db
+}
+
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+// do runs fn against the current connection. If fn fails because the
+// connection was closed, the client re-dials with exponential backoff and
+// runs fn once more on the new connection, so fn must be safe to repeat. Each
+// attempt gets QueryTimeout when ctx carries no deadline of its own.
+func (c *Client) do(ctx context.Context, fn func(ctx context.Context, db *surrealdb.DB) error) error {
+	return c.run(ctx, true, fn)
+}
+
+// doOnce is do for operations that must not run twice, such as statements
+// that create records or edges: the server may have applied them before the
+// connection dropped. It still reconnects so later calls succeed, but returns
+// the original error.
+func (c *Client) doOnce(ctx context.Context, fn func(ctx context.Context, db *surrealdb.DB) error) error {
+	return c.run(ctx, false, fn)
+}
+
+func (c *Client) run(ctx context.Context, retry bool, fn func(ctx context.Context, db *surrealdb.DB) error) error {
+	start := time.Now()
+	defer func() { metrics.SurrealQueryDuration.Observe(time.Since(start).Seconds()) }()
+	db := c.DB()
+	err := c.attempt(ctx, db, fn)
+	if err == nil || !isConnClosed(err) || c.dial == nil {
+		return err
+	}
+	c.logger().Warn("surreal connection lost; reconnecting", "err", err, "retry", retry)
+	fresh, rerr := c.reconnect(ctx, db)
+	if rerr != nil {
+		return fmt.Errorf("%w (reconnect failed: %v)", err, rerr)
+	}
+	if !retry {
+		return fmt.Errorf("%w (reconnected; not retried as it may already have been applied)", err)
+	}
+	return c.attempt(ctx, fresh, fn)
+}
+
+// attempt runs fn once, bounded by QueryTimeout when ctx has no deadline. A
+// failure caused by that timeout is reported as ErrQueryTimeout.
+func (c *Client) attempt(ctx context.Context, db *surrealdb.DB, fn func(ctx context.Context, db *surrealdb.DB) error) error {
+	if _, ok := ctx.Deadline(); ok || c.QueryTimeout <= 0 {
+		return fn(ctx, db)
+	}
+	opCtx, cancel := context.WithTimeout(ctx, c.QueryTimeout)
+	defer cancel()
+	err := fn(opCtx, db)
+	if err != nil && ctx.Err() == nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", ErrQueryTimeout, c.QueryTimeout, err)
+	}
+	return err
+}
+
+// reconnect replaces stale with a freshly dialled connection. If another caller
+// already replaced it, the newer connection is returned without dialling, and
+// if another caller is reconnecting, its result is awaited. mu is not held
+// while dialling or backing off, so DB and other callers are never blocked
+// behind the backoff.
+func (c *Client) reconnect(ctx context.Context, stale *surrealdb.DB) (*surrealdb.DB, error) {
+	c.mu.Lock()
+	if c.db != stale && c.db != nil {
+		db := c.db
+		c.mu.Unlock()
+		return db, nil
+	}
+	if wait := c.redial; wait != nil {
+		c.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.redialErr != nil {
+			return nil, c.redialErr
+		}
+		return c.db, nil
 	}
+	done := make(chan struct{})
+	c.redial = done
+	c.mu.Unlock()
+
+	fresh, err := c.redialLoop(ctx)
 
-	// Authenticate if credentials provided
-	if strings.TrimSpace(user) != "" || strings.TrimSpace(pass) != "" {
-		if _, err := sdk.SignIn(ctx, surrealdb.Auth{Username: user, Password: pass}); err != nil {
-			return nil, fmt.Errorf("surreal signin: %w", err)
+	c.mu.Lock()
+	if err == nil {
+		c.db = fresh
+	}
+	c.redial, c.redialErr = nil, err
+	c.mu.Unlock()
+	close(done)
+	if err == nil && stale != nil {
+		_ = stale.Close(context.Background())
+	}
+	return fresh, err
+}
+
+// redialLoop dials with exponential backoff until it succeeds, ctx ends or
+// reconnectAttempts are used up.
+func (c *Client) redialLoop(ctx context.Context) (*surrealdb.DB, error) {
+	delay := c.backoffBase
+	var lastErr error
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		fresh, err := c.dial(ctx)
+		if err == nil {
+			c.logger().Info("surreal reconnected", "attempts", attempt)
+			return fresh, nil
+		}
+		lastErr = err
+		c.logger().Warn("surreal reconnect attempt failed", "attempt", attempt, "max_attempts", reconnectAttempts, "err", err)
+		delay *= 2
+		if delay > c.backoffLimit {
+			delay = c.backoffLimit
 		}
 	}
+	return nil, fmt.Errorf("gave up after %d attempts: %w", reconnectAttempts, lastErr)
+}
 
-	// Select namespace and database
-	if err := sdk.Use(ctx, ns, db); err != nil {
-		return nil, fmt.Errorf("surreal use ns/db: %w", err)
+// isConnClosed reports whether err indicates the WebSocket connection is gone.
+func isConnClosed(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
 	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"use of closed connection",
+		"use of closed network connection",
+		"connection closed",
+		"connection reset",
+		"broken pipe",
+		"websocket: close",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
 
-	return &Client{
-		ns:     ns,
-		dbName: db,
-		Db:     sdk,
-		runner: sdkRunner{},
-	}, nil
+// Ping runs a trivial statement to confirm the connection is usable.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		return c.runner.Run(ctx, db, "RETURN true;", nil)
+	})
 }
 
 // Exec runs the provided statements in a single multi-statement query.
@@ -107,10 +326,17 @@ func (c *Client) execChunk(ctx context.Context, stmts []string) error {
 		buf.WriteByte('\n')
 	}
 
-	log.Printf("[SQL] batch:\n%s", buf.String())
+	if len(stmts) == 1 {
+		c.logger().Debug("surreal exec", "statements", 1, "sql", buf.String())
+	} else {
+		c.logger().Debug("surreal exec", "statements", len(stmts), "first", truncateStatement(stmts[0]))
+	}
 
 	// Execute via SDK. We ignore results and rely on errors from the driver.
-	if err := c.runner.Run(ctx, c.Db, buf.String(), nil); err != nil {
+	// Statements such as RELATE are not idempotent, so they are not retried.
+	if err := c.doOnce(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		return c.runner.Run(ctx, db, buf.String(), nil)
+	}); err != nil {
 		return fmt.Errorf("surreal query failed: %w", err)
 	}
 	return nil
@@ -133,8 +359,10 @@ func truncateStatement(stmt string) string {
 
 // UpsertRecord upserts a specific record by table and ID with the provided content.
 func (c *Client) UpsertRecord(ctx context.Context, table, id string, content map[string]any) error {
-	_, err := surrealdb.Upsert[map[string]any](ctx, c.Db, models.NewRecordID(table, id), content)
-	return err
+	return c.do(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		_, err := surrealdb.Upsert[map[string]any](ctx, db, models.NewRecordID(table, id), content)
+		return err
+	})
 }
 
 // MergeRecord merges the provided content into an existing record without overwriting unspecified fields.
@@ -142,27 +370,64 @@ func (c *Client) MergeRecord(ctx context.Context, table, id string, content map[
 	if len(content) == 0 {
 		return nil
 	}
-	_, err := surrealdb.Merge[map[string]any](ctx, c.Db, models.NewRecordID(table, id), content)
-	return err
+	return c.do(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		_, err := surrealdb.Merge[map[string]any](ctx, db, models.NewRecordID(table, id), content)
+		return err
+	})
 }
 
 // Relate creates a relation from in -> relation -> out with optional data.
+// Each call creates a new edge, so it is not retried after a reconnect.
 func (c *Client) Relate(ctx context.Context, inTable, inID, relation, outTable, outID string, data map[string]any) error {
-	_, err := surrealdb.Relate[any](ctx, c.Db, &surrealdb.Relationship{
-		In:       models.NewRecordID(inTable, inID),
-		Out:      models.NewRecordID(outTable, outID),
-		Relation: models.Table(relation),
-		Data:     data,
+	return c.doOnce(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		_, err := surrealdb.Relate[any](ctx, db, &surrealdb.Relationship{
+			In:       models.NewRecordID(inTable, inID),
+			Out:      models.NewRecordID(outTable, outID),
+			Relation: models.Table(relation),
+			Data:     data,
+		})
+		return err
+	})
+}
+
+// DeleteRecord deletes a single record by table and ID. Deleting a record that
+// does not exist is not an error.
+func (c *Client) DeleteRecord(ctx context.Context, table, id string) error {
+	return c.do(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		_, err := surrealdb.Delete[any](ctx, db, models.NewRecordID(table, id))
+		return err
 	})
-	return err
+}
+
+// SelectRecord fetches a single record by table and ID into T. It returns nil
+// without error when the record does not exist.
+func SelectRecord[T any](ctx context.Context, c *Client, table, id string) (*T, error) {
+	var res *T
+	err := c.do(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		var err error
+		res, err = surrealdb.Select[T](ctx, db, models.NewRecordID(table, id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // Query executes a SurrealQL statement and unmarshals the first result set into dst.
+// It is retried after a reconnect, so sql must be safe to run twice.
+// A statement that fails on the server is reported as a *surrealdb.QueryError,
+// which callers can match with errors.As.
 func Query[T any](ctx context.Context, c *Client, sql string, vars map[string]any) ([]T, error) {
 	if vars == nil {
 		vars = map[string]any{}
 	}
-	res, err := surrealdb.Query[[]T](ctx, c.Db, sql, vars)
+	var res *[]surrealdb.QueryResult[[]T]
+	err := c.do(ctx, func(ctx context.Context, db *surrealdb.DB) error {
+		var err error
+		res, err = surrealdb.Query[[]T](ctx, db, sql, vars)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
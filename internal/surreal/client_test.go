@@ -2,9 +2,11 @@ package surreal
 
 import (
     "context"
+    "errors"
     "fmt"
     "strings"
     "testing"
+    "time"
 
     surrealdb "github.com/surrealdb/surrealdb.go"
 )
@@ -40,3 +42,175 @@ func TestClientExecJoinsStatements(t *testing.T) {
         t.Fatalf("batch missing trailing semicolon: %s", b)
     }
 }
+
+type flakyRunner struct {
+    failures int
+    calls    int
+}
+
+func (f *flakyRunner) Run(_ context.Context, _ *surrealdb.DB, _ string, _ map[string]any) error {
+    f.calls++
+    if f.calls <= f.failures {
+        return errors.New("write: use of closed connection")
+    }
+    return nil
+}
+
+func TestClientPingReconnectsOnClosedConnection(t *testing.T) {
+    r := &flakyRunner{failures: 1}
+    dials := 0
+    client := &Client{
+        ns:     "chaos",
+        dbName: "smith",
+        runner: r,
+        dial: func(context.Context) (*surrealdb.DB, error) {
+            dials++
+            return nil, nil
+        },
+        backoffBase:  time.Millisecond,
+        backoffLimit: time.Millisecond,
+    }
+
+    if err := client.Ping(context.Background()); err != nil {
+        t.Fatalf("ping after reconnect: %v", err)
+    }
+    if dials != 1 {
+        t.Fatalf("expected 1 dial, got %d", dials)
+    }
+    if r.calls != 2 {
+        t.Fatalf("expected query retried once, got %d calls", r.calls)
+    }
+}
+
+func TestClientExecReconnectsWithoutRetrying(t *testing.T) {
+    r := &flakyRunner{failures: 1}
+    dials := 0
+    client := &Client{
+        ns:     "chaos",
+        dbName: "smith",
+        runner: r,
+        dial: func(context.Context) (*surrealdb.DB, error) {
+            dials++
+            return nil, nil
+        },
+        backoffBase:  time.Millisecond,
+        backoffLimit: time.Millisecond,
+    }
+
+    if err := client.Exec(context.Background(), []string{"RELATE a:1->edge->b:1"}); err == nil {
+        t.Fatalf("expected the dropped exec to be reported")
+    }
+    if dials != 1 || r.calls != 1 {
+        t.Fatalf("expected a reconnect without a retry, got %d dials and %d calls", dials, r.calls)
+    }
+    if err := client.Exec(context.Background(), []string{"RELATE a:1->edge->b:1"}); err != nil {
+        t.Fatalf("exec on the new connection: %v", err)
+    }
+}
+
+func TestClientReconnectDoesNotBlockReaders(t *testing.T) {
+    release := make(chan struct{})
+    dialing := make(chan struct{})
+    client := &Client{
+        runner: &flakyRunner{failures: 1},
+        dial: func(context.Context) (*surrealdb.DB, error) {
+            close(dialing)
+            <-release
+            return nil, nil
+        },
+        backoffBase:  time.Millisecond,
+        backoffLimit: time.Millisecond,
+    }
+
+    pinged := make(chan error, 1)
+    go func() { pinged <- client.Ping(context.Background()) }()
+    <-dialing
+
+    got := make(chan struct{})
+    go func() {
+        client.DB()
+        close(got)
+    }()
+    select {
+    case <-got:
+    case <-time.After(time.Second):
+        t.Fatalf("DB blocked while a reconnect was dialling")
+    }
+
+    // A second caller waits for the reconnect in progress instead of
+    // dialling again, and gives up when its own context ends.
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+    if _, err := client.reconnect(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("expected the waiting caller to time out, got %v", err)
+    }
+
+    close(release)
+    if err := <-pinged; err != nil {
+        t.Fatalf("ping after reconnect: %v", err)
+    }
+}
+
+func TestClientExecDoesNotRetryOtherErrors(t *testing.T) {
+    dials := 0
+    client := &Client{
+        runner: runnerFunc(func() error { return errors.New("parse error") }),
+        dial: func(context.Context) (*surrealdb.DB, error) {
+            dials++
+            return nil, nil
+        },
+    }
+    if err := client.Exec(context.Background(), []string{"BOGUS"}); err == nil {
+        t.Fatalf("expected error")
+    }
+    if dials != 0 {
+        t.Fatalf("expected no reconnect for non-connection error, got %d dials", dials)
+    }
+}
+
+type runnerFunc func() error
+
+func (f runnerFunc) Run(context.Context, *surrealdb.DB, string, map[string]any) error {
+    return f()
+}
+
+type blockingRunner struct{}
+
+func (blockingRunner) Run(ctx context.Context, _ *surrealdb.DB, _ string, _ map[string]any) error {
+    <-ctx.Done()
+    return ctx.Err()
+}
+
+func TestClientQueryTimeout(t *testing.T) {
+    client := &Client{runner: blockingRunner{}, QueryTimeout: 10 * time.Millisecond}
+
+    err := client.Exec(context.Background(), []string{"SLEEP 1s"})
+    if !errors.Is(err, ErrQueryTimeout) {
+        t.Fatalf("expected ErrQueryTimeout, got %v", err)
+    }
+
+    // A caller deadline takes precedence and is not reported as ErrQueryTimeout.
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+    err = client.Exec(ctx, []string{"SLEEP 1s"})
+    if err == nil || errors.Is(err, ErrQueryTimeout) {
+        t.Fatalf("expected caller deadline error, got %v", err)
+    }
+}
+
+func TestNewClientRequiresPositiveConnectTimeout(t *testing.T) {
+    if _, err := NewClient("ws://127.0.0.1:1", "", "", "chaos", "smith", 0); err == nil {
+        t.Fatalf("expected error for zero connect timeout")
+    }
+}
+
+func TestNewClientConnectTimeoutFailsFast(t *testing.T) {
+    // 192.0.2.0/24 is reserved for documentation and never routable.
+    start := time.Now()
+    if _, err := NewClient("ws://192.0.2.1:8000", "", "", "chaos", "smith", 50*time.Millisecond); err == nil {
+        t.Fatalf("expected connect error")
+    }
+    if elapsed := time.Since(start); elapsed > 5*time.Second {
+        t.Fatalf("connect took %s despite 50ms timeout", elapsed)
+    }
+}
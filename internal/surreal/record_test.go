@@ -0,0 +1,149 @@
+package surreal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	surrealdb "github.com/surrealdb/surrealdb.go"
+	"github.com/surrealdb/surrealdb.go/pkg/models"
+	"github.com/surrealdb/surrealdb.go/surrealcbor"
+)
+
+// fakeRPC answers SurrealDB HTTP RPC calls with canned results, after delay
+// when set, and records the methods and record ids it was asked about.
+type fakeRPC struct {
+	results map[string]any // keyed by method
+	delay   time.Duration
+	calls   []string
+	targets []models.RecordID
+}
+
+func (f *fakeRPC) client(t testing.TB) *Client {
+	t.Helper()
+	codec := surrealcbor.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rpc" {
+			return // health check
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			ID     any    `json:"id"`
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		if err := codec.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.delay > 0 {
+			time.Sleep(f.delay)
+		}
+		f.calls = append(f.calls, req.Method)
+		if len(req.Params) > 0 {
+			if rid, ok := req.Params[0].(models.RecordID); ok {
+				f.targets = append(f.targets, rid)
+			}
+		}
+		resp := map[string]any{"id": req.ID}
+		if res, ok := f.results[req.Method]; ok {
+			resp["result"] = res
+		}
+		out, _ := codec.Marshal(resp)
+		w.Header().Set("Content-Type", "application/cbor")
+		_, _ = w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	db, err := surrealdb.FromEndpointURLString(ctx, srv.URL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := db.Use(ctx, "chaos", "smith"); err != nil {
+		t.Fatalf("use: %v", err)
+	}
+	return &Client{ns: "chaos", dbName: "smith", db: db, runner: &fakeRunner{}}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	f := &fakeRPC{}
+	client := f.client(t)
+
+	if err := client.DeleteRecord(context.Background(), "workspace", "ws-1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(f.calls) != 1 || f.calls[0] != "delete" {
+		t.Fatalf("expected one delete call, got %v", f.calls)
+	}
+	if want := models.NewRecordID("workspace", "ws-1"); len(f.targets) != 1 || f.targets[0] != want {
+		t.Fatalf("expected target %v, got %v", want, f.targets)
+	}
+}
+
+func TestSelectRecord(t *testing.T) {
+	type node struct {
+		Hostname string `json:"hostname"`
+	}
+	f := &fakeRPC{results: map[string]any{"select": map[string]any{"hostname": "forge"}}}
+	client := f.client(t)
+
+	got, err := SelectRecord[node](context.Background(), client, "node", "n1")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if got == nil || got.Hostname != "forge" {
+		t.Fatalf("unexpected record %+v", got)
+	}
+	if want := models.NewRecordID("node", "n1"); len(f.targets) != 1 || f.targets[0] != want {
+		t.Fatalf("expected target %v, got %v", want, f.targets)
+	}
+}
+
+func TestSelectRecordMissing(t *testing.T) {
+	f := &fakeRPC{}
+	client := f.client(t)
+
+	got, err := SelectRecord[map[string]any](context.Background(), client, "node", "missing")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil for missing record, got %v", *got)
+	}
+}
+
+func TestQueryReturnsFirstResultSet(t *testing.T) {
+	type row struct {
+		Name string `json:"name"`
+	}
+	f := &fakeRPC{results: map[string]any{"query": []any{
+		map[string]any{"status": "OK", "time": "1ms", "result": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}},
+	}}}
+	client := f.client(t)
+
+	rows, err := Query[row](context.Background(), client, "SELECT name FROM thing", nil)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Name != "a" || rows[1].Name != "b" {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+}
+
+func TestQueryReturnsStatementError(t *testing.T) {
+	f := &fakeRPC{results: map[string]any{"query": []any{
+		map[string]any{"status": "ERR", "time": "1ms", "result": "There was a problem with the database"},
+	}}}
+	client := f.client(t)
+
+	_, err := Query[map[string]any](context.Background(), client, "SELEC oops", nil)
+	var qerr *surrealdb.QueryError
+	if !errors.As(err, &qerr) || qerr.Message != "There was a problem with the database" {
+		t.Fatalf("expected a QueryError, got %v", err)
+	}
+}
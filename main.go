@@ -2,141 +2,326 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/auditlog"
 	"github.com/CryingSurrogate/chaosmith-core/internal/config"
 	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
 	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
 	"github.com/CryingSurrogate/chaosmith-core/tools"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func main() {
-	log.SetFlags(0)
-
 	cfgPathFlag := flag.String("config", "etc/centralmcp.toml", "path to chaosmith central config (TOML)")
-	listenAddrFlag := flag.String("listen", ":9878", "HTTP listen address for MCP Streamable HTTP endpoint")
+	listenAddrFlag := flag.String("listen", ":9878", "HTTP listen address for MCP Streamable HTTP endpoint: \":port\", \"host:port\" or \"unix:/path\" for a Unix domain socket")
 	enableStdio := flag.Bool("stdio", false, "also serve MCP over stdio (optional)")
+	socketPathFlag := flag.String("socket", "", "also serve HTTP on this Unix domain socket path (optional)")
 	flag.Parse()
 
 	configPath := resolveConfigPath(*cfgPathFlag)
 	cfg, err := config.Load(configPath)
 	if err != nil {
-		log.Fatalf("config error: %v", err)
+		fatal(slog.Default(), "config error", err)
+	}
+	if err := cfg.Prepare(); err != nil {
+		fatal(slog.Default(), "config error", err)
+	}
+
+	logger, err := newLogger(cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fatal(slog.Default(), "config error", err)
 	}
+	slog.SetDefault(logger)
 
-	surrealClient, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB)
+	surrealClient, err := connectSurreal(cfg, logger)
 	if err != nil {
-		log.Fatalf("surreal client: %v", err)
+		fatal(logger, "surreal client", err)
 	}
+	surrealClient.BatchSize = cfg.SurrealBatchSize
+	surrealClient.QueryTimeout = time.Duration(cfg.SurrealQueryTimeoutMS) * time.Millisecond
+	surrealClient.Logger = logger
 
-	indexEngine, err := indexer.New(cfg, surrealClient)
+	indexEngine, err := indexer.New(cfg, surrealClient, logger)
 	if err != nil {
-		log.Fatalf("indexer init: %v", err)
+		fatal(logger, "indexer init", err)
 	}
-	embedClient := embedder.New(cfg.EmbedURL, cfg.EmbedModel)
+	embedClient, err := embedder.NewFromConfig(cfg)
+	if err != nil {
+		fatal(logger, "embedder init", err)
+	}
+	embedClient.Logger = logger
+	// Queries must be normalized the same way as stored vectors.
+	embedClient.Normalize = cfg.NormalizeEmbeddings
+	// Queries must also be projected like the stored vectors.
+	transform, err := embxform.Resolve(context.Background(), cfg.TransformPath, surrealClient, cfg.TransformID)
+	if err != nil {
+		fatal(logger, "load transform", err)
+	}
+
+	tools.ConfigureExecRateLimit(cfg.MaxExecPerMinute, cfg.ExecRateLimits)
+	tools.ConfigureExecPolicy(cfg.ExecAllow, cfg.ExecDeny)
 
-	server := mcp.NewServer(&mcp.Implementation{Name: "chaosmith-central", Version: "v0.2.0"}, nil)
-	l1 := &tools.L1IndexerTools{Engine: indexEngine}
+	var audit *auditlog.Logger
+	if cfg.AuditLogPath != "" {
+		audit, err = auditlog.Open(cfg.AuditLogPath, logger)
+		if err != nil {
+			fatal(logger, "audit log", err)
+		}
+		tools.ConfigureAuditLog(audit)
+	}
+
+	impl := &mcp.Implementation{Name: "chaosmith-central", Version: "v0.2.0"}
+	server := mcp.NewServer(impl, nil)
+	info := &tools.ServerInfo{
+		Name:        impl.Name,
+		Version:     impl.Version,
+		EmbedModel:  cfg.EmbedModel,
+		TokenizerID: cfg.TokenizerID,
+		TransformID: cfg.TransformID,
+	}
+	l1 := &tools.L1IndexerTools{Engine: indexEngine, DB: surrealClient}
+	gitSync := &tools.WorkspaceSyncGit{DB: surrealClient, Engine: indexEngine}
+	runHistory := &tools.RunHistory{DB: surrealClient}
 	listNodes := &tools.ListNodes{DB: surrealClient}
 	listWorkspaces := &tools.ListWorkspaces{DB: surrealClient}
 	nodereg := &tools.NodeRegister{DB: surrealClient}
-	fileVector := &tools.FileVectorSearch{DB: surrealClient, Embedder: embedClient}
+	heartbeat := &tools.NodeHeartbeat{DB: surrealClient}
+	fileVector := &tools.FileVectorSearch{DB: surrealClient, Embedder: embedClient, CandidateMultiplier: cfg.KNNCandidateMultiplier, Transform: transform}
 	findFile := &tools.FindFile{DB: surrealClient}
 	fileTextSearch := &tools.FileSearchText{DB: surrealClient}
 	textSearch := &tools.WorkspaceSearchText{DB: surrealClient}
 	tree := &tools.WorkspaceTree{DB: surrealClient}
-	wsVector := &tools.WorkspaceVectorSearch{DB: surrealClient, Embedder: embedClient}
+	wsVector := &tools.WorkspaceVectorSearch{DB: surrealClient, Embedder: embedClient, Transform: transform, CandidateMultiplier: cfg.KNNCandidateMultiplier}
+	wsBM25 := &tools.WorkspaceBM25Search{DB: surrealClient}
+	globalText := &tools.GlobalSearchText{DB: surrealClient}
 	wsreg := &tools.WorkspaceRegister{DB: surrealClient}
 	reader := &tools.ReadWorkspaceFile{DB: surrealClient}
-
-	mcp.AddTool(server, &mcp.Tool{
+	writer := &tools.WriteWorkspaceFile{DB: surrealClient}
+	deleter := &tools.DeleteWorkspaceFile{DB: surrealClient, TrashDir: filepath.Join(cfg.ArtifactRoot, "trash")}
+	duplicates := &tools.WorkspaceDuplicates{DB: surrealClient}
+	wsDiff := &tools.WorkspaceDiff{DB: surrealClient}
+	vectorModels := &tools.VectorModels{DB: surrealClient}
+	transforms := &tools.TransformList{DB: surrealClient}
+	embedQuery := &tools.EmbedQuery{Embedder: embedClient}
+	embedHealth := &tools.EmbedHealth{Embedder: embedClient}
+	chunkList := &tools.VectorChunkList{DB: surrealClient}
+	coverage := &tools.EmbedCoverage{DB: surrealClient}
+
+	addTool(server, info, &mcp.Tool{
 		Name:        "index_workspace_scan",
 		Description: "PCS/1.3-native L1 scan: enumerate workspace directories/files and commit to SurrealDB.",
-	}, l1.Scan)
+	}, tools.Recover(l1.Scan))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "index_workspace_embed",
 		Description: "PCS/1.3-native L1 embedding: call local embedding executor and store vector_chunk rows.",
-	}, l1.Embed)
+	}, tools.Recover(l1.Embed))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
+		Name:        "workspace_reindex",
+		Description: "Re-run indexing for a registered workspace using the path stored on its workspace record; scans and embeds by default, or only embeds with fullPipeline=false.",
+	}, tools.Recover(l1.Reindex))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "workspace_sync_git",
+		Description: "Fetch and fast-forward a Git workspace to origin/<branch>, record the new rev and re-index when commits were pulled; dryRun reports what would be pulled",
+	}, tools.Recover(gitSync.Sync))
+
+	addTool(server, info, &mcp.Tool{
 		Name:        "index_workspace_all",
 		Description: "Run full L1 pipeline (scan + embed) with UDCS-compliant reporting.",
-	}, l1.All)
+	}, tools.Recover(l1.All))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "index_cancel",
+		Description: "Cancel an in-flight index run by runId; the run returns a failed report noting the cancellation.",
+	}, tools.Recover(l1.Cancel))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "run_list",
+		Description: "List stored index run reports, newest first, optionally filtered by workspace, step or outcome",
+	}, tools.Recover(runHistory.List))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
+		Name:        "run_get",
+		Description: "Fetch the stored report of an index run by runId",
+	}, tools.Recover(runHistory.Get))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "embed_coverage",
+		Description: "Report which workspace files have no vector chunks and the overall embedding coverage",
+	}, tools.Recover(coverage.Report))
+
+	addTool(server, info, &mcp.Tool{
 		Name:        "node_register",
 		Description: "Upsert a node record with optional metadata so workspaces can target it",
-	}, nodereg.Register)
+	}, tools.Recover(nodereg.Register))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "node_heartbeat",
+		Description: "Stamp last_seen (and an optional status) on a registered node so stale nodes can be pruned",
+	}, tools.Recover(heartbeat.Beat))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "node_list",
-		Description: "List all registered nodes with metadata",
-	}, listNodes.List)
+		Description: "List registered nodes with metadata, optionally filtered by kind or label",
+	}, tools.Recover(listNodes.List))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_list",
 		Description: "List all registered workspaces",
-	}, listWorkspaces.List)
+	}, tools.Recover(listWorkspaces.List))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_tree",
 		Description: "Return directory and file tree for a workspace",
-	}, tree.List)
+	}, tools.Recover(tree.List))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_find_file",
 		Description: "Find files in a workspace by exact/partial path",
-	}, findFile.Search)
+	}, tools.Recover(findFile.Search))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "workspace_duplicates",
+		Description: "Group workspace files by content sha and return groups with more than one path",
+	}, tools.Recover(duplicates.List))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "workspace_diff",
+		Description: "Compare the indexed files of two workspaces by relpath and sha: files only in A, only in B, and changed",
+	}, tools.Recover(wsDiff.Diff))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_search_text",
 		Description: "Find exact text within workspace files",
-	}, textSearch.Search)
+	}, tools.Recover(textSearch.Search))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "file_search_text",
 		Description: "Find exact text within a specific workspace file",
-	}, fileTextSearch.Search)
+	}, tools.Recover(fileTextSearch.Search))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "file_vector_search",
 		Description: "Vector similarity search within a workspace file",
-	}, fileVector.Search)
+	}, tools.Recover(fileVector.Search))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_vector_search",
 		Description: "Vector similarity search across a workspace",
-	}, wsVector.Search)
-
-	mcp.AddTool(server, &mcp.Tool{
+	}, tools.Recover(wsVector.Search))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "workspace_bm25_search",
+		Description: "BM25 keyword search over stored file content in a workspace",
+	}, tools.Recover(wsBM25.Search))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "global_search_text",
+		Description: "Exact text search across several (or all) registered workspaces",
+	}, tools.Recover(globalText.Search))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "vector_model_list",
+		Description: "List vector models with dimensions and the number of chunks referencing each",
+	}, tools.Recover(vectorModels.List))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "vector_model_delete",
+		Description: "Delete a vector model; refuses while chunks reference it unless force cascades their deletion",
+	}, tools.Recover(vectorModels.Delete))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "transform_list",
+		Description: "List PCA transforms stored in SurrealDB by build-pca -store, with their native and output dimensions",
+	}, tools.Recover(transforms.List))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "embed_query",
+		Description: "Embed arbitrary text with the configured (or given) model and return the vector, its dimension and L2 norm; for inspecting embeddings and debugging search",
+	}, tools.Recover(embedQuery.Embed))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "embed_health",
+		Description: "Report each embedding endpoint's health as seen by recent requests: healthy, out of rotation until, and last error",
+	}, tools.Recover(embedHealth.Report))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "vector_chunk_list",
+		Description: "List the vector_chunk records stored for a file (offsets, token counts, content sha, model, optionally vectors) ordered by chunk index",
+	}, tools.Recover(chunkList.List))
+
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_register",
 		Description: "Upsert a workspace bound to an existing node so scan/embed have a target.",
-	}, wsreg.Register)
+	}, tools.Recover(wsreg.Register))
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(server, info, &mcp.Tool{
 		Name:        "workspace_read_file",
-		Description: "Read a file span from a workspace with optional hex encoding.",
-	}, reader.Read)
+		Description: "Read a file span from a workspace with optional hex encoding, or its first (head) or last (tail) N lines.",
+	}, tools.Recover(reader.Read))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "write_workspace_file",
+		Description: "Create, overwrite or append to a UTF-8 file in a workspace; writes atomically and refreshes the file record's sha, size and mtime.",
+	}, tools.Recover(writer.Write))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "delete_workspace_file",
+		Description: "Delete a workspace file and its file, chunk and edge records; the file is moved to the trash unless permanent is set.",
+	}, tools.Recover(deleter.Delete))
+
+	addTool(server, info, &mcp.Tool{
+		Name:        "trash_empty",
+		Description: "Permanently remove files moved to the trash by delete_workspace_file.",
+	}, tools.Recover(deleter.Empty))
+
+	if !tools.ExecDisabled() {
+		addTool(server, info, &mcp.Tool{
+			Name:        "term_exec",
+			Description: "Execute a command in non-interactive terminal",
+		}, tools.Recover(tools.ExecCommand))
+
+		addTool(server, info, &mcp.Tool{
+			Name:        "term_pty",
+			Description: "Manage an interactive pseudo-terminal session scoped to the MCP session",
+		}, tools.Recover(tools.ExecPTY))
+	}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "term_exec",
-		Description: "Execute a command in non-interactive terminal",
-	}, tools.ExecCommand)
+	if cfg.EnableAdmin {
+		admin := &tools.AdminQuery{DB: surrealClient}
+		addTool(server, info, &mcp.Tool{
+			Name:        "admin_query",
+			Description: "Run a single read-only SurrealQL statement (SELECT or INFO) with optional parameters",
+		}, tools.Recover(admin.Run))
+	}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "term_pty",
-		Description: "Manage an interactive pseudo-terminal session scoped to the MCP session",
-	}, tools.ExecPTY)
+	addTool(server, info, &mcp.Tool{
+		Name:        "server_info",
+		Description: "Report the server version and build, Go runtime, configured embed model, tokenizer and transform, and registered tool names",
+	}, tools.Recover(info.Info))
+
+	resources := &tools.SystemResources{Engine: indexEngine, ArtifactRoot: cfg.ArtifactRoot}
+	addTool(server, info, &mcp.Tool{
+		Name:        "system_resources",
+		Description: "Report host capacity: OS, CPUs, server heap, free memory, free disk under artifact_root, and active PTY sessions and index runs",
+	}, tools.Recover(resources.Info))
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
@@ -146,7 +331,11 @@ func main() {
 	}, &mcp.StreamableHTTPOptions{JSONResponse: false})
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/mcp", handler.ServeHTTP)
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz(surrealClient, embedClient))
+	// Token auth guards HTTP only; stdio is trusted as a local child process.
+	// CORS sits outside auth so browser preflights succeed without a token.
+	mux.Handle("/mcp", withCORS(cfg.CORSOrigins, requireBearer(authTokens(cfg), handler)))
 
 	httpSrv := &http.Server{
 		Addr:              *listenAddrFlag,
@@ -154,29 +343,158 @@ func main() {
 		ReadHeaderTimeout: 15 * time.Second,
 	}
 
-	go func() {
-		<-ctx.Done()
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		_ = httpSrv.Shutdown(shutdownCtx)
-	}()
-
-	go func() {
-		log.Printf("chaosmith-central: StreamableHTTP listening on %s/mcp", *listenAddrFlag)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("http server: %v", err)
+	ln, err := listenHTTP(*listenAddrFlag)
+	if err != nil {
+		fatal(logger, "http listen", err)
+	}
+	listeners := []net.Listener{ln}
+	if *socketPathFlag != "" {
+		sock, err := listenUnix(*socketPathFlag)
+		if err != nil {
+			fatal(logger, "unix socket listen", err)
 		}
-	}()
+		listeners = append(listeners, sock)
+	}
+	for _, l := range listeners {
+		go func() {
+			logger.Info("chaosmith-central: StreamableHTTP listening", "network", l.Addr().Network(), "addr", l.Addr().String(), "path", "/mcp")
+			// Shutdown closes the listeners before httpSrv, so a closed listener is expected.
+			if err := httpSrv.Serve(l); err != nil && err != http.ErrServerClosed && !errors.Is(err, net.ErrClosed) {
+				fatal(logger, "http server", err)
+			}
+		}()
+	}
+
+	if cfg.MetricsAddr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.MetricsAddr, logger); err != nil {
+				fatal(logger, "metrics server", err)
+			}
+		}()
+	}
 
 	if *enableStdio {
 		go func() {
 			if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil {
-				log.Fatalf("stdio server: %v", err)
+				fatal(logger, "stdio server", err)
 			}
 		}()
 	}
 
 	<-ctx.Done()
+	shutdown(logger, httpSrv, listeners, indexEngine, time.Duration(cfg.ShutdownTimeoutSecs)*time.Second)
+	if err := audit.Close(); err != nil {
+		logger.Warn("audit log close", "err", err)
+	}
+}
+
+// maxConnectRetryDelay caps the wait between startup connection attempts.
+const maxConnectRetryDelay = 30 * time.Second
+
+// connectSurreal connects to SurrealDB, retrying with exponential backoff so
+// the server can start before the database is ready. It gives up after
+// cfg.SurrealConnectAttempts failures and returns the last error.
+func connectSurreal(cfg *config.Config, logger *slog.Logger) (*surreal.Client, error) {
+	delay := time.Duration(cfg.SurrealConnectRetryMS) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		client, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB, time.Duration(cfg.SurrealConnectTimeoutMS)*time.Millisecond)
+		if err == nil {
+			return client, nil
+		}
+		if attempt >= cfg.SurrealConnectAttempts {
+			return nil, fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+		logger.Warn("surreal connect failed; retrying", "attempt", attempt, "of", cfg.SurrealConnectAttempts, "retry_in", delay, "err", err)
+		time.Sleep(delay)
+		delay = min(delay*2, maxConnectRetryDelay)
+	}
+}
+
+// addTool registers h on server and records the tool name for server_info.
+func addTool[In, Out any](server *mcp.Server, info *tools.ServerInfo, t *mcp.Tool, h mcp.ToolHandlerFor[In, Out]) {
+	mcp.AddTool(server, t, h)
+	info.Tools = append(info.Tools, t.Name)
+}
+
+// shutdown drains the server within timeout. It stops accepting connections,
+// closes PTY sessions, lets in-flight index runs finish before cancelling the
+// rest, and shuts the HTTP server down last.
+func shutdown(logger *slog.Logger, srv *http.Server, listeners []net.Listener, ix *indexer.Indexer, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	logger.Info("chaosmith-central: shutting down", "timeout", timeout)
+	srv.SetKeepAlivesEnabled(false)
+	for _, ln := range listeners {
+		_ = ln.Close()
+	}
+
+	tools.CloseAllSessions(time.Until(deadline))
+	if n := ix.Drain(ctx); n > 0 {
+		logger.Warn("cancelled in-flight index runs", "count", n)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warn("http shutdown incomplete", "err", err)
+		_ = srv.Close()
+	}
+}
+
+// listenHTTP listens on addr, which is a TCP address or "unix:" followed by a
+// socket path.
+func listenHTTP(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return listenUnix(path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// listenUnix listens on a Unix domain socket at path, readable and writable by
+// the owner only. A stale socket left by an unclean exit is replaced; any other
+// file at path is an error. Closing the listener removes the socket file.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	return ln, nil
+}
+
+// newLogger builds the server logger from the configured level and format.
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level == "" {
+		level = "info"
+	}
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("log_level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("log_format %q: must be text or json", format)
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
 }
 
 func resolveConfigPath(proposed string) string {
@@ -186,7 +504,8 @@ func resolveConfigPath(proposed string) string {
 	if _, err := os.Stat(proposed); err == nil {
 		return proposed
 	} else if !os.IsNotExist(err) {
-		log.Fatalf("config path %s: %v", proposed, err)
+		slog.Error("config path", "path", proposed, "err", err)
+		os.Exit(1)
 	}
 
 	if envPath := os.Getenv("CHAOSMITH_CONFIG"); envPath != "" {
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// AdminQuery runs ad-hoc read-only SurrealQL for debugging the index. It is
+// only registered when enable_admin is set.
+type AdminQuery struct {
+	DB *surreal.Client
+}
+
+type AdminQueryInput struct {
+	Query string         `json:"query" jsonschema:"a single SELECT or INFO statement"`
+	Vars  map[string]any `json:"vars,omitempty" jsonschema:"query parameters bound as $name"`
+}
+
+type AdminQueryOutput struct {
+	Rows []map[string]any `json:"rows" jsonschema:"result rows of the statement"`
+}
+
+func (a *AdminQuery) Run(ctx context.Context, _ *mcp.CallToolRequest, input AdminQueryInput) (*mcp.CallToolResult, AdminQueryOutput, error) {
+	out := AdminQueryOutput{Rows: make([]map[string]any, 0)}
+	if a == nil || a.DB == nil {
+		return nil, out, fmt.Errorf("surreal client not configured")
+	}
+	query, err := readOnlyStatement(input.Query)
+	if err != nil {
+		return nil, out, err
+	}
+
+	rows, err := surreal.Query[map[string]any](ctx, a.DB, query, input.Vars)
+	if err != nil {
+		return nil, out, fmt.Errorf("admin query: %w", err)
+	}
+	if rows != nil {
+		out.Rows = rows
+	}
+	return nil, out, nil
+}
+
+// readOnlyStatement trims q and accepts it only if it is a single statement
+// starting with SELECT or INFO. This is a guard against accidents, not a
+// parser: any semicolon other than a trailing one is rejected so a read cannot
+// be chained with a write.
+func readOnlyStatement(q string) (string, error) {
+	q = strings.TrimSpace(q)
+	q = strings.TrimSpace(strings.TrimSuffix(q, ";"))
+	if q == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	if strings.Contains(q, ";") {
+		return "", fmt.Errorf("only a single statement is allowed")
+	}
+	verb := strings.ToUpper(strings.Fields(q)[0])
+	if verb != "SELECT" && verb != "INFO" {
+		return "", fmt.Errorf("only SELECT and INFO statements are allowed, got %s", verb)
+	}
+	return q, nil
+}
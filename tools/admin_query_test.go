@@ -0,0 +1,28 @@
+package tools
+
+import "testing"
+
+func TestReadOnlyStatement(t *testing.T) {
+	ok := []string{
+		"SELECT * FROM file",
+		"  select count() FROM vector_chunk GROUP ALL;  ",
+		"INFO FOR DB",
+	}
+	for _, q := range ok {
+		if _, err := readOnlyStatement(q); err != nil {
+			t.Errorf("%q: unexpected error %v", q, err)
+		}
+	}
+	bad := []string{
+		"",
+		"DELETE file",
+		"UPDATE file SET size = 0",
+		"SELECT * FROM file; DELETE file",
+		"LET $x = 1",
+	}
+	for _, q := range bad {
+		if _, err := readOnlyStatement(q); err == nil {
+			t.Errorf("%q: expected rejection", q)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type EmbedCoverage struct {
+	DB *surreal.Client
+}
+
+type EmbedCoverageInput struct {
+	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"maximum number of uncovered files to list (default 200)"`
+}
+
+type EmbedCoverageOutput struct {
+	TotalFiles    int             `json:"totalFiles" jsonschema:"files recorded by the last scan"`
+	EmbeddedFiles int             `json:"embeddedFiles" jsonschema:"files with at least one vector chunk"`
+	CoveragePct   float64         `json:"coveragePct" jsonschema:"percentage of files with vectors"`
+	Uncovered     []UncoveredFile `json:"uncovered" jsonschema:"files with zero vector chunks"`
+	Truncated     bool            `json:"truncated,omitempty" jsonschema:"true if the uncovered list was cut at limit"`
+}
+
+type UncoveredFile struct {
+	RelPath string `json:"relpath" jsonschema:"path relative to workspace root"`
+	Size    int64  `json:"size" jsonschema:"file size in bytes at scan time"`
+	Reason  string `json:"reason" jsonschema:"binary | too_large | empty | not_regular | excluded | missing | not_embedded"`
+}
+
+func (c *EmbedCoverage) Report(ctx context.Context, _ *mcp.CallToolRequest, input EmbedCoverageInput) (*mcp.CallToolResult, EmbedCoverageOutput, error) {
+	out := EmbedCoverageOutput{Uncovered: make([]UncoveredFile, 0)}
+	if c == nil || c.DB == nil {
+		return nil, out, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, out, fmt.Errorf("workspaceId is required")
+	}
+	limit := clampLimit(input.Limit, 1000)
+	if input.Limit <= 0 {
+		limit = 200
+	}
+
+	wsPath, err := lookupWorkspacePath(ctx, c.DB, wsID)
+	if err != nil {
+		return nil, out, err
+	}
+
+	type fileRow struct {
+		RelPath string `json:"relpath"`
+		Size    int64  `json:"size"`
+	}
+	const fileQuery = `
+SELECT relpath, size FROM file
+WHERE ws = type::thing('workspace', $ws_id)
+ORDER BY relpath ASC
+`
+	type coveredRow struct {
+		RelPath string `json:"relpath"`
+	}
+	const coveredQuery = `
+SELECT file.relpath AS relpath FROM vector_chunk
+WHERE ws = type::thing('workspace', $ws_id)
+GROUP BY relpath
+`
+	vars := map[string]any{"ws_id": wsID}
+
+	files, err := surreal.Query[fileRow](ctx, c.DB, fileQuery, vars)
+	if err != nil {
+		return nil, out, fmt.Errorf("list files: %w", err)
+	}
+	covered, err := surreal.Query[coveredRow](ctx, c.DB, coveredQuery, vars)
+	if err != nil {
+		return nil, out, fmt.Errorf("list embedded files: %w", err)
+	}
+	coveredSet := make(map[string]struct{}, len(covered))
+	for _, r := range covered {
+		coveredSet[r.RelPath] = struct{}{}
+	}
+
+	out.TotalFiles = len(files)
+	for _, f := range files {
+		if _, ok := coveredSet[f.RelPath]; ok {
+			out.EmbeddedFiles++
+			continue
+		}
+		if len(out.Uncovered) >= limit {
+			out.Truncated = true
+			continue
+		}
+		out.Uncovered = append(out.Uncovered, UncoveredFile{
+			RelPath: f.RelPath,
+			Size:    f.Size,
+			Reason:  uncoveredReason(filepath.Join(wsPath, filepath.FromSlash(f.RelPath))),
+		})
+	}
+	if out.TotalFiles > 0 {
+		out.CoveragePct = 100 * float64(out.EmbeddedFiles) / float64(out.TotalFiles)
+	}
+	return nil, out, nil
+}
+
+// uncoveredReason re-stats a file to explain why it has no vectors.
+func uncoveredReason(fullPath string) string {
+	reason, err := indexer.EmbedSkipReason(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "missing"
+		}
+		return "unknown"
+	}
+	if reason == "" {
+		return "not_embedded"
+	}
+	return reason
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type EmbedHealth struct {
+	Embedder *embedder.Client
+}
+
+type EmbedHealthInput struct{}
+
+type EmbedHealthOutput struct {
+	RoundRobin bool                      `json:"roundRobin" jsonschema:"true when requests rotate across endpoints"`
+	Endpoints  []embedder.EndpointHealth `json:"endpoints" jsonschema:"per-endpoint health as seen by recent requests; unhealthy endpoints are tried last until downUntil"`
+}
+
+func (e *EmbedHealth) Report(_ context.Context, _ *mcp.CallToolRequest, _ EmbedHealthInput) (*mcp.CallToolResult, EmbedHealthOutput, error) {
+	if e == nil || e.Embedder == nil {
+		return nil, EmbedHealthOutput{Endpoints: []embedder.EndpointHealth{}}, fmt.Errorf("embedder not configured")
+	}
+	return nil, EmbedHealthOutput{
+		RoundRobin: e.Embedder.RoundRobin,
+		Endpoints:  e.Embedder.Health(time.Now()),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// EmbedQuery embeds arbitrary text outside of an index run, for inspecting
+// vectors and debugging search quality.
+type EmbedQuery struct {
+	Embedder *embedder.Client
+}
+
+type EmbedQueryInput struct {
+	Text          string `json:"text" jsonschema:"text to embed"`
+	ModelID       string `json:"modelId,omitempty" jsonschema:"backend model name or vector model slug (default: the configured embed model)"`
+	Normalize     bool   `json:"normalize,omitempty" jsonschema:"return the vector scaled to unit length"`
+	IncludeVector *bool  `json:"includeVector,omitempty" jsonschema:"include the vector in the output (default true)"`
+}
+
+type EmbedQueryOutput struct {
+	Vector  []float32 `json:"vector,omitempty" jsonschema:"embedding vector"`
+	Dim     int       `json:"dim" jsonschema:"vector dimension"`
+	ModelID string    `json:"modelId" jsonschema:"model the text was embedded with"`
+	L2Norm  float64   `json:"l2norm" jsonschema:"L2 norm of the vector as returned by the embedder, before normalize"`
+}
+
+func (e *EmbedQuery) Embed(ctx context.Context, _ *mcp.CallToolRequest, input EmbedQueryInput) (*mcp.CallToolResult, EmbedQueryOutput, error) {
+	var out EmbedQueryOutput
+	if e == nil || e.Embedder == nil {
+		return nil, out, fmt.Errorf("embedder not configured")
+	}
+	if strings.TrimSpace(input.Text) == "" {
+		return nil, out, fmt.Errorf("text is required")
+	}
+
+	out.ModelID = strings.TrimSpace(input.ModelID)
+	var (
+		vecs [][]float32
+		err  error
+	)
+	if out.ModelID != "" {
+		vecs, err = e.Embedder.EmbedWithModel(ctx, out.ModelID, []string{input.Text})
+	} else {
+		out.ModelID = embedder.ModelSlug(e.Embedder.Model)
+		vecs, err = e.Embedder.Embed(ctx, []string{input.Text})
+	}
+	if err != nil {
+		return nil, out, fmt.Errorf("embed text: %w", err)
+	}
+	if len(vecs) == 0 || len(vecs[0]) == 0 {
+		return nil, out, fmt.Errorf("embedding returned empty vector")
+	}
+
+	vec := vecs[0]
+	out.Dim = len(vec)
+	if input.Normalize {
+		out.L2Norm = embedder.L2Normalize(vec)
+	} else {
+		out.L2Norm = l2Norm(vec)
+	}
+	if input.IncludeVector == nil || *input.IncludeVector {
+		out.Vector = vec
+	}
+	return nil, out, nil
+}
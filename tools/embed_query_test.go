@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+)
+
+func TestEmbedQueryNormalizesAndReportsNorm(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[3,4]}]}`))
+	}))
+	defer srv.Close()
+	c := embedder.New(srv.URL, "mock", embedder.DefaultTimeout)
+	c.Logger = slog.New(slog.DiscardHandler)
+	e := &EmbedQuery{Embedder: c}
+
+	_, out, err := e.Embed(context.Background(), nil, EmbedQueryInput{Text: "hello", Normalize: true})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if out.Dim != 2 || out.L2Norm != 5 || out.ModelID != embedder.ModelSlug("mock") {
+		t.Fatalf("unexpected output %+v", out)
+	}
+	if math.Abs(float64(out.Vector[0])-0.6) > 1e-6 || math.Abs(float64(out.Vector[1])-0.8) > 1e-6 {
+		t.Fatalf("expected unit vector, got %v", out.Vector)
+	}
+
+	omit := false
+	_, out, err = e.Embed(context.Background(), nil, EmbedQueryInput{Text: "hello", IncludeVector: &omit})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if out.Vector != nil || out.L2Norm != 5 {
+		t.Fatalf("expected norm without vector, got %+v", out)
+	}
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// execPolicy decides which commands term_exec and term_pty may start, by
+// basename.
+var execPolicy = newCommandPolicy(nil, nil)
+
+// ConfigureExecPolicy restricts term_exec and term_pty to the commands in
+// allow (all when empty) minus those in deny. Entries are matched by basename, so "rm" and
+// "/usr/bin/rm" are the same; a deny entry of "*" blocks every command. It
+// should be called once at startup.
+func ConfigureExecPolicy(allow, deny []string) {
+	execPolicy = newCommandPolicy(allow, deny)
+}
+
+// ExecDisabled reports whether the policy denies every command, in which case
+// term_exec and term_pty need not be registered at all.
+func ExecDisabled() bool {
+	return execPolicy.denyAll
+}
+
+type commandPolicy struct {
+	allow   map[string]struct{}
+	deny    map[string]struct{}
+	denyAll bool
+}
+
+func newCommandPolicy(allow, deny []string) *commandPolicy {
+	p := &commandPolicy{allow: commandSet(allow), deny: commandSet(deny)}
+	_, p.denyAll = p.deny["*"]
+	return p
+}
+
+func commandSet(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			set[commandName(n)] = struct{}{}
+		}
+	}
+	return set
+}
+
+// commandName reduces a command or path to the name lists are matched on:
+// its basename, case-folded and without .exe on Windows.
+func commandName(cmd string) string {
+	if cmd == "*" {
+		return cmd
+	}
+	name := filepath.Base(cmd)
+	if runtime.GOOS == "windows" {
+		name = strings.ToLower(name)
+		for _, ext := range []string{".exe", ".com", ".bat", ".cmd"} {
+			if trimmed, ok := strings.CutSuffix(name, ext); ok {
+				name = trimmed
+				break
+			}
+		}
+	}
+	return name
+}
+
+// check resolves command on PATH and returns the path to run, or an error when
+// the policy blocks it. Commands that do not resolve are matched as given and
+// left for exec to report.
+func (p *commandPolicy) check(command string) (string, error) {
+	resolved := command
+	if path, err := exec.LookPath(command); err == nil {
+		resolved = path
+	}
+	name := commandName(resolved)
+	if p.denyAll {
+		return "", fmt.Errorf("command %q not permitted: command execution is disabled", name)
+	}
+	if _, ok := p.deny[name]; ok {
+		return "", fmt.Errorf("command %q not permitted", name)
+	}
+	if len(p.allow) > 0 {
+		if _, ok := p.allow[name]; !ok {
+			return "", fmt.Errorf("command %q not permitted; allowed: %s", name, strings.Join(p.allowed(), ", "))
+		}
+	}
+	return resolved, nil
+}
+
+func (p *commandPolicy) allowed() []string {
+	names := make([]string, 0, len(p.allow))
+	for n := range p.allow {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
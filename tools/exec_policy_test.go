@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCommandPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix commands")
+	}
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not on PATH")
+	}
+
+	p := newCommandPolicy(nil, []string{"/usr/bin/rm"})
+	if _, err := p.check("rm"); err == nil || !strings.Contains(err.Error(), "not permitted") {
+		t.Fatalf("expected rm denied by its path, got %v", err)
+	}
+	if got, err := p.check("sh"); err != nil || got != shPath {
+		t.Fatalf("expected sh allowed as %s, got %q, %v", shPath, got, err)
+	}
+
+	p = newCommandPolicy([]string{"sh"}, nil)
+	if _, err := p.check(shPath); err != nil {
+		t.Fatalf("expected %s allowed by basename, got %v", shPath, err)
+	}
+	if _, err := p.check("ls"); err == nil || !strings.Contains(err.Error(), "allowed: sh") {
+		t.Fatalf("expected ls outside the allowlist, got %v", err)
+	}
+
+	p = newCommandPolicy([]string{"sh"}, []string{"*"})
+	if !p.denyAll {
+		t.Fatalf("expected * to deny all")
+	}
+	if _, err := p.check("sh"); err == nil {
+		t.Fatalf("expected sh denied when all commands are")
+	}
+}
+
+func TestExecCommandHonoursPolicy(t *testing.T) {
+	prev := execPolicy
+	t.Cleanup(func() { execPolicy = prev })
+	ConfigureExecPolicy(nil, []string{"*"})
+
+	if !ExecDisabled() {
+		t.Fatalf("expected term_exec disabled")
+	}
+	if _, _, err := ExecCommand(context.Background(), nil, Input{Command: "echo"}); err == nil || !strings.Contains(err.Error(), "not permitted") {
+		t.Fatalf("expected not permitted, got %v", err)
+	}
+}
+
+func TestExecPTYHonoursPolicy(t *testing.T) {
+	prev := execPolicy
+	t.Cleanup(func() { execPolicy = prev })
+	ConfigureExecPolicy(nil, []string{"sh", "bash", "pwsh"})
+
+	_, out, err := ExecPTY(context.Background(), nil, PTYInput{SessionID: "policy-test", Action: "open", Command: "sh"})
+	if err == nil || !strings.Contains(err.Error(), "not permitted") {
+		t.Fatalf("expected not permitted, got %v", err)
+	}
+	if out.Started || getSession("policy-test") != nil {
+		t.Fatalf("denied command must not start a PTY: %+v", out)
+	}
+}
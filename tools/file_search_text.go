@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -17,11 +18,15 @@ type FileSearchText struct {
 }
 
 type FileSearchTextInput struct {
-	WorkspaceID   string `json:"workspaceId" jsonschema:"workspace identifier"`
-	RelPath       string `json:"relpath" jsonschema:"file path relative to workspace root"`
-	Query         string `json:"query" jsonschema:"exact text snippet to find"`
-	CaseSensitive bool   `json:"caseSensitive,omitempty" jsonschema:"if true, match is case-sensitive"`
-	Limit         int    `json:"limit,omitempty" jsonschema:"max matches to return (default 20)"`
+	WorkspaceID   string   `json:"workspaceId" jsonschema:"workspace identifier"`
+	RelPath       string   `json:"relpath" jsonschema:"file path relative to workspace root"`
+	Query         string   `json:"query,omitempty" jsonschema:"exact text snippet to find"`
+	Queries       []string `json:"queries,omitempty" jsonschema:"further snippets; a line matches if it contains any of query and queries"`
+	CaseSensitive bool     `json:"caseSensitive,omitempty" jsonschema:"if true, match is case-sensitive"`
+	Invert        bool     `json:"invert,omitempty" jsonschema:"return lines that contain none of the queries (blank lines are skipped)"`
+	HighlightPre  string   `json:"highlightPre,omitempty" jsonschema:"marker inserted before each matched term in snippets, e.g. «; highlighting is off unless highlightPre or highlightPost is set"`
+	HighlightPost string   `json:"highlightPost,omitempty" jsonschema:"marker inserted after each matched term in snippets, e.g. »"`
+	Limit         int      `json:"limit,omitempty" jsonschema:"max matches to return (default 20)"`
 }
 
 type FileSearchTextOutput struct {
@@ -29,6 +34,7 @@ type FileSearchTextOutput struct {
 }
 
 func (s *FileSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest, input FileSearchTextInput) (*mcp.CallToolResult, FileSearchTextOutput, error) {
+	metrics.SearchRequests.WithLabelValues("file_search_text").Inc()
 	matches := make([]TextMatch, 0, input.Limit)
 	if s == nil || s.DB == nil {
 		return nil, FileSearchTextOutput{Matches: matches}, fmt.Errorf("surreal client not configured")
@@ -41,10 +47,11 @@ func (s *FileSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest, inp
 	if rel == "" {
 		return nil, FileSearchTextOutput{Matches: matches}, fmt.Errorf("relpath is required")
 	}
-	query := input.Query
-	if strings.TrimSpace(query) == "" {
-		return nil, FileSearchTextOutput{Matches: matches}, fmt.Errorf("query is required")
+	matcher, err := newLineMatcher(input.Query, input.Queries, input.CaseSensitive, input.Invert)
+	if err != nil {
+		return nil, FileSearchTextOutput{Matches: matches}, err
 	}
+	matcher.pre, matcher.post = input.HighlightPre, input.HighlightPost
 
 	fsPath, err := s.resolveFilePath(ctx, wsID, rel)
 	if err != nil {
@@ -56,12 +63,6 @@ func (s *FileSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest, inp
 		limit = 20
 	}
 
-	caseSensitive := input.CaseSensitive
-	searchNeedle := query
-	if !caseSensitive {
-		searchNeedle = strings.ToLower(query)
-	}
-
 	file, err := os.Open(fsPath)
 	if err != nil {
 		return nil, FileSearchTextOutput{Matches: matches}, fmt.Errorf("open file: %w", err)
@@ -77,15 +78,12 @@ func (s *FileSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest, inp
 	for scanner.Scan() {
 		lineNo++
 		line := scanner.Text()
-		lineForSearch := line
-		if !caseSensitive {
-			lineForSearch = strings.ToLower(line)
-		}
-		if strings.Contains(lineForSearch, searchNeedle) {
+		if term, ok := matcher.match(line); ok {
 			matches = append(matches, TextMatch{
 				RelPath:    rel,
 				LineNumber: lineNo,
-				Snippet:    strings.TrimSpace(line),
+				Snippet:    matcher.snippet(line),
+				Matched:    term,
 			})
 			if len(matches) >= limit {
 				break
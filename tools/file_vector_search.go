@@ -2,28 +2,38 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/surrealdb/surrealdb.go"
 )
 
 type FileVectorSearch struct {
 	DB       *surreal.Client
 	Embedder *embedder.Client
+	// CandidateMultiplier sizes the KNN pool as topK times this; see Search.
+	CandidateMultiplier int
+	// Transform projects queries embedded with the configured model, as the
+	// indexer does with their stored vectors. Nil leaves them native.
+	Transform *embxform.Transform
 }
 
 type FileVectorSearchInput struct {
-	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
-	RelPath     string `json:"relpath" jsonschema:"file path relative to workspace root"`
-	Query       string `json:"query" jsonschema:"natural language query"`
-	TopK        int    `json:"topK,omitempty" jsonschema:"number of matches to return (default 5, max 20)"`
-	ModelID     string `json:"modelId,omitempty" jsonschema:"override vector model slug"`
+	WorkspaceID    string `json:"workspaceId" jsonschema:"workspace identifier"`
+	RelPath        string `json:"relpath" jsonschema:"file path relative to workspace root"`
+	Query          string `json:"query" jsonschema:"natural language query"`
+	TopK           int    `json:"topK,omitempty" jsonschema:"number of matches to return (default 5, max 20)"`
+	ModelID        string `json:"modelId,omitempty" jsonschema:"override vector model slug"`
+	ExpandToSymbol bool   `json:"expandToSymbol,omitempty" jsonschema:"also return the indexed symbol (function, type, ...) enclosing each match"`
+	HighlightPre   string `json:"highlightPre,omitempty" jsonschema:"marker inserted before query words found in each snippet, e.g. «; highlighting is off unless highlightPre or highlightPost is set"`
+	HighlightPost  string `json:"highlightPost,omitempty" jsonschema:"marker inserted after query words found in each snippet, e.g. »"`
+	ContextChunks  int    `json:"contextChunks,omitempty" jsonschema:"also return the text of each match widened by this many neighbouring chunks on either side (max 5)"`
 }
 
 type FileVectorSearchOutput struct {
@@ -31,15 +41,29 @@ type FileVectorSearchOutput struct {
 }
 
 type VectorMatch struct {
-	Score      float64 `json:"score" jsonschema:"cosine similarity score"`
-	ContentSHA string  `json:"contentSha" jsonschema:"hash of the matched chunk"`
-	Start      int     `json:"start" jsonschema:"chunk start byte offset"`
-	End        int     `json:"end" jsonschema:"chunk end byte offset"`
-	TokenCount int     `json:"tokenCount" jsonschema:"token count for the chunk"`
-	Snippet    string  `json:"snippet" jsonschema:"text snippet of the chunk"`
+	Score            float64     `json:"score" jsonschema:"cosine similarity score"`
+	ContentSHA       string      `json:"contentSha" jsonschema:"hash of the matched chunk"`
+	Start            int         `json:"start" jsonschema:"chunk start byte offset"`
+	End              int         `json:"end" jsonschema:"chunk end byte offset"`
+	TokenCount       int         `json:"tokenCount" jsonschema:"token count for the chunk"`
+	Snippet          string      `json:"snippet" jsonschema:"text snippet of the chunk"`
+	Symbol           *SymbolSpan `json:"symbol,omitempty" jsonschema:"enclosing symbol when expandToSymbol is set; absent when no symbol contains the chunk"`
+	ChunkIndex       int         `json:"chunkIndex" jsonschema:"position of the chunk within the file"`
+	ContextStart     int         `json:"contextStart,omitempty" jsonschema:"start byte offset of the widened span"`
+	ContextEnd       int         `json:"contextEnd,omitempty" jsonschema:"end byte offset of the widened span"`
+	ContextText      string      `json:"contextText,omitempty" jsonschema:"text of the chunk and its neighbours"`
+	ContextTruncated bool        `json:"contextTruncated,omitempty" jsonschema:"contextText was cut short to keep the response within its size budget"`
 }
 
+// maxContextChunks bounds contextChunks, and maxContextBytes the combined
+// contextText of all matches in one response.
+const (
+	maxContextChunks = 5
+	maxContextBytes  = 64 << 10
+)
+
 func (s *FileVectorSearch) Search(ctx context.Context, _ *mcp.CallToolRequest, input FileVectorSearchInput) (*mcp.CallToolResult, FileVectorSearchOutput, error) {
+	metrics.SearchRequests.WithLabelValues("file_vector_search").Inc()
 	if s == nil || s.DB == nil || s.Embedder == nil {
 		return nil, FileVectorSearchOutput{}, fmt.Errorf("vector search requires surreal client and embedder")
 	}
@@ -64,10 +88,6 @@ func (s *FileVectorSearch) Search(ctx context.Context, _ *mcp.CallToolRequest, i
 		topK = 20
 	}
 
-	limit := topK
-
-	topK *= 1000
-
 	wsPath, err := lookupWorkspacePath(ctx, s.DB, wsID)
 	if err != nil {
 		return nil, FileVectorSearchOutput{}, err
@@ -77,8 +97,6 @@ func (s *FileVectorSearch) Search(ctx context.Context, _ *mcp.CallToolRequest, i
 		return nil, FileVectorSearchOutput{}, err
 	}
 
-	println("File record ID: ", fileRecordID)
-
 	modelID, err := s.resolveModel(ctx, fileRecordID, input.ModelID)
 	if err != nil {
 		return nil, FileVectorSearchOutput{}, err
@@ -98,11 +116,17 @@ func (s *FileVectorSearch) Search(ctx context.Context, _ *mcp.CallToolRequest, i
 		return nil, FileVectorSearchOutput{}, err
 	}
 
-	// KNN directly in SurrealDB; returns cosine distance via vector::distance::knn()
+	// The <|k,COSINE|> operator picks the k nearest chunks across the whole
+	// table; the file and model filters only run on those k afterwards. k must
+	// therefore exceed topK by enough for this file's chunks to be among the
+	// candidates. Large workspaces may need a bigger knn_candidate_multiplier
+	// to return a full topK.
+	knn := topK * candidateMultiplier(s.CandidateMultiplier)
 	q := fmt.Sprintf(`
 SELECT * FROM (
 SELECT
   content_sha,
+  chunk_index,
   start,
   end,
   token_count,
@@ -114,13 +138,13 @@ WHERE
   vector <|%d,COSINE|> $qvec
 )
 WHERE file = type::thing('file', $file_id) AND model = type::thing('vector_model', $model_id)
-
 ORDER BY distance ASC
 LIMIT %d;
-`, topK, limit)
+`, knn, topK)
 
 	type row struct {
 		ContentSHA string  `json:"content_sha"`
+		ChunkIndex int     `json:"chunk_index"`
 		Start      int     `json:"start"`
 		End        int     `json:"end"`
 		TokenCount int     `json:"token_count"`
@@ -133,23 +157,40 @@ LIMIT %d;
 		"qvec":     qvec,
 	}
 
-	queryResults, err := surrealdb.Query[[]row](ctx, s.DB.Db, q, params)
+	rows, err := surreal.Query[row](ctx, s.DB, q, params)
 	if err != nil {
 		return nil, FileVectorSearchOutput{}, fmt.Errorf("knn query: %w", err)
 	}
-	if len(*queryResults) == 0 {
+	if len(rows) == 0 {
 		return nil, FileVectorSearchOutput{Matches: make([]VectorMatch, 0)}, nil
 	}
 
-	// println(fmt.Sprintf("FILE RESULTS: %v", (*queryResults)[0].Result))
-
-	fileBytes, err := os.ReadFile(filepath.Join(wsPath, filepath.FromSlash(rel)))
+	fileBytes, err := readIndexedText(filepath.Join(wsPath, filepath.FromSlash(rel)))
 	if err != nil {
 		return nil, FileVectorSearchOutput{}, fmt.Errorf("read file for snippet: %w", err)
 	}
 
-	matches := make([]VectorMatch, len((*queryResults)[0].Result))
-	for i, r := range (*queryResults)[0].Result {
+	var symbols []symbolRange
+	if input.ExpandToSymbol {
+		if symbols, err = lookupFileSymbols(ctx, s.DB, wsID, rel); err != nil {
+			return nil, FileVectorSearchOutput{}, err
+		}
+	}
+
+	var spans map[int]chunkSpan
+	contextChunks := min(input.ContextChunks, maxContextChunks)
+	if contextChunks > 0 {
+		if spans, err = s.chunkSpans(ctx, fileRecordID, modelID); err != nil {
+			return nil, FileVectorSearchOutput{}, err
+		}
+	}
+
+	// A chunk matches by meaning, so highlighting can only point at the
+	// query's words where they happen to appear.
+	terms := queryTerms(query)
+	budget := maxContextBytes
+	matches := make([]VectorMatch, len(rows))
+	for i, r := range rows {
 		// Surreal returns cosine distance; convert to similarity in [0..1]
 		sim := 1.0 - r.Distance
 		matches[i] = VectorMatch{
@@ -158,7 +199,15 @@ LIMIT %d;
 			Start:      r.Start,
 			End:        r.End,
 			TokenCount: r.TokenCount,
-			Snippet:    sliceSnippet(fileBytes, r.Start, r.End),
+			Snippet:    highlightTerms(sliceSnippet(fileBytes, r.Start, r.End), terms, true, input.HighlightPre, input.HighlightPost),
+			Symbol:     expandToSymbol(fileBytes, symbols, r.Start, r.End),
+			ChunkIndex: r.ChunkIndex,
+		}
+		if contextChunks > 0 {
+			m := &matches[i]
+			m.ContextStart, m.ContextEnd = widenSpan(spans, r.ChunkIndex, contextChunks, r.Start, r.End)
+			m.ContextText, m.ContextTruncated = sliceContext(fileBytes, m.ContextStart, m.ContextEnd, budget)
+			budget -= len(m.ContextText)
 		}
 	}
 
@@ -189,27 +238,71 @@ LIMIT 1
 	return rows[0].ModelID, nil
 }
 
-// model-aware embedding with graceful fallback
-type modelAwareEmbedder interface {
-	EmbedWithModel(ctx context.Context, model string, inputs []string) ([][]float32, error)
+// chunkSpan is the byte range of one stored chunk.
+type chunkSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
-func (s *FileVectorSearch) embedQuery(ctx context.Context, modelID, query string) ([]float32, error) {
-	if me, ok := any(s.Embedder).(modelAwareEmbedder); ok && modelID != "" {
-		vecs, err := me.EmbedWithModel(ctx, modelID, []string{query})
-		if err == nil && len(vecs) > 0 && len(vecs[0]) > 0 {
-			return vecs[0], nil
-		}
-		// fall through to generic path on error/empty
+// chunkSpans returns the spans of the file's chunks for a model, keyed by
+// chunk_index.
+func (s *FileVectorSearch) chunkSpans(ctx context.Context, fileRecordID, modelID string) (map[int]chunkSpan, error) {
+	type row struct {
+		ChunkIndex int `json:"chunk_index"`
+		chunkSpan
 	}
-	vecs, err := s.Embedder.Embed(ctx, []string{query})
+	const q = `
+SELECT chunk_index, start, end
+FROM vector_chunk
+WHERE file = type::thing('file', $file_id) AND model = type::thing('vector_model', $model_id)
+`
+	rows, err := surreal.Query[row](ctx, s.DB, q, map[string]any{"file_id": fileRecordID, "model_id": modelID})
 	if err != nil {
-		return nil, fmt.Errorf("embed query: %w", err)
+		return nil, fmt.Errorf("load neighbouring chunks: %w", err)
 	}
-	if len(vecs) == 0 || len(vecs[0]) == 0 {
-		return nil, fmt.Errorf("embedding returned empty vector")
+	spans := make(map[int]chunkSpan, len(rows))
+	for _, r := range rows {
+		spans[r.ChunkIndex] = r.chunkSpan
+	}
+	return spans, nil
+}
+
+// widenSpan extends start..end over the chunks up to n positions before and
+// after index. Missing neighbours (file edges, dropped short chunks) are
+// skipped.
+func widenSpan(spans map[int]chunkSpan, index, n, start, end int) (int, int) {
+	for i := index - n; i <= index+n; i++ {
+		if sp, ok := spans[i]; ok && i != index {
+			start = min(start, sp.Start)
+			end = max(end, sp.End)
+		}
 	}
-	return vecs[0], nil
+	return start, end
+}
+
+// sliceContext returns data[start:end] cut to at most budget bytes on a rune
+// boundary, and whether it was cut.
+func sliceContext(data []byte, start, end, budget int) (string, bool) {
+	start = max(0, min(start, len(data)))
+	end = max(start, min(end, len(data)))
+	start, end = alignRunes(data, start, end)
+	if budget <= 0 {
+		return "", end > start
+	}
+	if end-start <= budget {
+		return string(data[start:end]), false
+	}
+	_, cut := alignRunes(data, start, start+budget)
+	return string(data[start:cut]), true
+}
+
+// embedQuery embeds query with the model whose slug is modelID.
+func (s *FileVectorSearch) embedQuery(ctx context.Context, modelID, query string) ([]float32, error) {
+	model, err := vectorModelName(ctx, s.DB, s.Embedder, modelID)
+	if err != nil {
+		return nil, err
+	}
+	return embedQueryVector(ctx, s.Embedder, s.Transform, model, query)
 }
 
 func lookupWorkspacePath(ctx context.Context, db *surreal.Client, wsID string) (string, error) {
@@ -229,6 +322,10 @@ SELECT path FROM workspace WHERE id = type::thing('workspace', $ws_id) LIMIT 1
 	return strings.TrimSpace(rows[0].Path), nil
 }
 
+// errFileNotIndexed is returned by lookupFileRecordID when the workspace has no
+// record for the file, e.g. because it has not been scanned yet.
+var errFileNotIndexed = errors.New("file not indexed")
+
 func lookupFileRecordID(ctx context.Context, db *surreal.Client, wsID, rel string) (string, error) {
 	type row struct {
 		FileID string `json:"file_id"`
@@ -244,7 +341,7 @@ LIMIT 1
 		return "", fmt.Errorf("lookup file id: %w", err)
 	}
 	if len(rows) == 0 || strings.TrimSpace(rows[0].FileID) == "" {
-		return "", fmt.Errorf("file %s not found in workspace %s", rel, wsID)
+		return "", fmt.Errorf("file %s not found in workspace %s: %w", rel, wsID, errFileNotIndexed)
 	}
 	return rows[0].FileID, nil
 }
@@ -259,12 +356,13 @@ func sliceSnippet(data []byte, start, end int) string {
 	if start >= len(data) || start >= end {
 		return ""
 	}
-	window := data[start:end]
-	text := string(window)
+	start, end = alignRunes(data, start, end)
+	text := string(data[start:end])
 	text = strings.ReplaceAll(text, "\n", " ")
 	text = strings.TrimSpace(text)
 	if len(text) > 512 {
-		text = text[:512] + "…"
+		_, cut := alignRunes([]byte(text), 0, 512)
+		text = text[:cut] + "…"
 	}
 	return text
 }
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWidenSpan(t *testing.T) {
+	spans := map[int]chunkSpan{0: {0, 10}, 1: {10, 20}, 2: {20, 30}, 4: {40, 50}}
+	cases := []struct{ index, n, start, end int }{
+		{1, 1, 0, 30},
+		{0, 1, 0, 20},
+		{2, 2, 0, 50}, // index 3 is missing but 4 is in reach
+		{4, 1, 40, 50},
+	}
+	for _, tc := range cases {
+		sp := spans[tc.index]
+		if s, e := widenSpan(spans, tc.index, tc.n, sp.Start, sp.End); s != tc.start || e != tc.end {
+			t.Errorf("widenSpan(%d, %d) = %d, %d; want %d, %d", tc.index, tc.n, s, e, tc.start, tc.end)
+		}
+	}
+}
+
+func TestSliceContextBudget(t *testing.T) {
+	data := []byte("aé bc")
+	if got, cut := sliceContext(data, 0, len(data), 10); got != "aé bc" || cut {
+		t.Fatalf("got %q cut=%v", got, cut)
+	}
+	// A budget ending inside "é" stops before it.
+	if got, cut := sliceContext(data, 0, len(data), 2); got != "a" || !cut {
+		t.Fatalf("got %q cut=%v", got, cut)
+	}
+	if got, cut := sliceContext(data, 0, len(data), 0); got != "" || !cut {
+		t.Fatalf("got %q cut=%v", got, cut)
+	}
+}
+
+func TestSliceSnippetTruncatesToValidUTF8(t *testing.T) {
+	// 511 ASCII bytes put the 512-byte cut inside the first "é".
+	data := []byte(strings.Repeat("a", 511) + strings.Repeat("é", 10))
+	got := sliceSnippet(data, 0, len(data))
+	if !utf8.ValidString(got) {
+		t.Fatalf("snippet is not valid UTF-8: %q", got[len(got)-8:])
+	}
+	if want := strings.Repeat("a", 511) + "…"; got != want {
+		t.Fatalf("unexpected snippet tail %q", got[500:])
+	}
+}
@@ -3,7 +3,10 @@ package tools
 import (
 	"context"
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -15,8 +18,15 @@ type FindFile struct {
 
 type FindFileInput struct {
 	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
-	Query       string `json:"query" jsonschema:"exact match or substring to look for"`
-	MatchType   string `json:"matchType,omitempty" jsonschema:"exact | substring | prefix | suffix"`
+	Query       string `json:"query,omitempty" jsonschema:"exact match, substring, regex or glob to look for; optional when lang or a size bound is given"`
+	MatchType   string `json:"matchType,omitempty" jsonschema:"exact | substring | prefix | suffix | regex | glob (** matches any number of directories)"`
+	IgnoreCase  bool   `json:"ignoreCase,omitempty" jsonschema:"for matchType=regex, match case-insensitively"`
+	Lang        string `json:"lang,omitempty" jsonschema:"only files with this language hint (e.g. go, python)"`
+	MinSize     int64  `json:"minSize,omitempty" jsonschema:"only files of at least this many bytes"`
+	MaxSize     int64  `json:"maxSize,omitempty" jsonschema:"only files of at most this many bytes"`
+	SortBy      string `json:"sortBy,omitempty" jsonschema:"path (default), size or mtime"`
+	Desc        bool   `json:"desc,omitempty" jsonschema:"sort descending, e.g. largest or most recently modified first"`
+	IncludeDirs bool   `json:"includeDirs,omitempty" jsonschema:"also match directories; ignored when lang or a size bound is set"`
 	Limit       int    `json:"limit,omitempty" jsonschema:"maximum number of results to return"`
 }
 
@@ -25,10 +35,12 @@ type FindFileOutput struct {
 }
 
 type FindFileResult struct {
-	RelPath string `json:"relpath" jsonschema:"path relative to workspace root"`
-	Lang    string `json:"lang,omitempty" jsonschema:"language hint"`
-	Size    int64  `json:"size" jsonschema:"file size in bytes"`
-	SHA     string `json:"sha" jsonschema:"content hash"`
+	RelPath string    `json:"relpath" jsonschema:"path relative to workspace root"`
+	Type    string    `json:"type" jsonschema:"file or dir"`
+	Lang    string    `json:"lang,omitempty" jsonschema:"language hint"`
+	Size    int64     `json:"size" jsonschema:"file size in bytes; 0 for directories"`
+	MTime   time.Time `json:"mtime,omitzero" jsonschema:"modification time (UTC)"`
+	SHA     string    `json:"sha" jsonschema:"content hash"`
 }
 
 func (f *FindFile) Search(ctx context.Context, _ *mcp.CallToolRequest, input FindFileInput) (*mcp.CallToolResult, FindFileOutput, error) {
@@ -41,8 +53,16 @@ func (f *FindFile) Search(ctx context.Context, _ *mcp.CallToolRequest, input Fin
 		return nil, FindFileOutput{Results: results}, fmt.Errorf("workspaceId is required")
 	}
 	q := strings.TrimSpace(input.Query)
-	if q == "" {
-		return nil, FindFileOutput{Results: results}, fmt.Errorf("query is required")
+	lang := strings.ToLower(strings.TrimSpace(input.Lang))
+	if q == "" && lang == "" && input.MinSize <= 0 && input.MaxSize <= 0 {
+		return nil, FindFileOutput{Results: results}, fmt.Errorf("query is required unless lang, minSize or maxSize is set")
+	}
+	if input.MinSize > 0 && input.MaxSize > 0 && input.MinSize > input.MaxSize {
+		return nil, FindFileOutput{Results: results}, fmt.Errorf("minSize %d exceeds maxSize %d", input.MinSize, input.MaxSize)
+	}
+	orderBy, err := fileOrderBy(input.SortBy, input.Desc)
+	if err != nil {
+		return nil, FindFileOutput{Results: results}, err
 	}
 
 	matchType := strings.ToLower(strings.TrimSpace(input.MatchType))
@@ -50,46 +70,82 @@ func (f *FindFile) Search(ctx context.Context, _ *mcp.CallToolRequest, input Fin
 		matchType = "substring"
 	}
 
+	limit := clampLimit(input.Limit, 100)
 	var (
 		filter string
 		vars   = map[string]any{
 			"ws_id": wsID,
-			"limit": clampLimit(input.Limit, 100),
+			"limit": limit,
 		}
+		// match filters rows client-side for patterns SurrealDB cannot evaluate.
+		match func(relpath string) bool
 	)
 
-	switch matchType {
-	case "exact":
+	switch {
+	case q == "":
+		filter = "true"
+	case matchType == "exact":
 		filter = "relpath = $query"
 		vars["query"] = q
-	case "prefix":
+	case matchType == "prefix":
 		filter = "string::begins_with(relpath, $query)"
 		vars["query"] = q
-	case "suffix":
+	case matchType == "suffix":
 		filter = "string::ends_with(relpath, $query)"
 		vars["query"] = q
-	case "substring":
+	case matchType == "substring":
 		filter = "string::contains(relpath, $query)"
 		vars["query"] = q
+	case matchType == "regex":
+		expr := q
+		if input.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, FindFileOutput{Results: results}, fmt.Errorf("invalid regex %q: %w", q, err)
+		}
+		filter = "true"
+		match = re.MatchString
+	case matchType == "glob":
+		if err := validateGlob(q); err != nil {
+			return nil, FindFileOutput{Results: results}, fmt.Errorf("invalid glob %q: %w", q, err)
+		}
+		filter = "true"
+		if prefix := globLiteralPrefix(q); prefix != "" {
+			filter = "string::begins_with(relpath, $query)"
+			vars["query"] = prefix
+		}
+		match = func(relpath string) bool { return matchGlob(q, relpath) }
 	default:
 		return nil, FindFileOutput{Results: results}, fmt.Errorf("unsupported matchType %q", matchType)
 	}
 
+	dirFilter := filter
+	filter, dirsMatch := attributeFilter(filter, lang, input.MinSize, input.MaxSize, vars)
+	includeDirs := input.IncludeDirs && dirsMatch
+
 	const tmpl = `
-SELECT relpath, lang, size, sha
+SELECT relpath, lang, size, mtime, sha
 FROM file
 WHERE ws = type::thing('workspace', $ws_id) AND %s
-ORDER BY relpath ASC
-LIMIT $limit
+ORDER BY %s
+%s
 `
 
-	sql := fmt.Sprintf(tmpl, filter)
+	// Client-side matches are limited after filtering.
+	limitClause := "LIMIT $limit"
+	if match != nil {
+		limitClause = ""
+	}
+	sql := fmt.Sprintf(tmpl, filter, orderBy, limitClause)
 
 	type row struct {
-		RelPath string `json:"relpath"`
-		Lang    string `json:"lang"`
-		Size    int64  `json:"size"`
-		SHA     string `json:"sha"`
+		RelPath string    `json:"relpath"`
+		Lang    string    `json:"lang"`
+		Size    int64     `json:"size"`
+		MTime   time.Time `json:"mtime"`
+		SHA     string    `json:"sha"`
 	}
 
 	rows, err := surreal.Query[row](ctx, f.DB, sql, vars)
@@ -98,8 +154,155 @@ LIMIT $limit
 	}
 
 	for _, r := range rows {
-		results = append(results, FindFileResult(r))
+		if match != nil && !match(r.RelPath) {
+			continue
+		}
+		results = append(results, FindFileResult{
+			RelPath: r.RelPath,
+			Type:    "file",
+			Lang:    r.Lang,
+			Size:    r.Size,
+			MTime:   r.MTime,
+			SHA:     r.SHA,
+		})
+		if len(results) == limit {
+			break
+		}
+	}
+
+	if includeDirs {
+		dirs, err := f.findDirs(ctx, dirFilter, input.Desc, limitClause, vars, match, limit)
+		if err != nil {
+			return nil, FindFileOutput{Results: results}, err
+		}
+		results = mergeFindResults(results, dirs, orderBy, input.Desc, limit)
 	}
 
 	return nil, FindFileOutput{Results: results}, nil
 }
+
+// attributeFilter appends the lang and size bounds to filter, binding their
+// values in vars; zero values add nothing. Directories have no language or
+// size, so it also reports whether they can still match.
+func attributeFilter(filter, lang string, minSize, maxSize int64, vars map[string]any) (string, bool) {
+	dirs := true
+	if lang != "" {
+		filter += " AND lang = $lang"
+		vars["lang"] = lang
+		dirs = false
+	}
+	if minSize > 0 {
+		filter += " AND size >= $min_size"
+		vars["min_size"] = minSize
+		dirs = false
+	}
+	if maxSize > 0 {
+		filter += " AND size <= $max_size"
+		vars["max_size"] = maxSize
+		dirs = false
+	}
+	return filter, dirs
+}
+
+// findDirs returns up to limit directories matching filter (and match, when
+// set), ordered by relpath. The workspace root is never returned.
+func (f *FindFile) findDirs(ctx context.Context, filter string, desc bool, limitClause string, vars map[string]any, match func(string) bool, limit int) ([]FindFileResult, error) {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	sql := fmt.Sprintf(`
+SELECT relpath, sha
+FROM directory
+WHERE ws = type::thing('workspace', $ws_id) AND relpath != "" AND %s
+ORDER BY relpath %s
+%s
+`, filter, dir, limitClause)
+
+	type row struct {
+		RelPath string `json:"relpath"`
+		SHA     string `json:"sha"`
+	}
+	rows, err := surreal.Query[row](ctx, f.DB, sql, vars)
+	if err != nil {
+		return nil, fmt.Errorf("find directories: %w", err)
+	}
+	dirs := make([]FindFileResult, 0, len(rows))
+	for _, r := range rows {
+		if match != nil && !match(r.RelPath) {
+			continue
+		}
+		dirs = append(dirs, FindFileResult{RelPath: r.RelPath, Type: "dir", SHA: r.SHA})
+		if len(dirs) == limit {
+			break
+		}
+	}
+	return dirs, nil
+}
+
+// mergeFindResults combines file and directory matches and keeps the first
+// limit. When ordering by path the two lists are interleaved by relpath;
+// otherwise directories, which have no size or mtime, follow the files.
+func mergeFindResults(files, dirs []FindFileResult, orderBy string, desc bool, limit int) []FindFileResult {
+	if !strings.HasPrefix(orderBy, "relpath ") {
+		return append(files, dirs...)[:min(len(files)+len(dirs), limit)]
+	}
+	merged := make([]FindFileResult, 0, min(len(files)+len(dirs), limit))
+	for len(merged) < limit && (len(files) > 0 || len(dirs) > 0) {
+		takeFile := len(dirs) == 0
+		if len(files) > 0 && len(dirs) > 0 {
+			takeFile = (files[0].RelPath < dirs[0].RelPath) != desc
+		}
+		if takeFile {
+			merged, files = append(merged, files[0]), files[1:]
+		} else {
+			merged, dirs = append(merged, dirs[0]), dirs[1:]
+		}
+	}
+	return merged
+}
+
+// validateGlob reports a malformed segment of a slash-separated glob.
+func validateGlob(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// globLiteralPrefix returns the part of pattern before its first wildcard.
+func globLiteralPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// matchGlob reports whether relpath matches pattern. A "**" segment matches
+// zero or more path segments; other segments follow path.Match.
+func matchGlob(pattern, relpath string) bool {
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(relpath, "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
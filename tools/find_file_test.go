@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, relpath string
+		want             bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "tools/main.go", false},
+		{"tools/*.go", "tools/find_file.go", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/indexer/scan.go", true},
+		{"internal/**", "internal/indexer/scan.go", true},
+		{"internal/**/scan.go", "internal/scan.go", true},
+		{"internal/**/scan.go", "internal/indexer/embed.go", false},
+		{"cmd/?ain.go", "cmd/main.go", true},
+	}
+	for _, tc := range cases {
+		if got := matchGlob(tc.pattern, tc.relpath); got != tc.want {
+			t.Fatalf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.relpath, got, tc.want)
+		}
+	}
+}
+
+func TestGlobLiteralPrefixAndValidation(t *testing.T) {
+	if got := globLiteralPrefix("internal/indexer/*.go"); got != "internal/indexer/" {
+		t.Fatalf("unexpected prefix %q", got)
+	}
+	if got := globLiteralPrefix("**/*.go"); got != "" {
+		t.Fatalf("unexpected prefix %q", got)
+	}
+	if err := validateGlob("src/[a-"); err == nil {
+		t.Fatalf("expected error for malformed glob")
+	}
+	if err := validateGlob("src/**/*.go"); err != nil {
+		t.Fatalf("valid glob rejected: %v", err)
+	}
+}
+
+func TestMergeFindResults(t *testing.T) {
+	files := []FindFileResult{{RelPath: "a.go", Type: "file"}, {RelPath: "cmd/main.go", Type: "file"}}
+	dirs := []FindFileResult{{RelPath: "cmd", Type: "dir"}, {RelPath: "internal", Type: "dir"}}
+
+	got := mergeFindResults(files, dirs, "relpath ASC", false, 3)
+	want := []string{"a.go", "cmd", "cmd/main.go"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i, r := range got {
+		if r.RelPath != want[i] {
+			t.Fatalf("result %d = %q, want %q", i, r.RelPath, want[i])
+		}
+	}
+
+	got = mergeFindResults(files, dirs, "size DESC, relpath ASC", true, 10)
+	if len(got) != 4 || got[2].Type != "dir" || got[3].Type != "dir" {
+		t.Fatalf("expected directories after files, got %+v", got)
+	}
+}
+
+func TestAttributeFilter(t *testing.T) {
+	const match = "string::contains(relpath, $query)"
+	cases := []struct {
+		name             string
+		lang             string
+		minSize, maxSize int64
+		want             string
+		wantVars         map[string]any
+		wantDirs         bool
+	}{
+		{name: "none", want: match, wantVars: map[string]any{}, wantDirs: true},
+		{name: "lang", lang: "go", want: match + " AND lang = $lang", wantVars: map[string]any{"lang": "go"}},
+		{name: "minSize", minSize: 10 << 10, want: match + " AND size >= $min_size", wantVars: map[string]any{"min_size": int64(10 << 10)}},
+		{name: "maxSize", maxSize: 512, want: match + " AND size <= $max_size", wantVars: map[string]any{"max_size": int64(512)}},
+		{
+			name: "all", lang: "python", minSize: 1, maxSize: 2,
+			want:     match + " AND lang = $lang AND size >= $min_size AND size <= $max_size",
+			wantVars: map[string]any{"lang": "python", "min_size": int64(1), "max_size": int64(2)},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vars := map[string]any{}
+			got, dirs := attributeFilter(match, tc.lang, tc.minSize, tc.maxSize, vars)
+			if got != tc.want {
+				t.Fatalf("filter = %q, want %q", got, tc.want)
+			}
+			if dirs != tc.wantDirs {
+				t.Fatalf("dirs = %v, want %v", dirs, tc.wantDirs)
+			}
+			if len(vars) != len(tc.wantVars) {
+				t.Fatalf("vars = %v, want %v", vars, tc.wantVars)
+			}
+			for k, v := range tc.wantVars {
+				if vars[k] != v {
+					t.Fatalf("vars[%s] = %v, want %v", k, vars[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestFindFileValidatesFilters(t *testing.T) {
+	f := &FindFile{DB: &surreal.Client{}}
+	cases := []struct {
+		name  string
+		input FindFileInput
+		want  string
+	}{
+		{"no filter", FindFileInput{WorkspaceID: "ws"}, "query is required unless lang, minSize or maxSize is set"},
+		{"inverted size range", FindFileInput{WorkspaceID: "ws", MinSize: 10, MaxSize: 5}, "minSize 10 exceeds maxSize 5"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := f.Search(context.Background(), nil, tc.input)
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("Search error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
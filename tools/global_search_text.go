@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// globalSearchMaxLimit is kept below the per-workspace maximum because results
+// span many workspaces.
+const globalSearchMaxLimit = 50
+
+type GlobalSearchText struct {
+	DB *surreal.Client
+}
+
+type GlobalSearchTextInput struct {
+	Query         string   `json:"query,omitempty" jsonschema:"exact text snippet to find"`
+	Queries       []string `json:"queries,omitempty" jsonschema:"further snippets; a line matches if it contains any of query and queries"`
+	WorkspaceIDs  []string `json:"workspaceIds,omitempty" jsonschema:"workspaces to search; empty searches every registered workspace"`
+	CaseSensitive bool     `json:"caseSensitive,omitempty" jsonschema:"if true, match is case-sensitive"`
+	Invert        bool     `json:"invert,omitempty" jsonschema:"return lines that contain none of the queries (blank lines are skipped)"`
+	HighlightPre  string   `json:"highlightPre,omitempty" jsonschema:"marker inserted before each matched term in snippets, e.g. «; highlighting is off unless highlightPre or highlightPost is set"`
+	HighlightPost string   `json:"highlightPost,omitempty" jsonschema:"marker inserted after each matched term in snippets, e.g. »"`
+	Limit         int      `json:"limit,omitempty" jsonschema:"max number of matches across all workspaces (default 20, max 50)"`
+	MaxFileBytes  int64    `json:"maxFileBytes,omitempty" jsonschema:"skip files larger than this many bytes (default 1048576)"`
+}
+
+type GlobalSearchTextOutput struct {
+	Matches []TextMatch `json:"matches" jsonschema:"list of file matches with their workspace"`
+}
+
+func (g *GlobalSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest, input GlobalSearchTextInput) (*mcp.CallToolResult, GlobalSearchTextOutput, error) {
+	metrics.SearchRequests.WithLabelValues("global_search_text").Inc()
+	matches := make([]TextMatch, 0)
+	if g == nil || g.DB == nil {
+		return nil, GlobalSearchTextOutput{Matches: matches}, fmt.Errorf("surreal client not configured")
+	}
+	matcher, err := newLineMatcher(input.Query, input.Queries, input.CaseSensitive, input.Invert)
+	if err != nil {
+		return nil, GlobalSearchTextOutput{Matches: matches}, err
+	}
+	matcher.pre, matcher.post = input.HighlightPre, input.HighlightPost
+	maxBytes := input.MaxFileBytes
+	if maxBytes <= 0 {
+		maxBytes = 1 << 20 // 1 MiB
+	}
+	limit := clampLimit(input.Limit, globalSearchMaxLimit)
+	if input.Limit <= 0 {
+		limit = 20
+	}
+	workspaces, err := g.listWorkspaces(ctx, input.WorkspaceIDs)
+	if err != nil {
+		return nil, GlobalSearchTextOutput{Matches: matches}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	remaining := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return limit - len(matches)
+	}
+
+	jobs := make(chan globalWorkspace)
+	workers := runtime.NumCPU()
+	if workers > len(workspaces) {
+		workers = len(workspaces)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ws := range jobs {
+				files, err := listWorkspaceFiles(ctx, g.DB, ws.ID, nil, nil)
+				if err != nil {
+					slog.Warn("global_search_text: list files failed", "workspace", ws.ID, "err", err)
+					continue
+				}
+				for _, file := range files {
+					left := remaining()
+					if left <= 0 || ctx.Err() != nil {
+						break
+					}
+					found, err := searchFileLines(filepath.Join(ws.Path, filepath.FromSlash(file.RelPath)), file.RelPath, matcher, maxBytes, left)
+					if err != nil {
+						slog.Warn("global_search_text: file not fully searched", "workspace", ws.ID, "err", err)
+					}
+					if len(found) == 0 {
+						continue
+					}
+					mu.Lock()
+					for _, m := range found {
+						if len(matches) >= limit {
+							break
+						}
+						m.WorkspaceID = ws.ID
+						matches = append(matches, m)
+					}
+					if len(matches) >= limit {
+						cancel()
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for _, ws := range workspaces {
+		select {
+		case jobs <- ws:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].WorkspaceID != matches[j].WorkspaceID {
+			return matches[i].WorkspaceID < matches[j].WorkspaceID
+		}
+		if matches[i].RelPath != matches[j].RelPath {
+			return matches[i].RelPath < matches[j].RelPath
+		}
+		return matches[i].LineNumber < matches[j].LineNumber
+	})
+	return nil, GlobalSearchTextOutput{Matches: matches}, nil
+}
+
+type globalWorkspace struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// listWorkspaces resolves the ids and paths of the requested workspaces, or of
+// every registered workspace when ids is empty, in a single query.
+func (g *GlobalSearchText) listWorkspaces(ctx context.Context, ids []string) ([]globalWorkspace, error) {
+	wanted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if trimmed := strings.TrimSpace(id); trimmed != "" {
+			wanted = append(wanted, trimmed)
+		}
+	}
+	const q = `
+SELECT meta::id(id) AS id, path FROM workspace
+WHERE array::len($ids) = 0 OR meta::id(id) IN $ids
+ORDER BY id ASC
+`
+	rows, err := surreal.Query[globalWorkspace](ctx, g.DB, q, map[string]any{"ids": wanted})
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	out := make([]globalWorkspace, 0, len(rows))
+	for _, r := range rows {
+		if strings.TrimSpace(r.Path) != "" {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// highlightTerms wraps each occurrence of any of terms in text with pre and
+// post. Where several terms match at the same position the longest wins, and
+// matches do not overlap. text is returned unchanged when both markers are
+// empty.
+func highlightTerms(text string, terms []string, foldCase bool, pre, post string) string {
+	if pre == "" && post == "" || len(terms) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(text); {
+		n := 0
+		for _, term := range terms {
+			n = max(n, termPrefixLen(text[i:], term, foldCase))
+		}
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		b.WriteString(text[last:i])
+		b.WriteString(pre)
+		b.WriteString(text[i : i+n])
+		b.WriteString(post)
+		i += n
+		last = i
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// termPrefixLen returns the byte length of the prefix of s that matches term,
+// or 0 when s does not start with term.
+func termPrefixLen(s, term string, foldCase bool) int {
+	if term == "" {
+		return 0
+	}
+	if !foldCase {
+		if strings.HasPrefix(s, term) {
+			return len(term)
+		}
+		return 0
+	}
+	i := 0
+	for _, want := range term {
+		if i >= len(s) {
+			return 0
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != want && !strings.EqualFold(string(r), string(want)) {
+			return 0
+		}
+		i += size
+	}
+	return i
+}
+
+// queryTerms splits a natural language query into the words worth
+// highlighting in a vector match: runs of letters and digits of at least three
+// characters, lower-cased and without repeats.
+func queryTerms(query string) []string {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(w) >= 3 && !slices.Contains(terms, w) {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHighlightTerms(t *testing.T) {
+	cases := []struct {
+		text     string
+		terms    []string
+		foldCase bool
+		want     string
+	}{
+		{"call Foo() then foo()", []string{"foo"}, true, "call «Foo»() then «foo»()"},
+		{"call Foo() then foo()", []string{"foo"}, false, "call Foo() then «foo»()"},
+		{"foobar", []string{"foo", "foobar"}, true, "«foobar»"},
+		{"ÉCOLE école", []string{"école"}, true, "«ÉCOLE» «école»"},
+		{"nothing here", []string{"zzz"}, true, "nothing here"},
+	}
+	for _, tc := range cases {
+		if got := highlightTerms(tc.text, tc.terms, tc.foldCase, "«", "»"); got != tc.want {
+			t.Errorf("highlightTerms(%q, %q) = %q, want %q", tc.text, tc.terms, got, tc.want)
+		}
+	}
+	if got := highlightTerms("foo", []string{"foo"}, true, "", ""); got != "foo" {
+		t.Fatalf("expected no markers when highlighting is off, got %q", got)
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	got := queryTerms("How is the DB client's retry-backoff configured? DB retry")
+	want := []string{"how", "the", "client", "retry", "backoff", "configured"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("queryTerms = %q, want %q", got, want)
+	}
+}
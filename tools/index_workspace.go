@@ -2,14 +2,19 @@ package tools
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// L1IndexerTools exposes MCP handlers for workspace indexing.
+// L1IndexerTools exposes MCP handlers for workspace indexing. DB is used to
+// resolve registered workspace paths for Reindex.
 type L1IndexerTools struct {
 	Engine *indexer.Indexer
+	DB     *surreal.Client
 }
 
 // IndexWorkspaceInput contains required fields for L1 steps.
@@ -24,35 +29,111 @@ type IndexWorkspaceOutput struct {
 	Run *indexer.RunReport `json:"run,omitempty"`
 }
 
+func (o IndexWorkspaceOutput) auditAcceptance() string {
+	if o.Run == nil {
+		return ""
+	}
+	return o.Run.Acceptance
+}
+
 // Scan handles index.workspace.scan.
-func (l *L1IndexerTools) Scan(ctx context.Context, _ *mcp.CallToolRequest, input IndexWorkspaceInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
+func (l *L1IndexerTools) Scan(ctx context.Context, req *mcp.CallToolRequest, input IndexWorkspaceInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
 	report, err := l.Engine.Scan(ctx, indexer.WorkspaceRequest{
 		WorkspaceRoot: input.WorkspaceRoot,
 		WorkspaceID:   input.WorkspaceID,
 		RunID:         input.RunID,
+		Request:       req,
 	})
 	out := IndexWorkspaceOutput{Run: report}
 	return nil, out, err
 }
 
 // Embed handles index.workspace.embed.
-func (l *L1IndexerTools) Embed(ctx context.Context, _ *mcp.CallToolRequest, input IndexWorkspaceInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
+func (l *L1IndexerTools) Embed(ctx context.Context, req *mcp.CallToolRequest, input IndexWorkspaceInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
 	report, err := l.Engine.Embed(ctx, indexer.WorkspaceRequest{
 		WorkspaceRoot: input.WorkspaceRoot,
 		WorkspaceID:   input.WorkspaceID,
 		RunID:         input.RunID,
+		Request:       req,
 	})
 	out := IndexWorkspaceOutput{Run: report}
 	return nil, out, err
 }
 
 // All orchestrates the full pipeline.
-func (l *L1IndexerTools) All(ctx context.Context, _ *mcp.CallToolRequest, input IndexWorkspaceInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
+func (l *L1IndexerTools) All(ctx context.Context, req *mcp.CallToolRequest, input IndexWorkspaceInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
 	report, err := l.Engine.All(ctx, indexer.WorkspaceRequest{
 		WorkspaceRoot: input.WorkspaceRoot,
 		WorkspaceID:   input.WorkspaceID,
 		RunID:         input.RunID,
+		Request:       req,
 	})
 	out := IndexWorkspaceOutput{Run: report}
 	return nil, out, err
 }
+
+// IndexCancelInput identifies the run to cancel.
+type IndexCancelInput struct {
+	RunID string `json:"runId" jsonschema:"run id of the in-flight index run"`
+}
+
+// IndexCancelOutput reports whether a run was cancelled.
+type IndexCancelOutput struct {
+	RunID     string `json:"runId"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+// Cancel handles index.cancel.
+func (l *L1IndexerTools) Cancel(_ context.Context, _ *mcp.CallToolRequest, input IndexCancelInput) (*mcp.CallToolResult, IndexCancelOutput, error) {
+	runID := strings.TrimSpace(input.RunID)
+	if runID == "" {
+		return nil, IndexCancelOutput{}, fmt.Errorf("runId is required")
+	}
+	if !l.Engine.CancelRun(runID) {
+		return nil, IndexCancelOutput{RunID: runID}, fmt.Errorf("run %s is not in progress", runID)
+	}
+	return nil, IndexCancelOutput{RunID: runID, Cancelled: true}, nil
+}
+
+// WorkspaceReindexInput identifies a registered workspace to index again.
+type WorkspaceReindexInput struct {
+	WorkspaceID  string `json:"workspaceId" jsonschema:"registered workspace identifier"`
+	FullPipeline *bool  `json:"fullPipeline,omitempty" jsonschema:"scan and embed (default true); false re-embeds only"`
+	RunID        string `json:"runId,omitempty" jsonschema:"optional deterministic run id"`
+}
+
+// Reindex handles workspace_reindex: it runs All, or Embed when fullPipeline is
+// false, against the path stored on the workspace record.
+func (l *L1IndexerTools) Reindex(ctx context.Context, req *mcp.CallToolRequest, input WorkspaceReindexInput) (*mcp.CallToolResult, IndexWorkspaceOutput, error) {
+	if l == nil || l.DB == nil {
+		return nil, IndexWorkspaceOutput{}, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, IndexWorkspaceOutput{}, fmt.Errorf("workspaceId is required")
+	}
+	type workspaceRow struct {
+		Path string `json:"path"`
+	}
+	ws, err := surreal.SelectRecord[workspaceRow](ctx, l.DB, "workspace", wsID)
+	if err != nil {
+		return nil, IndexWorkspaceOutput{}, fmt.Errorf("lookup workspace: %w", err)
+	}
+	if ws == nil || strings.TrimSpace(ws.Path) == "" {
+		return nil, IndexWorkspaceOutput{}, fmt.Errorf("workspace %q is not registered", wsID)
+	}
+
+	wreq := indexer.WorkspaceRequest{
+		WorkspaceRoot: ws.Path,
+		WorkspaceID:   wsID,
+		RunID:         input.RunID,
+		Request:       req,
+	}
+	var report *indexer.RunReport
+	if input.FullPipeline == nil || *input.FullPipeline {
+		report, err = l.Engine.All(ctx, wreq)
+	} else {
+		report, err = l.Engine.Embed(ctx, wreq)
+	}
+	return nil, IndexWorkspaceOutput{Run: report}, err
+}
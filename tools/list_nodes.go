@@ -3,11 +3,17 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// defaultNodeStaleAfter is how long a node may go without a heartbeat before
+// list_nodes reports it as stale.
+const defaultNodeStaleAfter = 5 * time.Minute
+
 type ListNodes struct {
 	DB *surreal.Client
 }
@@ -17,7 +23,10 @@ type ListNodesOutput struct {
 }
 
 type ListNodesInput struct {
-	Kind string `json:"kind,omitempty" jsonschema:"OPTIONAL, NOT IMPLEMENTED node kind (pc, vm, etc.)"`
+	Kind  string `json:"kind,omitempty" jsonschema:"optional node kind to filter by (pc, vm, etc.)"`
+	Label string `json:"label,omitempty" jsonschema:"optional label the node must carry"`
+	// StaleAfterSecs marks nodes whose last heartbeat is older than this.
+	StaleAfterSecs int `json:"staleAfterSecs,omitempty" jsonschema:"seconds without a heartbeat before a node is reported stale (default 300)"`
 }
 
 type NodeSummary struct {
@@ -28,6 +37,10 @@ type NodeSummary struct {
 	CPU    string   `json:"cpu,omitempty" jsonschema:"cpu model"`
 	RAMGB  int      `json:"ramGb,omitempty" jsonschema:"RAM in GB"`
 	Labels []string `json:"labels,omitempty" jsonschema:"free-form labels"`
+
+	LastSeen *time.Time `json:"lastSeen,omitempty" jsonschema:"time of the last node_heartbeat (UTC)"`
+	Status   string     `json:"status,omitempty" jsonschema:"status sent with the last heartbeat"`
+	Stale    bool       `json:"stale,omitempty" jsonschema:"true when the last heartbeat is older than staleAfterSecs; nodes that never sent one are not stale"`
 }
 
 func (l *ListNodes) List(ctx context.Context, _ *mcp.CallToolRequest, input ListNodesInput) (*mcp.CallToolResult, ListNodesOutput, error) {
@@ -43,23 +56,48 @@ func (l *ListNodes) List(ctx context.Context, _ *mcp.CallToolRequest, input List
 		CPU    string   `json:"cpu"`
 		RAMGB  int      `json:"ram_gb"`
 		Labels []string `json:"labels"`
-	}
 
-	if input.Kind != "" {
-		println(fmt.Sprintf("They wanted a kind of... %s ?", input.Kind))
+		LastSeen *time.Time `json:"last_seen"`
+		Status   string     `json:"status"`
 	}
 
-	const q = `
-SELECT meta::id(id) AS id, name, kind, os, cpu, ram_gb, labels
+	q := `
+SELECT meta::id(id) AS id, name, kind, os, cpu, ram_gb, labels, last_seen, status
 FROM node
-ORDER BY name ASC
 `
 
-	rows, err := surreal.Query[nodeRow](ctx, l.DB, q, nil)
+	var (
+		filters []string
+		vars    = map[string]any{}
+	)
+
+	if kind := strings.TrimSpace(input.Kind); kind != "" {
+		filters = append(filters, "kind = $kind")
+		vars["kind"] = kind
+	}
+
+	if label := strings.TrimSpace(input.Label); label != "" {
+		filters = append(filters, "$label IN labels")
+		vars["label"] = label
+	}
+
+	if len(filters) > 0 {
+		q += "WHERE " + strings.Join(filters, " AND ") + "\n"
+	}
+
+	q += "ORDER BY name ASC\n"
+
+	rows, err := surreal.Query[nodeRow](ctx, l.DB, q, vars)
 	if err != nil {
 		return nil, ListNodesOutput{}, fmt.Errorf("list nodes: %w", err)
 	}
 
+	staleAfter := defaultNodeStaleAfter
+	if input.StaleAfterSecs > 0 {
+		staleAfter = time.Duration(input.StaleAfterSecs) * time.Second
+	}
+	now := time.Now()
+
 	summaries := make([]NodeSummary, 0, len(rows))
 	for _, row := range rows {
 		summaries = append(summaries, NodeSummary{
@@ -70,6 +108,10 @@ ORDER BY name ASC
 			CPU:    row.CPU,
 			RAMGB:  row.RAMGB,
 			Labels: row.Labels,
+
+			LastSeen: row.LastSeen,
+			Status:   row.Status,
+			Stale:    row.LastSeen != nil && now.Sub(*row.LastSeen) > staleAfter,
 		})
 	}
 
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type NodeHeartbeat struct {
+	DB *surreal.Client
+}
+
+type NodeHeartbeatInput struct {
+	NodeID string `json:"nodeId" jsonschema:"identifier the node was registered with"`
+	Status string `json:"status,omitempty" jsonschema:"optional free-form status, e.g. idle or busy"`
+}
+
+type NodeHeartbeatOutput struct {
+	Node     string    `json:"node"`
+	LastSeen time.Time `json:"lastSeen" jsonschema:"time the heartbeat was recorded (UTC)"`
+}
+
+func (n *NodeHeartbeat) Beat(ctx context.Context, _ *mcp.CallToolRequest, input NodeHeartbeatInput) (*mcp.CallToolResult, NodeHeartbeatOutput, error) {
+	if n == nil || n.DB == nil {
+		return nil, NodeHeartbeatOutput{}, fmt.Errorf("surreal client not configured")
+	}
+	nodeID := strings.TrimSpace(input.NodeID)
+	if nodeID == "" {
+		return nil, NodeHeartbeatOutput{}, fmt.Errorf("nodeId is required")
+	}
+
+	// Merging into a missing record would create a node without a name.
+	existing, err := surreal.SelectRecord[map[string]any](ctx, n.DB, "node", nodeID)
+	if err != nil {
+		return nil, NodeHeartbeatOutput{}, fmt.Errorf("lookup node: %w", err)
+	}
+	if existing == nil {
+		return nil, NodeHeartbeatOutput{}, fmt.Errorf("node %q is not registered", nodeID)
+	}
+
+	now := time.Now().UTC()
+	data := map[string]any{
+		"last_seen": now,
+	}
+	if status := strings.TrimSpace(input.Status); status != "" {
+		data["status"] = status
+	}
+	if err := n.DB.MergeRecord(ctx, "node", nodeID, data); err != nil {
+		return nil, NodeHeartbeatOutput{}, fmt.Errorf("merge node heartbeat: %w", err)
+	}
+
+	return nil, NodeHeartbeatOutput{Node: nodeID, LastSeen: now}, nil
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdle is how long a session's buckets survive without calls.
+const limiterIdle = 5 * time.Minute
+
+// sharedBucket is the bucket used by every tool without its own limit.
+const sharedBucket = "exec"
+
+// execLimits throttles term_exec and term_pty per MCP session.
+var execLimits = newSessionLimiter(60, nil)
+
+// ConfigureExecRateLimit sets how many term_exec/term_pty calls a session may
+// make per minute. Tools listed in perTool get their own bucket with that
+// limit; the rest share one bucket of perMinute. A limit of zero disables
+// throttling. It should be called once at startup.
+func ConfigureExecRateLimit(perMinute int, perTool map[string]int) {
+	execLimits = newSessionLimiter(perMinute, perTool)
+}
+
+// sessionLimiter keeps a token bucket per session and bucket name.
+type sessionLimiter struct {
+	perMinute int
+	perTool   map[string]int
+
+	mu        sync.Mutex
+	buckets   map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+type limiterEntry struct {
+	limiter *rate.Limiter
+	last    time.Time
+}
+
+func newSessionLimiter(perMinute int, perTool map[string]int) *sessionLimiter {
+	return &sessionLimiter{
+		perMinute: perMinute,
+		perTool:   perTool,
+		buckets:   make(map[string]*limiterEntry),
+	}
+}
+
+// allow takes a token for tool in sessionID's bucket. When none is available it
+// reports how long until one is.
+func (l *sessionLimiter) allow(sessionID, tool string, now time.Time) (bool, time.Duration) {
+	bucket, limit := sharedBucket, l.perMinute
+	if n, ok := l.perTool[tool]; ok {
+		bucket, limit = tool, n
+	}
+	if limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(now)
+
+	key := sessionID + "\x00" + bucket
+	entry, ok := l.buckets[key]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(rate.Limit(float64(limit)/60), limit)}
+		l.buckets[key] = entry
+	}
+	entry.last = now
+
+	r := entry.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweepLocked drops buckets idle for longer than limiterIdle. It runs at most
+// once per idle period so the common path stays cheap.
+func (l *sessionLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < limiterIdle {
+		return
+	}
+	l.lastSweep = now
+	for key, entry := range l.buckets {
+		if now.Sub(entry.last) > limiterIdle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// checkExecRate returns a user-facing error message when the calling session
+// has exhausted its budget for tool, or "" when the call may proceed.
+func checkExecRate(req *mcp.CallToolRequest, tool string) string {
+	ok, wait := execLimits.allow(sessionIDOf(req), tool, time.Now())
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("rate limit exceeded; try again in %ds", int(math.Ceil(wait.Seconds())))
+}
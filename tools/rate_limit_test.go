@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionLimiterSharedBucket(t *testing.T) {
+	l := newSessionLimiter(2, nil)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("s1", "term_exec", now); !ok {
+			t.Fatalf("call %d should be allowed", i)
+		}
+	}
+	ok, wait := l.allow("s1", "term_pty", now)
+	if ok {
+		t.Fatalf("third call across tools should be limited")
+	}
+	if wait <= 0 || wait > 30*time.Second {
+		t.Fatalf("unexpected wait %s", wait)
+	}
+	if ok, _ := l.allow("s2", "term_exec", now); !ok {
+		t.Fatalf("other sessions have their own bucket")
+	}
+	if ok, _ := l.allow("s1", "term_exec", now.Add(30*time.Second)); !ok {
+		t.Fatalf("bucket should refill after 30s at 2/min")
+	}
+}
+
+func TestSessionLimiterPerToolOverride(t *testing.T) {
+	l := newSessionLimiter(1, map[string]int{"term_pty": 3})
+	now := time.Now()
+
+	if ok, _ := l.allow("s1", "term_exec", now); !ok {
+		t.Fatalf("first exec should be allowed")
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("s1", "term_pty", now); !ok {
+			t.Fatalf("pty call %d should use its own bucket", i)
+		}
+	}
+	if ok, _ := l.allow("s1", "term_pty", now); ok {
+		t.Fatalf("fourth pty call should be limited")
+	}
+	if ok, _ := l.allow("s1", "term_exec", now); ok {
+		t.Fatalf("second exec should be limited")
+	}
+}
+
+func TestSessionLimiterDisabledAndSweep(t *testing.T) {
+	if ok, _ := newSessionLimiter(0, nil).allow("s1", "term_exec", time.Now()); !ok {
+		t.Fatalf("zero limit disables throttling")
+	}
+
+	l := newSessionLimiter(5, nil)
+	now := time.Now()
+	l.allow("s1", "term_exec", now)
+	l.allow("s2", "term_exec", now.Add(time.Second))
+	if len(l.buckets) != 2 {
+		t.Fatalf("expected 2 buckets before sweep, got %d", len(l.buckets))
+	}
+	l.allow("s2", "term_exec", now.Add(limiterIdle+2*time.Second))
+	if _, ok := l.buckets["s1\x00"+sharedBucket]; ok {
+		t.Fatalf("idle session bucket should be swept")
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/auditlog"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// auditLog receives an entry for every tool call wrapped by Recover. Nil
+// disables auditing.
+var auditLog *auditlog.Logger
+
+// ConfigureAuditLog sets the audit log for tool calls. It should be called
+// once at startup, before the server accepts requests.
+func ConfigureAuditLog(l *auditlog.Logger) {
+	auditLog = l
+}
+
+// auditAcceptor is implemented by outputs that carry their own acceptance,
+// such as index run reports.
+type auditAcceptor interface {
+	auditAcceptance() string
+}
+
+// Recover wraps a tool handler so that a panic is logged with its stack and
+// returned to the client as a tool error instead of crashing the server. Each
+// call is also recorded in the audit log.
+func Recover[In, Out any](h mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input In) (result *mcp.CallToolResult, output Out, err error) {
+		name := "unknown"
+		if req != nil && req.Params != nil {
+			name = req.Params.Name
+		}
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("tool panicked", "tool", name, "panic", r, "stack", string(debug.Stack()))
+				var zero Out
+				result, output, err = nil, zero, fmt.Errorf("tool %s failed: internal error: %v", name, r)
+			}
+			audit(req, name, input, start, result, output, err)
+		}()
+		return h(ctx, req, input)
+	}
+}
+
+// audit records a finished tool call. Acceptance is "fail" when the call
+// returned an error or an error result, unless the output reports its own.
+func audit(req *mcp.CallToolRequest, name string, input any, start time.Time, result *mcp.CallToolResult, output any, err error) {
+	if auditLog == nil {
+		return
+	}
+	entry := auditlog.Entry{
+		TS:         start.UTC(),
+		SessionID:  sessionIDOf(req),
+		Tool:       name,
+		DurationMS: time.Since(start).Milliseconds(),
+		Acceptance: "pass",
+	}
+	if hash, hashErr := auditlog.HashInput(input); hashErr == nil {
+		entry.InputHash = hash
+	}
+	if a, ok := output.(auditAcceptor); ok && a.auditAcceptance() != "" {
+		entry.Acceptance = a.auditAcceptance()
+	} else if err != nil || (result != nil && result.IsError) {
+		entry.Acceptance = "fail"
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditLog.Log(entry)
+}
+
+func sessionIDOf(req *mcp.CallToolRequest) string {
+	if req != nil && req.Session != nil {
+		return req.Session.ID()
+	}
+	return ""
+}
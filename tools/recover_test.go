@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/auditlog"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestRecoverConvertsPanicToError(t *testing.T) {
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	defer slog.SetDefault(prev)
+
+	h := Recover(func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, int, error) {
+		var m map[string]int
+		m["boom"] = 1
+		return nil, 1, nil
+	})
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "panicky"}}
+	res, out, err := h(context.Background(), req, struct{}{})
+	if err == nil || !strings.Contains(err.Error(), "panicky") {
+		t.Fatalf("expected tool error naming the tool, got %v", err)
+	}
+	if res != nil || out != 0 {
+		t.Fatalf("expected zero result, got %v %v", res, out)
+	}
+}
+
+func TestRecoverWritesAuditEntry(t *testing.T) {
+	var buf bytes.Buffer
+	ConfigureAuditLog(auditlog.New(&buf, nil))
+	defer ConfigureAuditLog(nil)
+
+	type input struct {
+		Query string `json:"query"`
+		Token string `json:"token"`
+	}
+	h := Recover(func(context.Context, *mcp.CallToolRequest, input) (*mcp.CallToolResult, int, error) {
+		return nil, 0, errors.New("no such workspace")
+	})
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "workspace_tree"}}
+	_, _, _ = h(context.Background(), req, input{Query: "q", Token: "secret"})
+	if err := auditLog.Close(); err != nil {
+		t.Fatalf("close audit log: %v", err)
+	}
+
+	var entry auditlog.Entry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode entry %q: %v", buf.String(), err)
+	}
+	if entry.Tool != "workspace_tree" || entry.Acceptance != "fail" || entry.Error != "no such workspace" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	want, _ := auditlog.HashInput(input{Query: "q", Token: "other"})
+	if entry.InputHash != want {
+		t.Fatalf("input hash should ignore redacted token")
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("token leaked into audit log: %s", buf.String())
+	}
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RunHistory serves index run reports from the run table, which survives
+// server restarts.
+type RunHistory struct {
+	DB *surreal.Client
+}
+
+type RunListInput struct {
+	WorkspaceID string `json:"workspaceId,omitempty" jsonschema:"optional workspace identifier to filter by"`
+	Step        string `json:"step,omitempty" jsonschema:"optional step to filter by (index.scan, index.embed, index.all)"`
+	Acceptance  string `json:"acceptance,omitempty" jsonschema:"optional outcome to filter by (pass or fail)"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"maximum number of runs to return, newest first (default 50)"`
+}
+
+type RunListOutput struct {
+	Runs []indexer.RunReport `json:"runs" jsonschema:"run reports, newest first"`
+}
+
+type RunGetInput struct {
+	RunID string `json:"runId" jsonschema:"run identifier"`
+}
+
+type RunGetOutput struct {
+	Run *indexer.RunReport `json:"run,omitempty" jsonschema:"the run report"`
+}
+
+const runFields = "run_id, step, started, finished, acceptance, risks, notes, artifact_paths"
+
+func (h *RunHistory) List(ctx context.Context, _ *mcp.CallToolRequest, input RunListInput) (*mcp.CallToolResult, RunListOutput, error) {
+	runs := []indexer.RunReport{}
+	if h == nil || h.DB == nil {
+		return nil, RunListOutput{Runs: runs}, fmt.Errorf("surreal client not configured")
+	}
+
+	var (
+		filters []string
+		vars    = map[string]any{"limit": clampLimit(input.Limit, 50)}
+	)
+	if ws := strings.TrimSpace(input.WorkspaceID); ws != "" {
+		filters = append(filters, "workspace_id = $ws_id")
+		vars["ws_id"] = ws
+	}
+	if step := strings.TrimSpace(input.Step); step != "" {
+		filters = append(filters, "step = $step")
+		vars["step"] = step
+	}
+	if acceptance := strings.ToLower(strings.TrimSpace(input.Acceptance)); acceptance != "" {
+		filters = append(filters, "acceptance = $acceptance")
+		vars["acceptance"] = acceptance
+	}
+
+	q := "SELECT " + runFields + "\nFROM run\n"
+	if len(filters) > 0 {
+		q += "WHERE " + strings.Join(filters, " AND ") + "\n"
+	}
+	q += "ORDER BY started DESC\nLIMIT $limit\n"
+
+	rows, err := surreal.Query[indexer.RunReport](ctx, h.DB, q, vars)
+	if err != nil {
+		return nil, RunListOutput{Runs: runs}, fmt.Errorf("list runs: %w", err)
+	}
+	runs = append(runs, rows...)
+	return nil, RunListOutput{Runs: runs}, nil
+}
+
+func (h *RunHistory) Get(ctx context.Context, _ *mcp.CallToolRequest, input RunGetInput) (*mcp.CallToolResult, RunGetOutput, error) {
+	if h == nil || h.DB == nil {
+		return nil, RunGetOutput{}, fmt.Errorf("surreal client not configured")
+	}
+	runID := strings.TrimSpace(input.RunID)
+	if runID == "" {
+		return nil, RunGetOutput{}, fmt.Errorf("runId is required")
+	}
+
+	q := "SELECT " + runFields + "\nFROM type::thing('run', $run_id)\n"
+	rows, err := surreal.Query[indexer.RunReport](ctx, h.DB, q, map[string]any{"run_id": runID})
+	if err != nil {
+		return nil, RunGetOutput{}, fmt.Errorf("get run: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, RunGetOutput{}, fmt.Errorf("run %q not found", runID)
+	}
+	return nil, RunGetOutput{Run: &rows[0]}, nil
+}
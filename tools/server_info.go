@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ServerInfo answers server_info. main fills it in while registering tools;
+// it must not change once the server is serving.
+type ServerInfo struct {
+	Name        string
+	Version     string
+	EmbedModel  string
+	TokenizerID string
+	TransformID string
+	Tools       []string
+}
+
+type ServerInfoInput struct{}
+
+type ServerInfoOutput struct {
+	Name        string    `json:"name" jsonschema:"MCP implementation name"`
+	Version     string    `json:"version" jsonschema:"MCP implementation version"`
+	GoVersion   string    `json:"goVersion" jsonschema:"Go runtime version"`
+	Build       BuildInfo `json:"build" jsonschema:"module and VCS details embedded at build time"`
+	EmbedModel  string    `json:"embedModel" jsonschema:"configured embedding model"`
+	TokenizerID string    `json:"tokenizerId" jsonschema:"configured tokenizer"`
+	TransformID string    `json:"transformId" jsonschema:"configured vector transform id"`
+	Tools       []string  `json:"tools" jsonschema:"names of the registered tools"`
+}
+
+// BuildInfo is the subset of debug.BuildInfo useful for telling deployments apart.
+type BuildInfo struct {
+	Module   string `json:"module,omitempty"`
+	Version  string `json:"version,omitempty" jsonschema:"module version, (devel) for local builds"`
+	Revision string `json:"revision,omitempty" jsonschema:"VCS revision"`
+	Time     string `json:"time,omitempty" jsonschema:"VCS commit time"`
+	Modified bool   `json:"modified,omitempty" jsonschema:"true when built from a dirty tree"`
+}
+
+func (s *ServerInfo) Info(_ context.Context, _ *mcp.CallToolRequest, _ ServerInfoInput) (*mcp.CallToolResult, ServerInfoOutput, error) {
+	toolNames := append([]string(nil), s.Tools...)
+	sort.Strings(toolNames)
+	return nil, ServerInfoOutput{
+		Name:        s.Name,
+		Version:     s.Version,
+		GoVersion:   runtime.Version(),
+		Build:       readBuildInfo(),
+		EmbedModel:  s.EmbedModel,
+		TokenizerID: s.TokenizerID,
+		TransformID: s.TransformID,
+		Tools:       toolNames,
+	}, nil
+}
+
+func readBuildInfo() BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}
+	}
+	out := BuildInfo{Module: bi.Main.Path, Version: bi.Main.Version}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			out.Revision = setting.Value
+		case "vcs.time":
+			out.Time = setting.Value
+		case "vcs.modified":
+			out.Modified = setting.Value == "true"
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+)
+
+// SymbolSpan is the indexed symbol enclosing a vector match.
+type SymbolSpan struct {
+	Name      string `json:"name" jsonschema:"symbol name"`
+	Kind      string `json:"kind,omitempty" jsonschema:"symbol kind, e.g. func, type or class"`
+	StartLine int    `json:"startLine" jsonschema:"first line of the symbol (1-based)"`
+	EndLine   int    `json:"endLine" jsonschema:"last line of the symbol (1-based, inclusive)"`
+	Start     int    `json:"start" jsonschema:"byte offset of the start of startLine"`
+	End       int    `json:"end" jsonschema:"byte offset just past endLine"`
+	Text      string `json:"text" jsonschema:"full text of the symbol's lines"`
+}
+
+// symbolRange is a symbol row with the line part of its {start:{l,c}, end:{l,c}}
+// range; columns are ignored since whole lines are returned.
+type symbolRange struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Range struct {
+		Start struct {
+			L int `json:"l"`
+		} `json:"start"`
+		End struct {
+			L int `json:"l"`
+		} `json:"end"`
+	} `json:"range"`
+}
+
+// lookupFileSymbols returns the symbols indexed for rel in the workspace.
+func lookupFileSymbols(ctx context.Context, db *surreal.Client, wsID, rel string) ([]symbolRange, error) {
+	const q = `
+SELECT name, kind, range
+FROM symbol
+WHERE ws = type::thing('workspace', $ws_id) AND file.relpath = $rel
+`
+	rows, err := surreal.Query[symbolRange](ctx, db, q, map[string]any{"ws_id": wsID, "rel": rel})
+	if err != nil {
+		return nil, fmt.Errorf("lookup symbols: %w", err)
+	}
+	return rows, nil
+}
+
+// enclosingSymbol returns the smallest symbol whose line range contains
+// startLine..endLine, or nil when none does.
+func enclosingSymbol(symbols []symbolRange, startLine, endLine int) *symbolRange {
+	var best *symbolRange
+	for i := range symbols {
+		s := &symbols[i]
+		from, to := s.Range.Start.L, s.Range.End.L
+		if from <= 0 || from > startLine || to < endLine {
+			continue
+		}
+		if best == nil || to-from < best.Range.End.L-best.Range.Start.L {
+			best = s
+		}
+	}
+	return best
+}
+
+// expandToSymbol returns the symbol enclosing data[start:end], or nil when no
+// symbol contains the chunk's lines or data is empty.
+func expandToSymbol(data []byte, symbols []symbolRange, start, end int) *SymbolSpan {
+	if len(data) == 0 || len(symbols) == 0 {
+		return nil
+	}
+	start = max(0, min(start, len(data)))
+	end = max(start, min(end, len(data)))
+	last := end
+	if last > start {
+		last-- // a chunk ending in a newline does not reach the next line
+	}
+	startLine := 1 + bytes.Count(data[:start], []byte{'\n'})
+	endLine := startLine + bytes.Count(data[start:last], []byte{'\n'})
+
+	sym := enclosingSymbol(symbols, startLine, endLine)
+	if sym == nil {
+		return nil
+	}
+	from, to := lineOffsets(data, sym.Range.Start.L, sym.Range.End.L)
+	return &SymbolSpan{
+		Name:      sym.Name,
+		Kind:      sym.Kind,
+		StartLine: sym.Range.Start.L,
+		EndLine:   sym.Range.End.L,
+		Start:     from,
+		End:       to,
+		Text:      string(data[from:to]),
+	}
+}
+
+// lineOffsets returns the byte range of lines first..last (1-based, inclusive),
+// including the final newline. Lines past the end of data are clamped.
+func lineOffsets(data []byte, first, last int) (int, int) {
+	start, line := 0, 1
+	for line < first && start < len(data) {
+		i := bytes.IndexByte(data[start:], '\n')
+		if i < 0 {
+			start = len(data)
+			break
+		}
+		start += i + 1
+		line++
+	}
+	end := start
+	for line <= last && end < len(data) {
+		i := bytes.IndexByte(data[end:], '\n')
+		if i < 0 {
+			end = len(data)
+			break
+		}
+		end += i + 1
+		line++
+	}
+	return start, end
+}
@@ -0,0 +1,48 @@
+package tools
+
+import "testing"
+
+func symbolAt(name string, from, to int) symbolRange {
+	var s symbolRange
+	s.Name, s.Kind = name, "func"
+	s.Range.Start.L, s.Range.End.L = from, to
+	return s
+}
+
+func TestExpandToSymbol(t *testing.T) {
+	data := []byte("package x\n\ntype T struct{}\n\nfunc (T) M() {\n\ta()\n\tb()\n}\n")
+	symbols := []symbolRange{symbolAt("T", 3, 8), symbolAt("T.M", 5, 8)}
+
+	// A chunk covering "\ta()\n" sits inside both; the narrower method wins.
+	start := len("package x\n\ntype T struct{}\n\nfunc (T) M() {\n")
+	got := expandToSymbol(data, symbols, start, start+len("\ta()\n"))
+	if got == nil || got.Name != "T.M" || got.StartLine != 5 || got.EndLine != 8 {
+		t.Fatalf("unexpected symbol %+v", got)
+	}
+	if want := "func (T) M() {\n\ta()\n\tb()\n}\n"; got.Text != want || string(data[got.Start:got.End]) != want {
+		t.Fatalf("text = %q, want %q", got.Text, want)
+	}
+
+	// The package clause is outside every symbol.
+	if got := expandToSymbol(data, symbols, 0, len("package x\n")); got != nil {
+		t.Fatalf("expected no symbol, got %+v", got)
+	}
+	if got := expandToSymbol(data, nil, start, start+1); got != nil {
+		t.Fatalf("expected no symbol without symbols, got %+v", got)
+	}
+}
+
+func TestLineOffsets(t *testing.T) {
+	data := []byte("a\nbb\nccc")
+	cases := []struct{ first, last, start, end int }{
+		{1, 1, 0, 2},
+		{2, 3, 2, 8},
+		{3, 9, 5, 8},
+		{5, 6, 8, 8},
+	}
+	for _, tc := range cases {
+		if s, e := lineOffsets(data, tc.first, tc.last); s != tc.start || e != tc.end {
+			t.Errorf("lineOffsets(%d, %d) = %d, %d; want %d, %d", tc.first, tc.last, s, e, tc.start, tc.end)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SystemResources reports host capacity so callers can decide whether a large
+// index run fits. ArtifactRoot is the directory whose filesystem is checked
+// for free space.
+type SystemResources struct {
+	Engine       *indexer.Indexer
+	ArtifactRoot string
+}
+
+type SystemResourcesInput struct{}
+
+type SystemResourcesOutput struct {
+	OS                 string `json:"os" jsonschema:"operating system (GOOS)"`
+	Arch               string `json:"arch" jsonschema:"CPU architecture (GOARCH)"`
+	GoVersion          string `json:"goVersion" jsonschema:"Go runtime version"`
+	NumCPU             int    `json:"numCPU" jsonschema:"logical CPUs usable by the server"`
+	GoHeapAllocMB      int64  `json:"goHeapAllocMB" jsonschema:"heap memory allocated by the server"`
+	OSFreeMemMB        int64  `json:"osFreeMemMB" jsonschema:"free host memory; -1 when unavailable on this OS"`
+	ArtifactDiskFreeMB int64  `json:"artifactDiskFreeMB" jsonschema:"free space for artifact_root; -1 when unavailable on this OS"`
+	ActivePTYSessions  int    `json:"activePTYSessions" jsonschema:"open term_pty sessions"`
+	ActiveIndexRuns    int    `json:"activeIndexRuns" jsonschema:"index runs in progress"`
+}
+
+func (s *SystemResources) Info(_ context.Context, _ *mcp.CallToolRequest, _ SystemResourcesInput) (*mcp.CallToolResult, SystemResourcesOutput, error) {
+	const mb = 1 << 20
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	out := SystemResourcesOutput{
+		OS:                 runtime.GOOS,
+		Arch:               runtime.GOARCH,
+		GoVersion:          runtime.Version(),
+		NumCPU:             runtime.NumCPU(),
+		GoHeapAllocMB:      int64(mem.HeapAlloc / mb),
+		OSFreeMemMB:        -1,
+		ArtifactDiskFreeMB: -1,
+	}
+	if free, ok := osFreeMemBytes(); ok {
+		out.OSFreeMemMB = int64(free / mb)
+	}
+	if s != nil && s.ArtifactRoot != "" {
+		if free, ok := diskFreeBytes(existingAncestor(s.ArtifactRoot)); ok {
+			out.ArtifactDiskFreeMB = int64(free / mb)
+		}
+	}
+
+	ptyRegistry.Lock()
+	out.ActivePTYSessions = len(ptyRegistry.sessions)
+	ptyRegistry.Unlock()
+	if s != nil && s.Engine != nil {
+		out.ActiveIndexRuns = s.Engine.ActiveRuns()
+	}
+	return nil, out, nil
+}
+
+// existingAncestor returns dir, or its closest existing parent, so free space
+// can be reported before the first run creates artifact_root.
+func existingAncestor(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package tools
+
+import "syscall"
+
+func osFreeMemBytes() (uint64, bool) {
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return 0, false
+	}
+	return uint64(info.Freeram) * uint64(info.Unit), true
+}
+
+func diskFreeBytes(path string) (uint64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return st.Bavail * uint64(st.Bsize), true
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package tools
+
+func osFreeMemBytes() (uint64, bool) { return 0, false }
+
+func diskFreeBytes(string) (uint64, bool) { return 0, false }
@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSystemResourcesInfo(t *testing.T) {
+	s := &SystemResources{ArtifactRoot: filepath.Join(t.TempDir(), "not", "created", "yet")}
+	_, out, err := s.Info(context.Background(), nil, SystemResourcesInput{})
+	if err != nil {
+		t.Fatalf("info: %v", err)
+	}
+	if out.NumCPU < 1 || out.GoVersion == "" || out.OS != runtime.GOOS {
+		t.Fatalf("unexpected runtime fields %+v", out)
+	}
+	if runtime.GOOS == "linux" && (out.OSFreeMemMB < 0 || out.ArtifactDiskFreeMB < 0) {
+		t.Fatalf("expected host memory and disk stats on linux, got %+v", out)
+	}
+}
@@ -12,6 +12,8 @@ import (
 type Input struct {
 	Command string   `json:"command" jsonschema:"the command to execute"`
 	Args    []string `json:"args,omitempty" jsonschema:"the command arguments in order (optional)"`
+	// CombineOutput captures both streams into one buffer, like 2>&1.
+	CombineOutput bool `json:"combineOutput,omitempty" jsonschema:"capture stdout and stderr together in stdout, preserving their interleaving (like 2>&1); stderr is then empty"`
 }
 
 type Output struct {
@@ -21,20 +23,37 @@ type Output struct {
 	Error    string `json:"error,omitempty" jsonschema:"error message if execution failed"`
 }
 
-func ExecCommand(ctx context.Context, _ *mcp.CallToolRequest, input Input) (
+func ExecCommand(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 	*mcp.CallToolResult, Output, error,
 ) {
 	if strings.TrimSpace(input.Command) == "" {
 		return nil, Output{}, fmt.Errorf("command is required")
 	}
+	command, err := execPolicy.check(input.Command)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	if msg := checkExecRate(req, "term_exec"); msg != "" {
+		return nil, Output{Error: msg, ExitCode: -1}, nil
+	}
 
-	cmd := exec.CommandContext(ctx, input.Command, input.Args...)
+	cmd := exec.CommandContext(ctx, command, input.Args...)
 
+	// With a progress token, output is also streamed to the client while the
+	// command runs; the full output is still returned at the end.
+	stream := newOutputStream(req)
 	var stdout, stderr strings.Builder
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stdout = stream.writer("stdout", &stdout)
+	cmd.Stderr = stream.writer("stderr", &stderr)
+	if input.CombineOutput {
+		// With the same writer on both, exec shares a single pipe, so the
+		// order the process wrote in is kept.
+		cmd.Stderr = cmd.Stdout
+	}
 
-	err := cmd.Run()
+	stream.start(ctx)
+	err = cmd.Run()
+	stream.finish(ctx)
 
 	out := Output{
 		Stdout: strings.TrimRight(stdout.String(), "\r\n"),
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// execStreamInterval is how often term_exec sends output written since the
+// last progress notification.
+const execStreamInterval = 250 * time.Millisecond
+
+// execStreamChunk caps the output carried by one notification.
+const execStreamChunk = 32 * 1024
+
+// outputStream forwards command output to the client as MCP progress
+// notifications while the command runs. Writes only append to a pending
+// buffer; a separate goroutine sends it, so a slow client never stalls the
+// copy from the command's pipes. A nil stream is valid and does nothing, which
+// is what newOutputStream returns when the request carries no progress token.
+type outputStream struct {
+	session *mcp.ServerSession
+	token   any
+
+	mu      sync.Mutex
+	pending []streamSegment
+	sent    int // bytes sent so far, reported as progress
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// streamSegment is a run of output from one stream.
+type streamSegment struct {
+	name string
+	data []byte
+}
+
+func newOutputStream(req *mcp.CallToolRequest) *outputStream {
+	if req == nil || req.Session == nil || req.Params == nil {
+		return nil
+	}
+	token := req.Params.GetProgressToken()
+	if token == nil {
+		return nil
+	}
+	return &outputStream{session: req.Session, token: token}
+}
+
+// writer returns an io.Writer that captures into buf and queues the output as
+// stream name. With a nil stream it returns buf itself.
+func (s *outputStream) writer(name string, buf io.Writer) io.Writer {
+	if s == nil {
+		return buf
+	}
+	return &streamWriter{s: s, name: name, buf: buf}
+}
+
+// start sends pending output every execStreamInterval until finish is called.
+func (s *outputStream) start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(execStreamInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(ctx, false)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// finish stops the sender and sends whatever output is left. It must be called
+// after the command has exited so no more writes arrive.
+func (s *outputStream) finish(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.flush(ctx, true)
+}
+
+func (s *outputStream) add(name string, p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.pending); n > 0 && s.pending[n-1].name == name {
+		s.pending[n-1].data = append(s.pending[n-1].data, p...)
+		return
+	}
+	s.pending = append(s.pending, streamSegment{name: name, data: append([]byte(nil), p...)})
+}
+
+// flush sends pending output in chunks of at most execStreamChunk bytes. Unless
+// final, a rune split across writes is held back until it is complete.
+func (s *outputStream) flush(ctx context.Context, final bool) {
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		seg := &s.pending[0]
+		n := min(len(seg.data), execStreamChunk)
+		if n < len(seg.data) || !final {
+			n = completeRunes(seg.data[:n])
+		}
+		if n == 0 {
+			if len(s.pending) == 1 {
+				s.mu.Unlock()
+				return
+			}
+			n = len(seg.data) // a later segment means this one is complete
+		}
+		name, text := seg.name, string(seg.data[:n])
+		if seg.data = seg.data[n:]; len(seg.data) == 0 {
+			s.pending = s.pending[1:]
+		}
+		s.sent += n
+		progress := float64(s.sent)
+		s.mu.Unlock()
+
+		if err := s.session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+			ProgressToken: s.token,
+			Message:       name + ": " + text,
+			Progress:      progress,
+		}); err != nil {
+			slog.Warn("term_exec: progress notification failed", "err", err)
+			return
+		}
+	}
+}
+
+// completeRunes returns the length of b without a trailing incomplete UTF-8
+// sequence.
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
+	}
+	return len(b)
+}
+
+// streamWriter is one stream of a command's output. A single writer shared by
+// Stdout and Stderr keeps exec's single-pipe behaviour for combineOutput.
+type streamWriter struct {
+	s    *outputStream
+	name string
+	buf  io.Writer
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	n, err := w.buf.Write(p)
+	w.s.add(w.name, p[:n])
+	return n, err
+}
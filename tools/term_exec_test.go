@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestExecCommandCombineOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	script := "echo one; echo two >&2; echo three"
+
+	_, out, err := ExecCommand(context.Background(), nil, Input{Command: "sh", Args: []string{"-c", script}, CombineOutput: true})
+	if err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	if out.Stdout != "one\ntwo\nthree" || out.Stderr != "" || out.ExitCode != 0 {
+		t.Fatalf("unexpected combined output %+v", out)
+	}
+
+	_, out, err = ExecCommand(context.Background(), nil, Input{Command: "sh", Args: []string{"-c", script}})
+	if err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	if out.Stdout != "one\nthree" || out.Stderr != "two" {
+		t.Fatalf("unexpected separate output %+v", out)
+	}
+}
+
+func TestExecCommandStreamsProgress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "term_exec"}, ExecCommand)
+
+	var mu sync.Mutex
+	var messages []string
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, req.Params.Message)
+		},
+	})
+	st, ct := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	defer ss.Close()
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer cs.Close()
+
+	// SetProgressToken drops the token when Meta is nil, so set Meta directly.
+	params := &mcp.CallToolParams{
+		Meta:      mcp.Meta{"progressToken": "exec-1"},
+		Name:      "term_exec",
+		Arguments: map[string]any{"command": "sh", "args": []string{"-c", "echo first; sleep 0.4; echo second >&2"}},
+	}
+	res, err := cs.CallTool(ctx, params)
+	if err != nil || res.IsError {
+		t.Fatalf("call: %v %+v", err, res)
+	}
+	var out Output
+	data, _ := json.Marshal(res.StructuredContent)
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if out.Stdout != "first" || out.Stderr != "second" {
+		t.Fatalf("unexpected buffered output %+v", out)
+	}
+
+	// Notifications are delivered asynchronously.
+	want := []string{"stdout: first\n", "stderr: second\n"}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := slices.Clone(messages)
+		mu.Unlock()
+		if slices.Equal(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected progress messages %q, got %q", want, got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCompleteRunes(t *testing.T) {
+	euro := []byte("a€") // € is three bytes
+	for n, want := range []int{0, 1, 1, 1, 4} {
+		if got := completeRunes(euro[:n]); got != want {
+			t.Fatalf("completeRunes(%q) = %d, want %d", euro[:n], got, want)
+		}
+	}
+}
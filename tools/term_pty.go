@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"regexp"
@@ -14,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -266,6 +268,9 @@ var ptyRegistry = struct {
 
 func storeSession(id string, session *ptySession) {
 	ptyRegistry.Lock()
+	if _, exists := ptyRegistry.sessions[id]; !exists {
+		metrics.PTYSessionsActive.Inc()
+	}
 	ptyRegistry.sessions[id] = session
 	ptyRegistry.Unlock()
 }
@@ -281,7 +286,54 @@ func removeSession(id string, target *ptySession) {
 	defer ptyRegistry.Unlock()
 	if existing, ok := ptyRegistry.sessions[id]; ok && existing == target {
 		delete(ptyRegistry.sessions, id)
+		metrics.PTYSessionsActive.Dec()
+	}
+}
+
+// CloseAllSessions closes every registered PTY session and waits up to timeout for
+// their processes to exit. It is called on server shutdown so shells are not
+// left orphaned.
+func CloseAllSessions(timeout time.Duration) {
+	ptyRegistry.Lock()
+	sessions := make([]*ptySession, 0, len(ptyRegistry.sessions))
+	for _, session := range ptyRegistry.sessions {
+		sessions = append(sessions, session)
+	}
+	ptyRegistry.Unlock()
+
+	var wg sync.WaitGroup
+	for _, session := range sessions {
+		wg.Add(1)
+		go func(session *ptySession) {
+			defer wg.Done()
+			closePTY(session, timeout)
+		}(session)
+	}
+	wg.Wait()
+}
+
+// closePTY closes session, waits up to timeout for it to exit, and removes it
+// from the registry.
+func closePTY(session *ptySession, timeout time.Duration) {
+	_ = session.close()
+	if !session.waitForExit(timeout) {
+		slog.Warn("term_pty: session did not exit", "session", session.id, "timeout", timeout)
 	}
+	removeSession(session.id, session)
+}
+
+// reapOnSessionClose closes session once the MCP session that opened it
+// disconnects.
+func reapOnSessionClose(mcpSession *mcp.ServerSession, session *ptySession) {
+	if mcpSession == nil {
+		return
+	}
+	go func() {
+		_ = mcpSession.Wait()
+		if getSession(session.id) == session {
+			closePTY(session, 500*time.Millisecond)
+		}
+	}()
 }
 
 func ExecPTY(_ context.Context, req *mcp.CallToolRequest, input PTYInput) (*mcp.CallToolResult, PTYOutput, error) {
@@ -290,6 +342,10 @@ func ExecPTY(_ context.Context, req *mcp.CallToolRequest, input PTYInput) (*mcp.
 		return nil, PTYOutput{}, fmt.Errorf("session id is required for interactive PTYs")
 	}
 
+	if msg := checkExecRate(req, "term_pty"); msg != "" {
+		return nil, PTYOutput{SessionID: sessionID, Error: msg}, nil
+	}
+
 	session := getSession(sessionID)
 	action := normalizeAction(input.Action, session != nil, input)
 
@@ -303,6 +359,10 @@ func ExecPTY(_ context.Context, req *mcp.CallToolRequest, input PTYInput) (*mcp.
 
 	switch action {
 	case "open":
+		command, err := execPolicy.check(resolveCommand(input.Command))
+		if err != nil {
+			return nil, PTYOutput{}, err
+		}
 		if session != nil && !input.Force {
 			output.Error = "a PTY is already active; use force=true to replace it"
 			return nil, output, nil
@@ -313,7 +373,7 @@ func ExecPTY(_ context.Context, req *mcp.CallToolRequest, input PTYInput) (*mcp.
 			removeSession(sessionID, session)
 			session = nil
 		}
-		handle, startErr := startPlatformPTY(resolveCommand(input.Command), input.Args, input.Cols, input.Rows)
+		handle, startErr := startPlatformPTY(command, input.Args, input.Cols, input.Rows)
 		if startErr != nil {
 			output.Error = startErr.Error()
 			return nil, output, nil
@@ -322,6 +382,9 @@ func ExecPTY(_ context.Context, req *mcp.CallToolRequest, input PTYInput) (*mcp.
 		var created *ptySession
 		created = newPTYSession(sessionID, handle, func() { removeSession(sessionID, created) })
 		storeSession(sessionID, created)
+		if req != nil {
+			reapOnSessionClose(req.Session, created)
+		}
 		session = created
 		output.Started = true
 		awaitOutput = true
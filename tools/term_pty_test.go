@@ -3,9 +3,12 @@ package tools
 import (
 	"context"
 	"errors"
+	"io"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -104,3 +107,32 @@ func TestStripANSI(t *testing.T) {
 		t.Fatalf("stripANSI: got %q want %q", got, want)
 	}
 }
+
+func TestCloseAllSessions(t *testing.T) {
+	stdoutR, stdoutW := io.Pipe()
+	exited := make(chan struct{})
+	var once sync.Once
+	handle := &ptyHandle{
+		stdin:  stdoutW,
+		stdout: stdoutR,
+		close: func() error {
+			once.Do(func() { close(exited) })
+			return stdoutW.Close()
+		},
+		wait: func() (int, error) {
+			<-exited
+			return 0, nil
+		},
+	}
+	session := newPTYSession("shutdown-test", handle, nil)
+	storeSession("shutdown-test", session)
+
+	CloseAllSessions(time.Second)
+
+	if getSession("shutdown-test") != nil {
+		t.Fatalf("CloseAllSessions should remove closed sessions from the registry")
+	}
+	if exited, _, _ := session.status(); !exited {
+		t.Fatalf("CloseAllSessions should wait for the session to exit")
+	}
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type TransformList struct {
+	DB *surreal.Client
+}
+
+type TransformListInput struct{}
+
+type TransformListOutput struct {
+	Transforms []TransformSummary `json:"transforms" jsonschema:"PCA transforms stored in SurrealDB"`
+}
+
+type TransformSummary struct {
+	ID        string    `json:"id" jsonschema:"transform_id (vector_transform record id)"`
+	NativeDim int       `json:"nativeDim" jsonschema:"dimension of the embeddings the transform accepts"`
+	Dim       int       `json:"dim" jsonschema:"dimension of the projected vectors"`
+	Whiten    bool      `json:"whiten,omitempty" jsonschema:"whether components are scaled to unit variance"`
+	Stored    time.Time `json:"stored" jsonschema:"when the transform was stored"`
+}
+
+func (t *TransformList) List(ctx context.Context, _ *mcp.CallToolRequest, _ TransformListInput) (*mcp.CallToolResult, TransformListOutput, error) {
+	transforms := make([]TransformSummary, 0)
+	if t == nil || t.DB == nil {
+		return nil, TransformListOutput{Transforms: transforms}, fmt.Errorf("surreal client not configured")
+	}
+
+	type row struct {
+		ID        string    `json:"id"`
+		NativeDim int       `json:"native_dim"`
+		Dim       int       `json:"dim"`
+		Whiten    bool      `json:"whiten"`
+		Stored    time.Time `json:"ts"`
+	}
+
+	const q = `
+SELECT meta::id(id) AS id, native_dim, dim, whiten, ts
+FROM vector_transform
+ORDER BY id ASC
+`
+
+	rows, err := surreal.Query[row](ctx, t.DB, q, nil)
+	if err != nil {
+		return nil, TransformListOutput{Transforms: transforms}, fmt.Errorf("list transforms: %w", err)
+	}
+	for _, r := range rows {
+		transforms = append(transforms, TransformSummary(r))
+	}
+	return nil, TransformListOutput{Transforms: transforms}, nil
+}
@@ -2,10 +2,19 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
 )
 
 func clampLimit(requested int, max int) int {
@@ -18,6 +27,25 @@ func clampLimit(requested int, max int) int {
 	return requested
 }
 
+// fileOrderBy maps the sortBy/desc inputs of the file listing tools to an
+// ORDER BY clause. sortBy is path (default), size or mtime; relpath breaks
+// ties so results are stable.
+func fileOrderBy(sortBy string, desc bool) (string, error) {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	switch strings.ToLower(strings.TrimSpace(sortBy)) {
+	case "", "path", "relpath":
+		return "relpath " + dir, nil
+	case "size":
+		return "size " + dir + ", relpath ASC", nil
+	case "mtime":
+		return "mtime " + dir + ", relpath ASC", nil
+	}
+	return "", fmt.Errorf("unsupported sortBy %q (want path, size or mtime)", sortBy)
+}
+
 func lookupVectorModelID(ctx context.Context, db *surreal.Client, wsID, candidate string) (string, error) {
 	cand := strings.TrimSpace(candidate)
 	if cand == "" {
@@ -48,3 +76,137 @@ LIMIT 1
 	}
 	return rows[0].ModelID, nil
 }
+
+// vectorModelName returns the backend model name for the vector_model slug,
+// which is what the embedder must be sent to reproduce the stored vectors.
+func vectorModelName(ctx context.Context, db *surreal.Client, emb *embedder.Client, slug string) (string, error) {
+	rec, err := surreal.SelectRecord[struct {
+		Name *string `json:"name"`
+	}](ctx, db, "vector_model", slug)
+	if err != nil {
+		return "", fmt.Errorf("load vector_model %s: %w", slug, err)
+	}
+	var stored string
+	if rec != nil && rec.Name != nil {
+		stored = *rec.Name
+	}
+	return modelNameFor(stored, slug, emb.Model), nil
+}
+
+// modelNameFor picks the model name for slug. Records written before the name
+// was stored have none; the configured model's slug then maps back to it and
+// any other slug is sent as is.
+func modelNameFor(stored, slug, configured string) string {
+	if stored = strings.TrimSpace(stored); stored != "" {
+		return stored
+	}
+	if slug == embedder.ModelSlug(configured) {
+		return configured
+	}
+	return slug
+}
+
+// embedQueryVector embeds query with the model that produced the stored
+// vectors. Errors are returned rather than retried with the configured model,
+// whose vectors would not be comparable. xf projects vectors of the configured
+// model the way the indexer does.
+func embedQueryVector(ctx context.Context, emb *embedder.Client, xf *embxform.Transform, model, query string) ([]float32, error) {
+	vecs, err := emb.EmbedUsing(ctx, model, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vecs) == 0 || len(vecs[0]) == 0 {
+		return nil, fmt.Errorf("embedding returned empty vector")
+	}
+	vec := vecs[0]
+	if xf != nil && model == emb.Model {
+		if vec = xf.Apply(vec); vec == nil {
+			return nil, fmt.Errorf("transform expects native dim %d, embedder returned %d", xf.NativeDim(), len(vecs[0]))
+		}
+		if emb.Normalize {
+			embedder.L2Normalize(vec)
+		}
+	}
+	return vec, nil
+}
+
+// timeRangeFilter returns SurrealQL conditions restricting field to the
+// [after, before] range and adds the bound values to params. Nil bounds are
+// left open, so two nil bounds produce no filter.
+func timeRangeFilter(field string, after, before *time.Time, params map[string]any) (string, error) {
+	if after != nil && before != nil && after.After(*before) {
+		return "", fmt.Errorf("modifiedAfter must not be later than modifiedBefore")
+	}
+	var filter string
+	if after != nil {
+		filter += "\n  AND " + field + " >= type::datetime($modified_after)"
+		params["modified_after"] = after.UTC().Format(time.RFC3339Nano)
+	}
+	if before != nil {
+		filter += "\n  AND " + field + " <= type::datetime($modified_before)"
+		params["modified_before"] = before.UTC().Format(time.RFC3339Nano)
+	}
+	return filter, nil
+}
+
+// alignRunes moves byte offsets start and end of data off continuation bytes,
+// start forward and end back, so data[start:end] holds only whole UTF-8
+// characters. Offsets must already lie within data.
+func alignRunes(data []byte, start, end int) (int, int) {
+	for start < end && !utf8.RuneStart(data[start]) {
+		start++
+	}
+	for end > start && end < len(data) && !utf8.RuneStart(data[end]) {
+		end--
+	}
+	return start, end
+}
+
+// readIndexedText reads the file at path decoded the way the embedder chunked
+// it, so stored chunk offsets index into the result even for files with a BOM
+// or in UTF-16 or Latin-1. Content that is not decodable text is returned as
+// read.
+func readIndexedText(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if text, err := indexer.DecodeText(data); err == nil {
+		return text, nil
+	}
+	return data, nil
+}
+
+// errOutsideWorkspace is returned for paths that leave the workspace root.
+var errOutsideWorkspace = errors.New("relPath must stay inside the workspace")
+
+// checkParentInWorkspace fails unless the directory holding full lies inside
+// the workspace at wsPath once symlinks are resolved, so a symlinked directory
+// inside the workspace cannot carry a write or delete outside it. A directory
+// that does not exist yet is checked through its deepest existing ancestor,
+// since anything created below that stays where the ancestor is.
+func checkParentInWorkspace(wsPath, full string) error {
+	root, err := filepath.EvalSymlinks(wsPath)
+	if err != nil {
+		return fmt.Errorf("resolve workspace path: %w", err)
+	}
+	dir := filepath.Dir(full)
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			rel, err := filepath.Rel(root, resolved)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return errOutsideWorkspace
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("resolve parent directory: %w", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return errOutsideWorkspace
+		}
+		dir = parent
+	}
+}
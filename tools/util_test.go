@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
+)
+
+func TestTimeRangeFilter(t *testing.T) {
+	params := map[string]any{}
+	filter, err := timeRangeFilter("mtime", nil, nil, params)
+	if err != nil || filter != "" || len(params) != 0 {
+		t.Fatalf("nil bounds should produce no filter, got %q %v %v", filter, params, err)
+	}
+
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := after.Add(24 * time.Hour)
+	filter, err = timeRangeFilter("mtime", &after, &before, params)
+	if err != nil {
+		t.Fatalf("timeRangeFilter: %v", err)
+	}
+	if !strings.Contains(filter, "mtime >= type::datetime($modified_after)") || !strings.Contains(filter, "mtime <= type::datetime($modified_before)") {
+		t.Fatalf("unexpected filter %q", filter)
+	}
+	if params["modified_after"] != "2025-01-01T00:00:00Z" {
+		t.Fatalf("unexpected modified_after %v", params["modified_after"])
+	}
+
+	if _, err := timeRangeFilter("mtime", &before, &after, map[string]any{}); err == nil {
+		t.Fatalf("expected error for inverted range")
+	}
+}
+
+func TestFileOrderBy(t *testing.T) {
+	cases := []struct {
+		sortBy string
+		desc   bool
+		want   string
+	}{
+		{"", false, "relpath ASC"},
+		{"path", true, "relpath DESC"},
+		{"size", true, "size DESC, relpath ASC"},
+		{"MTime", false, "mtime ASC, relpath ASC"},
+	}
+	for _, tc := range cases {
+		got, err := fileOrderBy(tc.sortBy, tc.desc)
+		if err != nil {
+			t.Fatalf("fileOrderBy(%q, %v): %v", tc.sortBy, tc.desc, err)
+		}
+		if got != tc.want {
+			t.Fatalf("fileOrderBy(%q, %v) = %q, want %q", tc.sortBy, tc.desc, got, tc.want)
+		}
+	}
+	if _, err := fileOrderBy("sha; DELETE file", false); err == nil {
+		t.Fatalf("expected error for unsupported sort key")
+	}
+}
+
+func TestReadIndexedTextMatchesChunkOffsets(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name string
+		raw  []byte
+	}{
+		{"bom.txt", []byte("\xEF\xBB\xBFfirst\nsecond\n")},
+		{"utf16.txt", []byte("\xFF\xFEf\x00i\x00r\x00s\x00t\x00\n\x00s\x00e\x00c\x00o\x00n\x00d\x00\n\x00")},
+		{"latin1.txt", []byte("first\nsecond caf\xE9\n")},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, tc.raw, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := readIndexedText(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		// The indexer chunks "second" at offsets 6-12 of the decoded text.
+		if got := string(data[6:12]); got != "second" {
+			t.Fatalf("%s: decoded offsets 6-12 = %q, want second", tc.name, got)
+		}
+	}
+}
+
+func TestModelNameFor(t *testing.T) {
+	cases := []struct {
+		stored, slug, configured, want string
+	}{
+		{"nomic-embed-text-v1.5", embedder.ModelSlug("nomic-embed-text-v1.5"), "other", "nomic-embed-text-v1.5"},
+		{"", embedder.ModelSlug("text-embedding-3-small"), "text-embedding-3-small", "text-embedding-3-small"},
+		{"", "legacy_slug", "text-embedding-3-small", "legacy_slug"},
+	}
+	for _, tc := range cases {
+		if got := modelNameFor(tc.stored, tc.slug, tc.configured); got != tc.want {
+			t.Errorf("modelNameFor(%q, %q, %q) = %q, want %q", tc.stored, tc.slug, tc.configured, got, tc.want)
+		}
+	}
+}
+
+func TestEmbedQueryVectorReturnsBackendError(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		models = append(models, string(body))
+		if strings.Contains(string(body), `"model":"code-model"`) {
+			http.Error(w, "model not loaded", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+	c := embedder.New(srv.URL, "configured", embedder.DefaultTimeout)
+	c.Logger = slog.New(slog.DiscardHandler)
+
+	if _, err := embedQueryVector(context.Background(), c, nil, "code-model", "q"); err == nil {
+		t.Fatal("expected the backend error, got a vector")
+	}
+	if len(models) != 1 {
+		t.Fatalf("expected no retry with the configured model, got requests %v", models)
+	}
+	vec, err := embedQueryVector(context.Background(), c, nil, "configured", "q")
+	if err != nil || len(vec) != 2 {
+		t.Fatalf("embed configured model: %v %v", vec, err)
+	}
+
+	xf := &embxform.Transform{Mean: []float32{0, 0}, Components: [][]float32{{2}, {0}}, Dim: 1}
+	if vec, err = embedQueryVector(context.Background(), c, xf, "configured", "q"); err != nil || len(vec) != 1 || vec[0] != 2 {
+		t.Fatalf("expected the projected query [2], got %v %v", vec, err)
+	}
+}
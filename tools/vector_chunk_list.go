@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type VectorChunkList struct {
+	DB *surreal.Client
+}
+
+type VectorChunkListInput struct {
+	WorkspaceID   string `json:"workspaceId" jsonschema:"workspace identifier"`
+	RelPath       string `json:"relPath" jsonschema:"file path relative to workspace root"`
+	ModelID       string `json:"modelId,omitempty" jsonschema:"only chunks of this vector model (slug, name or id)"`
+	IncludeVector bool   `json:"includeVector,omitempty" jsonschema:"include each chunk's stored vector"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"maximum chunks per page (default and max 500)"`
+	Cursor        string `json:"cursor,omitempty" jsonschema:"opaque cursor from a previous nextCursor to fetch the following page"`
+}
+
+type VectorChunkListOutput struct {
+	Chunks     []VectorChunkSummary `json:"chunks" jsonschema:"stored chunks ordered by chunk index"`
+	NextCursor string               `json:"nextCursor,omitempty" jsonschema:"cursor for the next page; empty when there are no more chunks"`
+}
+
+type VectorChunkSummary struct {
+	ChunkIndex int       `json:"chunkIndex" jsonschema:"position of the chunk within the file"`
+	Start      int       `json:"start" jsonschema:"chunk start byte offset"`
+	End        int       `json:"end" jsonschema:"chunk end byte offset (exclusive)"`
+	TokenCount int       `json:"tokenCount" jsonschema:"tokens in the chunk"`
+	ContentSHA string    `json:"contentSha" jsonschema:"hash of the chunk text"`
+	ModelID    string    `json:"modelId" jsonschema:"vector model id"`
+	Vector     []float32 `json:"vector,omitempty" jsonschema:"stored vector, when includeVector is set"`
+}
+
+func (v *VectorChunkList) List(ctx context.Context, _ *mcp.CallToolRequest, input VectorChunkListInput) (*mcp.CallToolResult, VectorChunkListOutput, error) {
+	chunks := make([]VectorChunkSummary, 0)
+	if v == nil || v.DB == nil {
+		return nil, VectorChunkListOutput{Chunks: chunks}, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, VectorChunkListOutput{Chunks: chunks}, fmt.Errorf("workspaceId is required")
+	}
+	rel := strings.TrimSpace(input.RelPath)
+	if rel == "" {
+		return nil, VectorChunkListOutput{Chunks: chunks}, fmt.Errorf("relPath is required")
+	}
+
+	var after *chunkListCursor
+	if strings.TrimSpace(input.Cursor) != "" {
+		c, err := decodeChunkListCursor(input.Cursor)
+		if err != nil {
+			return nil, VectorChunkListOutput{Chunks: chunks}, err
+		}
+		after = c
+	}
+	limit := clampLimit(input.Limit, maxChunkListLimit)
+
+	fileID, err := lookupFileRecordID(ctx, v.DB, wsID, rel)
+	if err != nil {
+		return nil, VectorChunkListOutput{Chunks: chunks}, err
+	}
+	modelID := ""
+	if input.ModelID != "" {
+		id, err := lookupVectorModelID(ctx, v.DB, wsID, input.ModelID)
+		if err != nil {
+			return nil, VectorChunkListOutput{Chunks: chunks}, err
+		}
+		modelID = strings.TrimPrefix(id, "vector_model:")
+	}
+	q, vars := chunkListQuery(fileID, modelID, input.IncludeVector, after, limit)
+
+	type row struct {
+		ChunkIndex int       `json:"chunk_index"`
+		Start      int       `json:"start"`
+		End        int       `json:"end"`
+		TokenCount int       `json:"token_count"`
+		ContentSHA string    `json:"content_sha"`
+		ModelID    string    `json:"model_id"`
+		Vector     []float32 `json:"vector"`
+	}
+	rows, err := surreal.Query[row](ctx, v.DB, q, vars)
+	if err != nil {
+		return nil, VectorChunkListOutput{Chunks: chunks}, fmt.Errorf("list vector chunks: %w", err)
+	}
+	for _, r := range rows {
+		chunks = append(chunks, VectorChunkSummary(r))
+	}
+	chunks, next := pageChunks(chunks, limit)
+	return nil, VectorChunkListOutput{Chunks: chunks, NextCursor: next}, nil
+}
+
+// maxChunkListLimit caps, and is the default for, VectorChunkListInput.Limit.
+const maxChunkListLimit = 500
+
+// chunkListQuery builds the query for the chunks of fileID, only those of
+// modelID when it is set, that sort after the cursor. It fetches one row more
+// than limit so pageChunks can tell whether another page follows.
+func chunkListQuery(fileID, modelID string, includeVector bool, after *chunkListCursor, limit int) (string, map[string]any) {
+	vars := map[string]any{"file_id": fileID, "limit": limit + 1}
+	filter := ""
+	if modelID != "" {
+		vars["model_id"] = modelID
+		filter += "\n  AND model = type::thing('vector_model', $model_id)"
+	}
+	if after != nil {
+		vars["after_index"] = after.Index
+		vars["after_model"] = after.Model
+		filter += "\n  AND (chunk_index > $after_index OR (chunk_index = $after_index AND meta::id(model) > $after_model))"
+	}
+	fields := "chunk_index, start, end, token_count, content_sha, meta::id(model) AS model_id"
+	if includeVector {
+		fields += ", vector"
+	}
+	q := fmt.Sprintf(`
+SELECT %s
+FROM vector_chunk
+WHERE file = type::thing('file', $file_id)%s
+ORDER BY chunk_index ASC, model_id ASC
+LIMIT $limit
+`, fields, filter)
+	return q, vars
+}
+
+// pageChunks trims chunks to limit and returns the cursor for the next page,
+// or "" when chunks held no more than limit.
+func pageChunks(chunks []VectorChunkSummary, limit int) ([]VectorChunkSummary, string) {
+	if len(chunks) <= limit {
+		return chunks, ""
+	}
+	chunks = chunks[:limit]
+	last := chunks[len(chunks)-1]
+	raw, _ := json.Marshal(chunkListCursor{Index: last.ChunkIndex, Model: last.ModelID})
+	return chunks, base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// chunkListCursor marks the last chunk returned on a page.
+type chunkListCursor struct {
+	Index int    `json:"i"`
+	Model string `json:"m"`
+}
+
+func decodeChunkListCursor(cursor string) (*chunkListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(cursor))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c chunkListCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if c.Model == "" {
+		return nil, fmt.Errorf("invalid cursor: missing position")
+	}
+	return &c, nil
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+)
+
+func TestChunkListQueryFilters(t *testing.T) {
+	q, vars := chunkListQuery("file-abc", "", false, nil, 10)
+	if !strings.Contains(q, "WHERE file = type::thing('file', $file_id)") || vars["file_id"] != "file-abc" {
+		t.Fatalf("query not restricted to the file: %s %v", q, vars)
+	}
+	if strings.Contains(q, "$model_id") || strings.Contains(q, "$after_index") || strings.Contains(q, ", vector") {
+		t.Fatalf("unexpected optional clauses: %s", q)
+	}
+	if vars["limit"] != 11 {
+		t.Fatalf("expected one extra row to detect another page, got limit %v", vars["limit"])
+	}
+
+	q, vars = chunkListQuery("file-abc", "nomic", true, &chunkListCursor{Index: 4, Model: "nomic"}, 10)
+	if !strings.Contains(q, "AND model = type::thing('vector_model', $model_id)") || vars["model_id"] != "nomic" {
+		t.Fatalf("query not restricted to the model: %s %v", q, vars)
+	}
+	if !strings.Contains(q, "chunk_index > $after_index") || vars["after_index"] != 4 || vars["after_model"] != "nomic" {
+		t.Fatalf("query does not resume after the cursor: %s %v", q, vars)
+	}
+	if !strings.Contains(q, ", vector") {
+		t.Fatalf("includeVector did not select the vector: %s", q)
+	}
+}
+
+func TestPageChunks(t *testing.T) {
+	rows := []VectorChunkSummary{
+		{ChunkIndex: 0, ModelID: "a"},
+		{ChunkIndex: 0, ModelID: "b"},
+		{ChunkIndex: 1, ModelID: "a"},
+	}
+	page, next := pageChunks(rows, 3)
+	if len(page) != 3 || next != "" {
+		t.Fatalf("last page: got %d chunks and cursor %q", len(page), next)
+	}
+
+	page, next = pageChunks(rows, 2)
+	if len(page) != 2 || next == "" {
+		t.Fatalf("first page: got %d chunks and cursor %q", len(page), next)
+	}
+	c, err := decodeChunkListCursor(next)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	if c.Index != 0 || c.Model != "b" {
+		t.Fatalf("cursor should point at the last chunk returned, got %+v", c)
+	}
+	if _, err := decodeChunkListCursor("not a cursor"); err == nil {
+		t.Fatalf("expected an invalid cursor error")
+	}
+}
+
+func TestVectorChunkListRequiresWorkspaceAndFile(t *testing.T) {
+	v := &VectorChunkList{DB: &surreal.Client{}}
+	cases := []struct {
+		input VectorChunkListInput
+		want  string
+	}{
+		{VectorChunkListInput{RelPath: "main.go"}, "workspaceId is required"},
+		{VectorChunkListInput{WorkspaceID: "ws"}, "relPath is required"},
+		{VectorChunkListInput{WorkspaceID: "ws", RelPath: "main.go", Cursor: "%%"}, "invalid cursor"},
+	}
+	for _, tc := range cases {
+		_, _, err := v.List(context.Background(), nil, tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("List(%+v) error = %v, want %q", tc.input, err, tc.want)
+		}
+	}
+}
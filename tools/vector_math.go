@@ -0,0 +1,105 @@
+package tools
+
+import "math"
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when
+// either vector is empty, zero, or the dimensions differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// mmrOrder reranks candidates with Maximal Marginal Relevance. relevance holds
+// each candidate's similarity to the query. At each step it picks the candidate
+// maximising lambda*relevance - (1-lambda)*max similarity to those already
+// picked. It returns candidate indices in selection order with their MMR scores.
+func mmrOrder(vectors [][]float32, relevance []float64, lambda float64) ([]int, []float64) {
+	n := len(vectors)
+	order := make([]int, 0, n)
+	scores := make([]float64, 0, n)
+	picked := make([]bool, n)
+	// maxSim[i] tracks the highest similarity between i and any picked candidate.
+	maxSim := make([]float64, n)
+
+	for len(order) < n {
+		best, bestScore := -1, math.Inf(-1)
+		for i := 0; i < n; i++ {
+			if picked[i] {
+				continue
+			}
+			penalty := 0.0
+			if len(order) > 0 {
+				penalty = maxSim[i]
+			}
+			score := lambda*relevance[i] - (1-lambda)*penalty
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		picked[best] = true
+		order = append(order, best)
+		scores = append(scores, bestScore)
+		for i := 0; i < n; i++ {
+			if picked[i] {
+				continue
+			}
+			if sim := cosineSimilarity(vectors[i], vectors[best]); len(order) == 1 || sim > maxSim[i] {
+				maxSim[i] = sim
+			}
+		}
+	}
+	return order, scores
+}
+
+// dedupeBySimilarity walks vectors in ranked order and keeps each one whose
+// cosine similarity to every vector already kept is at most threshold. It
+// returns the indices kept, in order.
+func dedupeBySimilarity(vectors [][]float32, threshold float64) []int {
+	kept := make([]int, 0, len(vectors))
+	for i, v := range vectors {
+		duplicate := false
+		for _, j := range kept {
+			if cosineSimilarity(v, vectors[j]) > threshold {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// maxSimilarity returns the highest cosine similarity between v and any of
+// others, or 0 when others is empty.
+func maxSimilarity(v []float32, others [][]float32) float64 {
+	best := 0.0
+	for i, o := range others {
+		if sim := cosineSimilarity(v, o); i == 0 || sim > best {
+			best = sim
+		}
+	}
+	return best
+}
+
+// l2Norm returns the Euclidean length of v.
+func l2Norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
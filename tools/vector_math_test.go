@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float32{1, 0}, []float32{1, 0}); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("identical vectors: got %v", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{0, 1}); math.Abs(got) > 1e-9 {
+		t.Fatalf("orthogonal vectors: got %v", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{1}); got != 0 {
+		t.Fatalf("mismatched dims: got %v", got)
+	}
+}
+
+func TestMMROrderPrefersDiversity(t *testing.T) {
+	// Two near-identical top candidates and one distinct, slightly less relevant one.
+	vectors := [][]float32{{1, 0}, {0.99, 0.01}, {0, 1}}
+	relevance := []float64{0.95, 0.94, 0.90}
+
+	order, scores := mmrOrder(vectors, relevance, 0.5)
+	if len(order) != 3 || len(scores) != 3 {
+		t.Fatalf("expected all candidates ranked, got %v", order)
+	}
+	if order[0] != 0 || order[1] != 2 {
+		t.Fatalf("expected diverse candidate second, got order %v", order)
+	}
+
+	order, _ = mmrOrder(vectors, relevance, 1)
+	if order[0] != 0 || order[1] != 1 || order[2] != 2 {
+		t.Fatalf("lambda=1 should keep relevance order, got %v", order)
+	}
+}
+
+func TestDedupeBySimilarity(t *testing.T) {
+	vectors := [][]float32{{1, 0}, {0.99, 0.05}, {0, 1}, {0.02, 1}}
+	kept := dedupeBySimilarity(vectors, 0.95)
+	if len(kept) != 2 || kept[0] != 0 || kept[1] != 2 {
+		t.Fatalf("expected [0 2], got %v", kept)
+	}
+	if kept := dedupeBySimilarity(vectors, 1); len(kept) != len(vectors) {
+		t.Fatalf("threshold 1 should keep everything, got %v", kept)
+	}
+}
+
+func TestMaxSimilarity(t *testing.T) {
+	negatives := [][]float32{{0, 1}, {1, 1}}
+	if got := maxSimilarity([]float32{1, 0}, negatives); math.Abs(got-1/math.Sqrt2) > 1e-6 {
+		t.Fatalf("expected 1/sqrt(2), got %v", got)
+	}
+	if got := maxSimilarity([]float32{1, 0}, nil); got != 0 {
+		t.Fatalf("no negatives: got %v", got)
+	}
+}
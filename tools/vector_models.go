@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type VectorModels struct {
+	DB *surreal.Client
+}
+
+type VectorModelListInput struct{}
+
+type VectorModelListOutput struct {
+	Models []VectorModelSummary `json:"models" jsonschema:"registered vector models"`
+}
+
+type VectorModelSummary struct {
+	ID        string `json:"id" jsonschema:"vector_model record id"`
+	Slug      string `json:"slug" jsonschema:"model slug"`
+	Family    string `json:"family,omitempty" jsonschema:"model family"`
+	Version   string `json:"version,omitempty" jsonschema:"model version"`
+	NativeDim int    `json:"nativeDim" jsonschema:"raw output dimension"`
+	Chunks    int    `json:"chunks" jsonschema:"number of vector_chunk rows referencing the model"`
+}
+
+type VectorModelDeleteInput struct {
+	ModelID string `json:"modelId" jsonschema:"vector model slug or vector_model:<slug> record id"`
+	Force   bool   `json:"force,omitempty" jsonschema:"when true, also delete vector chunks and workspace vectors that reference the model"`
+}
+
+type VectorModelDeleteOutput struct {
+	Model         string `json:"model" jsonschema:"deleted model id"`
+	DeletedChunks int    `json:"deletedChunks" jsonschema:"number of vector_chunk rows removed with the model"`
+}
+
+func (v *VectorModels) List(ctx context.Context, _ *mcp.CallToolRequest, _ VectorModelListInput) (*mcp.CallToolResult, VectorModelListOutput, error) {
+	models := make([]VectorModelSummary, 0)
+	if v == nil || v.DB == nil {
+		return nil, VectorModelListOutput{Models: models}, fmt.Errorf("surreal client not configured")
+	}
+
+	type row struct {
+		ID        string `json:"id"`
+		Slug      string `json:"id_slug"`
+		Family    string `json:"family"`
+		Version   string `json:"version"`
+		NativeDim int    `json:"native_dim"`
+		Chunks    int    `json:"chunks"`
+	}
+
+	const q = `
+SELECT meta::id(id) AS id, id_slug, family, version, native_dim,
+       (SELECT count() AS count FROM vector_chunk WHERE model = $parent.id GROUP ALL)[0].count ?? 0 AS chunks
+FROM vector_model
+ORDER BY id_slug ASC
+`
+
+	rows, err := surreal.Query[row](ctx, v.DB, q, nil)
+	if err != nil {
+		return nil, VectorModelListOutput{Models: models}, fmt.Errorf("list vector models: %w", err)
+	}
+	for _, r := range rows {
+		models = append(models, VectorModelSummary(r))
+	}
+	return nil, VectorModelListOutput{Models: models}, nil
+}
+
+func (v *VectorModels) Delete(ctx context.Context, _ *mcp.CallToolRequest, input VectorModelDeleteInput) (*mcp.CallToolResult, VectorModelDeleteOutput, error) {
+	if v == nil || v.DB == nil {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("surreal client not configured")
+	}
+	modelID := strings.TrimPrefix(strings.TrimSpace(input.ModelID), "vector_model:")
+	if modelID == "" {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("modelId is required")
+	}
+	vars := map[string]any{"model_id": modelID}
+
+	type idRow struct {
+		ID string `json:"id"`
+	}
+	const modelQuery = `
+SELECT meta::id(id) AS id FROM type::thing('vector_model', $model_id)
+`
+	found, err := surreal.Query[idRow](ctx, v.DB, modelQuery, vars)
+	if err != nil {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("lookup vector model: %w", err)
+	}
+	if len(found) == 0 || strings.TrimSpace(found[0].ID) == "" {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("vector model %s not found", modelID)
+	}
+
+	type countRow struct {
+		Count int `json:"count"`
+	}
+	const countQuery = `
+SELECT count() AS count FROM vector_chunk
+WHERE model = type::thing('vector_model', $model_id)
+GROUP ALL
+`
+	counts, err := surreal.Query[countRow](ctx, v.DB, countQuery, vars)
+	if err != nil {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("count model chunks: %w", err)
+	}
+	chunks := 0
+	if len(counts) > 0 {
+		chunks = counts[0].Count
+	}
+	if chunks > 0 && !input.Force {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("vector model %s is referenced by %d vector chunks; set force=true to delete them", modelID, chunks)
+	}
+
+	const deleteQuery = `
+BEGIN TRANSACTION;
+DELETE vector_chunk WHERE model = type::thing('vector_model', $model_id);
+DELETE workspace_vector WHERE model = type::thing('vector_model', $model_id);
+DELETE type::thing('vector_model', $model_id);
+COMMIT TRANSACTION;
+`
+	if _, err := surreal.Query[any](ctx, v.DB, deleteQuery, vars); err != nil {
+		return nil, VectorModelDeleteOutput{}, fmt.Errorf("delete vector model: %w", err)
+	}
+
+	return nil, VectorModelDeleteOutput{Model: modelID, DeletedChunks: chunks}, nil
+}
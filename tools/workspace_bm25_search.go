@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type WorkspaceBM25Search struct {
+	DB *surreal.Client
+}
+
+type WorkspaceBM25SearchInput struct {
+	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
+	Query       string `json:"query" jsonschema:"keywords to match against file content"`
+	TopK        int    `json:"topK,omitempty" jsonschema:"number of results (default 10, max 100)"`
+}
+
+type WorkspaceBM25SearchOutput struct {
+	Matches []BM25Match `json:"matches" jsonschema:"files ranked by BM25 score"`
+}
+
+type BM25Match struct {
+	File    string  `json:"file" jsonschema:"file relpath"`
+	Score   float64 `json:"score" jsonschema:"BM25 relevance score"`
+	Snippet string  `json:"snippet" jsonschema:"content around the first matching term"`
+}
+
+// bm25SnippetRadius is the number of bytes kept on each side of the first hit.
+const bm25SnippetRadius = 160
+
+func (s *WorkspaceBM25Search) Search(ctx context.Context, _ *mcp.CallToolRequest, input WorkspaceBM25SearchInput) (*mcp.CallToolResult, WorkspaceBM25SearchOutput, error) {
+	metrics.SearchRequests.WithLabelValues("workspace_bm25_search").Inc()
+	matches := make([]BM25Match, 0)
+	if s == nil || s.DB == nil {
+		return nil, WorkspaceBM25SearchOutput{Matches: matches}, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, WorkspaceBM25SearchOutput{Matches: matches}, fmt.Errorf("workspaceId is required")
+	}
+	query := strings.TrimSpace(input.Query)
+	if query == "" {
+		return nil, WorkspaceBM25SearchOutput{Matches: matches}, fmt.Errorf("query is required")
+	}
+	topK := clampLimit(input.TopK, 100)
+	if input.TopK <= 0 {
+		topK = 10
+	}
+
+	type row struct {
+		RelPath string  `json:"relpath"`
+		Score   float64 `json:"score"`
+		Content string  `json:"content"`
+	}
+	const q = `
+SELECT relpath, content, search::score(1) AS score
+FROM file
+WHERE ws = type::thing('workspace', $ws_id)
+  AND content @1@ $query
+ORDER BY score DESC
+LIMIT $k
+`
+	rows, err := surreal.Query[row](ctx, s.DB, q, map[string]any{
+		"ws_id": wsID,
+		"query": query,
+		"k":     topK,
+	})
+	if err != nil {
+		return nil, WorkspaceBM25SearchOutput{Matches: matches}, fmt.Errorf("bm25 query: %w", err)
+	}
+	for _, r := range rows {
+		matches = append(matches, BM25Match{
+			File:    r.RelPath,
+			Score:   r.Score,
+			Snippet: bm25Snippet(r.Content, query),
+		})
+	}
+	return nil, WorkspaceBM25SearchOutput{Matches: matches}, nil
+}
+
+// bm25Snippet returns the content surrounding the earliest case-insensitive
+// occurrence of any query term, or the start of the content if none is found.
+func bm25Snippet(content, query string) string {
+	lower := strings.ToLower(content)
+	hit := -1
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if i := strings.Index(lower, term); i >= 0 && (hit < 0 || i < hit) {
+			hit = i
+		}
+	}
+	if hit < 0 {
+		hit = 0
+	}
+	return sliceSnippet([]byte(content), hit-bm25SnippetRadius, hit+bm25SnippetRadius)
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DeleteWorkspaceFile removes indexed files from a workspace. Unless asked to
+// delete permanently, files are moved under TrashDir so they can be restored.
+type DeleteWorkspaceFile struct {
+	DB       *surreal.Client
+	TrashDir string
+}
+
+type DeleteWorkspaceFileInput struct {
+	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
+	RelPath     string `json:"relPath" jsonschema:"file path relative to workspace root"`
+	Permanent   bool   `json:"permanent,omitempty" jsonschema:"delete the file instead of moving it to the trash"`
+}
+
+type DeleteWorkspaceFileOutput struct {
+	Deleted   bool   `json:"deleted" jsonschema:"true once the file and its records are removed"`
+	TrashedTo string `json:"trashedTo" jsonschema:"where the file was moved; empty for permanent deletes or when the file was already gone from disk"`
+}
+
+type TrashEmptyInput struct{}
+
+type TrashEmptyOutput struct {
+	Removed int `json:"removed" jsonschema:"number of trashed entries removed"`
+}
+
+func (d *DeleteWorkspaceFile) Delete(ctx context.Context, _ *mcp.CallToolRequest, input DeleteWorkspaceFileInput) (*mcp.CallToolResult, DeleteWorkspaceFileOutput, error) {
+	var out DeleteWorkspaceFileOutput
+	if d == nil || d.DB == nil {
+		return nil, out, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, out, fmt.Errorf("workspaceId is required")
+	}
+	rel := strings.TrimSpace(input.RelPath)
+	if rel == "" {
+		return nil, out, fmt.Errorf("relPath is required")
+	}
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return nil, out, errOutsideWorkspace
+	}
+	if !input.Permanent && strings.TrimSpace(d.TrashDir) == "" {
+		return nil, out, fmt.Errorf("trash directory not configured")
+	}
+
+	wsPath, err := lookupWorkspacePath(ctx, d.DB, wsID)
+	if err != nil {
+		return nil, out, err
+	}
+	fileID, err := lookupFileRecordID(ctx, d.DB, wsID, rel)
+	if err != nil {
+		return nil, out, err
+	}
+	full := filepath.Join(wsPath, filepath.FromSlash(rel))
+	// Checked before both the remove and the move to the trash.
+	if err := checkParentInWorkspace(wsPath, full); err != nil {
+		return nil, out, err
+	}
+
+	// A file already removed from disk still has its records cleaned up.
+	switch info, err := os.Lstat(full); {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, out, fmt.Errorf("stat file: %w", err)
+	case info.IsDir():
+		return nil, out, fmt.Errorf("%s is a directory", rel)
+	case input.Permanent:
+		if err := os.Remove(full); err != nil {
+			return nil, out, fmt.Errorf("remove file: %w", err)
+		}
+	default:
+		dest := trashPath(d.TrashDir, rel, time.Now().UTC())
+		if err := moveFile(full, dest); err != nil {
+			return nil, out, fmt.Errorf("move file to trash: %w", err)
+		}
+		out.TrashedTo = dest
+	}
+
+	const deleteQuery = `
+BEGIN TRANSACTION;
+LET $file = type::thing('file', $file_id);
+DELETE file_has_vector WHERE in = $file;
+DELETE dir_contains_file WHERE out = $file;
+DELETE vector_chunk WHERE file = $file;
+DELETE $file;
+COMMIT TRANSACTION;
+`
+	if _, err := surreal.Query[any](ctx, d.DB, deleteQuery, map[string]any{"file_id": fileID}); err != nil {
+		return nil, out, fmt.Errorf("delete file records: %w", err)
+	}
+	out.Deleted = true
+	return nil, out, nil
+}
+
+// Empty removes everything in the trash directory.
+func (d *DeleteWorkspaceFile) Empty(ctx context.Context, _ *mcp.CallToolRequest, _ TrashEmptyInput) (*mcp.CallToolResult, TrashEmptyOutput, error) {
+	var out TrashEmptyOutput
+	if d == nil || strings.TrimSpace(d.TrashDir) == "" {
+		return nil, out, fmt.Errorf("trash directory not configured")
+	}
+	entries, err := os.ReadDir(d.TrashDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, out, nil
+	}
+	if err != nil {
+		return nil, out, fmt.Errorf("read trash: %w", err)
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, out, err
+		}
+		if err := os.RemoveAll(filepath.Join(d.TrashDir, e.Name())); err != nil {
+			return nil, out, fmt.Errorf("remove %s: %w", e.Name(), err)
+		}
+		out.Removed++
+	}
+	return nil, out, nil
+}
+
+// trashPath returns where rel is kept in the trash when deleted at ts. The
+// timestamp prefixes the first path element so repeated deletes of the same
+// file do not collide.
+func trashPath(trashDir, rel string, ts time.Time) string {
+	return filepath.Join(trashDir, ts.Format("20060102T150405.000000000Z")+"_"+filepath.FromSlash(rel))
+}
+
+// moveFile renames src to dst, copying across filesystems when the rename
+// cannot be done in place.
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	outFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, in); err != nil {
+		outFile.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := outFile.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashPathPrefixesTimestamp(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 42, time.UTC)
+	got := trashPath("/trash", "src/main.go", ts)
+	want := filepath.Join("/trash", "20240501T123000.000000042Z_src", "main.go")
+	if got != want {
+		t.Fatalf("trashPath = %q, want %q", got, want)
+	}
+}
+
+func TestTrashEmptyRemovesEntries(t *testing.T) {
+	dir := t.TempDir()
+	trash := filepath.Join(dir, "trash")
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("a"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := moveFile(src, trashPath(trash, "nested/a.txt", time.Now())); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("expected source to be gone, got %v", err)
+	}
+
+	d := &DeleteWorkspaceFile{TrashDir: trash}
+	_, out, err := d.Empty(context.Background(), nil, TrashEmptyInput{})
+	if err != nil {
+		t.Fatalf("empty: %v", err)
+	}
+	if out.Removed != 1 {
+		t.Fatalf("expected 1 removed entry, got %d", out.Removed)
+	}
+	entries, err := os.ReadDir(trash)
+	if err != nil {
+		t.Fatalf("read trash: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty trash, found %d entries", len(entries))
+	}
+}
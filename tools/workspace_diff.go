@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type WorkspaceDiff struct {
+	DB *surreal.Client
+}
+
+type WorkspaceDiffInput struct {
+	WorkspaceIDA string `json:"workspaceIdA" jsonschema:"first workspace identifier"`
+	WorkspaceIDB string `json:"workspaceIdB" jsonschema:"second workspace identifier"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"maximum entries per list (default and max 1000); counts always cover every file"`
+}
+
+type WorkspaceDiffOutput struct {
+	OnlyInA        []FindFileResult `json:"onlyInA" jsonschema:"files indexed in A but not in B"`
+	OnlyInB        []FindFileResult `json:"onlyInB" jsonschema:"files indexed in B but not in A"`
+	Changed        []ChangedFile    `json:"changed" jsonschema:"files in both workspaces whose hashes differ"`
+	TotalA         int              `json:"totalA" jsonschema:"number of files indexed in A"`
+	TotalB         int              `json:"totalB" jsonschema:"number of files indexed in B"`
+	OnlyInACount   int              `json:"onlyInACount" jsonschema:"number of files only in A"`
+	OnlyInBCount   int              `json:"onlyInBCount" jsonschema:"number of files only in B"`
+	ChangedCount   int              `json:"changedCount" jsonschema:"number of files whose hashes differ"`
+	UnchangedCount int              `json:"unchangedCount" jsonschema:"number of files with matching hashes"`
+	Truncated      bool             `json:"truncated" jsonschema:"true if any list was cut at limit"`
+}
+
+type ChangedFile struct {
+	RelPath string `json:"relpath" jsonschema:"path relative to workspace root"`
+	ShaA    string `json:"shaA" jsonschema:"content hash in workspace A"`
+	ShaB    string `json:"shaB" jsonschema:"content hash in workspace B"`
+}
+
+// Diff compares the indexed files of two workspaces by relpath and content
+// hash. It reflects the last scan of each workspace, not the files on disk.
+func (d *WorkspaceDiff) Diff(ctx context.Context, _ *mcp.CallToolRequest, input WorkspaceDiffInput) (*mcp.CallToolResult, WorkspaceDiffOutput, error) {
+	out := WorkspaceDiffOutput{
+		OnlyInA: []FindFileResult{},
+		OnlyInB: []FindFileResult{},
+		Changed: []ChangedFile{},
+	}
+	if d == nil || d.DB == nil {
+		return nil, out, fmt.Errorf("surreal client not configured")
+	}
+	wsA := strings.TrimSpace(input.WorkspaceIDA)
+	wsB := strings.TrimSpace(input.WorkspaceIDB)
+	if wsA == "" || wsB == "" {
+		return nil, out, fmt.Errorf("workspaceIdA and workspaceIdB are required")
+	}
+
+	filesA, err := d.files(ctx, wsA)
+	if err != nil {
+		return nil, out, err
+	}
+	filesB, err := d.files(ctx, wsB)
+	if err != nil {
+		return nil, out, err
+	}
+
+	onlyA, onlyB, changed := diffFiles(filesA, filesB)
+	limit := clampLimit(input.Limit, 1000)
+	out.TotalA, out.TotalB = len(filesA), len(filesB)
+	out.OnlyInACount, out.OnlyInBCount, out.ChangedCount = len(onlyA), len(onlyB), len(changed)
+	out.UnchangedCount = len(filesA) - len(onlyA) - len(changed)
+	out.Truncated = len(onlyA) > limit || len(onlyB) > limit || len(changed) > limit
+	out.OnlyInA = append(out.OnlyInA, onlyA[:min(len(onlyA), limit)]...)
+	out.OnlyInB = append(out.OnlyInB, onlyB[:min(len(onlyB), limit)]...)
+	out.Changed = append(out.Changed, changed[:min(len(changed), limit)]...)
+	return nil, out, nil
+}
+
+func (d *WorkspaceDiff) files(ctx context.Context, wsID string) ([]FindFileResult, error) {
+	const q = `
+SELECT relpath, lang, size, mtime, sha
+FROM file
+WHERE ws = type::thing('workspace', $ws_id)
+`
+	type row struct {
+		RelPath string    `json:"relpath"`
+		Lang    string    `json:"lang"`
+		Size    int64     `json:"size"`
+		MTime   time.Time `json:"mtime"`
+		SHA     string    `json:"sha"`
+	}
+	rows, err := surreal.Query[row](ctx, d.DB, q, map[string]any{"ws_id": wsID})
+	if err != nil {
+		return nil, fmt.Errorf("list files of workspace %s: %w", wsID, err)
+	}
+	files := make([]FindFileResult, 0, len(rows))
+	for _, r := range rows {
+		files = append(files, FindFileResult{RelPath: r.RelPath, Type: "file", Lang: r.Lang, Size: r.Size, MTime: r.MTime, SHA: r.SHA})
+	}
+	return files, nil
+}
+
+// diffFiles splits a and b by relpath into files only in a, files only in b,
+// and files in both whose hashes differ. Each result is sorted by relpath.
+func diffFiles(a, b []FindFileResult) (onlyA, onlyB []FindFileResult, changed []ChangedFile) {
+	inB := make(map[string]FindFileResult, len(b))
+	for _, f := range b {
+		inB[f.RelPath] = f
+	}
+	for _, f := range a {
+		other, ok := inB[f.RelPath]
+		switch {
+		case !ok:
+			onlyA = append(onlyA, f)
+		case other.SHA != f.SHA:
+			changed = append(changed, ChangedFile{RelPath: f.RelPath, ShaA: f.SHA, ShaB: other.SHA})
+		}
+		delete(inB, f.RelPath)
+	}
+	for _, f := range inB {
+		onlyB = append(onlyB, f)
+	}
+	sort.Slice(onlyA, func(i, j int) bool { return onlyA[i].RelPath < onlyA[j].RelPath })
+	sort.Slice(onlyB, func(i, j int) bool { return onlyB[i].RelPath < onlyB[j].RelPath })
+	sort.Slice(changed, func(i, j int) bool { return changed[i].RelPath < changed[j].RelPath })
+	return onlyA, onlyB, changed
+}
@@ -0,0 +1,28 @@
+package tools
+
+import "testing"
+
+func TestDiffFiles(t *testing.T) {
+	a := []FindFileResult{
+		{RelPath: "same.go", SHA: "1"},
+		{RelPath: "edited.go", SHA: "2"},
+		{RelPath: "z_only_a.go", SHA: "3"},
+		{RelPath: "b_only_a.go", SHA: "4"},
+	}
+	b := []FindFileResult{
+		{RelPath: "same.go", SHA: "1"},
+		{RelPath: "edited.go", SHA: "9"},
+		{RelPath: "only_b.go", SHA: "5"},
+	}
+
+	onlyA, onlyB, changed := diffFiles(a, b)
+	if len(onlyA) != 2 || onlyA[0].RelPath != "b_only_a.go" || onlyA[1].RelPath != "z_only_a.go" {
+		t.Fatalf("unexpected onlyA %+v", onlyA)
+	}
+	if len(onlyB) != 1 || onlyB[0].RelPath != "only_b.go" {
+		t.Fatalf("unexpected onlyB %+v", onlyB)
+	}
+	if len(changed) != 1 || changed[0] != (ChangedFile{RelPath: "edited.go", ShaA: "2", ShaB: "9"}) {
+		t.Fatalf("unexpected changed %+v", changed)
+	}
+}
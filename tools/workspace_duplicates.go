@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type WorkspaceDuplicates struct {
+	DB *surreal.Client
+}
+
+type WorkspaceDuplicatesInput struct {
+	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"maximum number of duplicate groups to return (default 100)"`
+}
+
+type WorkspaceDuplicatesOutput struct {
+	Groups []DuplicateGroup `json:"groups" jsonschema:"groups of files sharing identical content"`
+}
+
+type DuplicateGroup struct {
+	SHA      string   `json:"sha" jsonschema:"shared content hash"`
+	Size     int64    `json:"size" jsonschema:"file size in bytes"`
+	Count    int      `json:"count" jsonschema:"number of files with this content"`
+	RelPaths []string `json:"relpaths" jsonschema:"paths relative to workspace root"`
+}
+
+func (d *WorkspaceDuplicates) List(ctx context.Context, _ *mcp.CallToolRequest, input WorkspaceDuplicatesInput) (*mcp.CallToolResult, WorkspaceDuplicatesOutput, error) {
+	groups := make([]DuplicateGroup, 0)
+	if d == nil || d.DB == nil {
+		return nil, WorkspaceDuplicatesOutput{Groups: groups}, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, WorkspaceDuplicatesOutput{Groups: groups}, fmt.Errorf("workspaceId is required")
+	}
+
+	// SurrealQL has no HAVING clause; filter the grouped projection instead.
+	const q = `
+SELECT * FROM (
+    SELECT sha, math::max(size) AS size, count() AS count, array::group(relpath) AS relpaths
+    FROM file
+    WHERE ws = type::thing('workspace', $ws_id) AND sha != ""
+    GROUP BY sha
+)
+WHERE count > 1
+ORDER BY count DESC, sha ASC
+LIMIT $limit
+`
+
+	type row struct {
+		SHA      string   `json:"sha"`
+		Size     int64    `json:"size"`
+		Count    int      `json:"count"`
+		RelPaths []string `json:"relpaths"`
+	}
+
+	rows, err := surreal.Query[row](ctx, d.DB, q, map[string]any{
+		"ws_id": wsID,
+		"limit": clampLimit(input.Limit, 100),
+	})
+	if err != nil {
+		return nil, WorkspaceDuplicatesOutput{Groups: groups}, fmt.Errorf("find duplicates: %w", err)
+	}
+
+	for _, r := range rows {
+		sort.Strings(r.RelPaths)
+		groups = append(groups, DuplicateGroup(r))
+	}
+
+	return nil, WorkspaceDuplicatesOutput{Groups: groups}, nil
+}
@@ -1,15 +1,19 @@
 package tools
 
 import (
+    "bytes"
     "context"
     "encoding/hex"
     "fmt"
+    "io"
     "os"
     "path/filepath"
     "strings"
+    "unicode/utf8"
 
     "github.com/CryingSurrogate/chaosmith-core/internal/surreal"
     "github.com/modelcontextprotocol/go-sdk/mcp"
+    "github.com/zeebo/blake3"
 )
 
 type ReadWorkspaceFile struct {
@@ -19,9 +23,13 @@ type ReadWorkspaceFile struct {
 type ReadWorkspaceFileInput struct {
     WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
     RelPath     string `json:"relPath" jsonschema:"file path relative to workspace root"`
-    Start       int    `json:"start" jsonschema:"start character offset (0-based)"`
-    End         int    `json:"end" jsonschema:"end character offset (exclusive)"`
+    Start       int    `json:"start" jsonschema:"start offset (0-based) in offsetUnit"`
+    End         int    `json:"end" jsonschema:"end offset (exclusive) in offsetUnit"`
+    OffsetUnit  string `json:"offsetUnit,omitempty" jsonschema:"byte (default; matches vector chunk and search offsets) or rune; ignored in hex mode"`
     Hex         bool   `json:"hex,omitempty" jsonschema:"when true, read as hex-encoded bytes and count hex characters"`
+    Head        int    `json:"head,omitempty" jsonschema:"return the first N lines instead of a character range"`
+    Tail        int    `json:"tail,omitempty" jsonschema:"return the last N lines instead of a character range"`
+    ContentSHA  string `json:"contentSha,omitempty" jsonschema:"return the exact span of the indexed chunk with this content_sha (as reported by vector search) instead of a character range"`
 }
 
 type ReadWorkspaceFileOutput struct {
@@ -29,6 +37,8 @@ type ReadWorkspaceFileOutput struct {
     Chunk     string `json:"chunk" jsonschema:"requested slice of the file contents"`
     Hex       bool   `json:"hex" jsonschema:"true if hex mode was used"`
     Truncated bool   `json:"truncated" jsonschema:"true if output was truncated for transport size"`
+    StartLine int    `json:"startLine,omitempty" jsonschema:"first line returned (1-based) in head/tail mode"`
+    EndLine   int    `json:"endLine,omitempty" jsonschema:"last line returned (1-based, inclusive) in head/tail mode"`
 }
 
 func (r *ReadWorkspaceFile) Read(ctx context.Context, _ *mcp.CallToolRequest, input ReadWorkspaceFileInput) (*mcp.CallToolResult, ReadWorkspaceFileOutput, error) {
@@ -52,7 +62,8 @@ func (r *ReadWorkspaceFile) Read(ctx context.Context, _ *mcp.CallToolRequest, in
         return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("path provided is not relative")
     }
 
-    if _, err := lookupFileRecordID(ctx, r.DB, wsID, rel); err != nil {
+    fileID, err := lookupFileRecordID(ctx, r.DB, wsID, rel)
+    if err != nil {
         return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, err
     }
 
@@ -61,7 +72,44 @@ func (r *ReadWorkspaceFile) Read(ctx context.Context, _ *mcp.CallToolRequest, in
         return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, err
     }
 
+    unit := strings.ToLower(strings.TrimSpace(input.OffsetUnit))
+    if unit == "" {
+        unit = "byte"
+    }
+    if unit != "byte" && unit != "rune" {
+        return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("offsetUnit must be byte or rune, got %q", input.OffsetUnit)
+    }
+
     full := filepath.Join(wsPath, filepath.FromSlash(rel))
+
+    if sha := strings.TrimSpace(input.ContentSHA); sha != "" {
+        if input.Head != 0 || input.Tail != 0 {
+            return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("contentSha cannot be combined with head or tail")
+        }
+        chunk, err := readChunkSpan(ctx, r.DB, full, fileID, sha, input.Hex)
+        if err != nil {
+            return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, err
+        }
+        return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: chunk, Hex: input.Hex, Truncated: false}, nil
+    }
+
+    if input.Head != 0 || input.Tail != 0 {
+        switch {
+        case input.Head < 0 || input.Tail < 0:
+            return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("head and tail must be positive")
+        case input.Head > 0 && input.Tail > 0:
+            return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("head and tail are mutually exclusive")
+        case input.Hex:
+            return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("head and tail cannot be combined with hex")
+        }
+        out, err := readLines(full, input.Head, input.Tail, maxChunkChars)
+        if err != nil {
+            return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, err
+        }
+        out.RelPath = rel
+        return nil, out, nil
+    }
+
     data, err := os.ReadFile(full)
     if err != nil {
         return nil, ReadWorkspaceFileOutput{RelPath: rel, Chunk: "", Hex: input.Hex, Truncated: false}, fmt.Errorf("read file: %w", err)
@@ -123,24 +171,7 @@ func (r *ReadWorkspaceFile) Read(ctx context.Context, _ *mcp.CallToolRequest, in
             chunk += ". . .truncated"
         }
     } else {
-        runes := []rune(string(data))
-        if start > len(runes) {
-            start = len(runes)
-        }
-        if end > len(runes) {
-            end = len(runes)
-        }
-        if end-start > maxChunkChars {
-            end = start + maxChunkChars
-            truncated = true
-        }
-        chunk = string(runes[start:end])
-        if end >= len(runes) {
-            chunk += "<|EOF|>"
-        }
-        if truncated {
-            chunk += ". . .truncated"
-        }
+        chunk, truncated = sliceText(data, start, end, unit, maxChunkChars)
     }
 
     out := ReadWorkspaceFileOutput{
@@ -152,3 +183,273 @@ func (r *ReadWorkspaceFile) Read(ctx context.Context, _ *mcp.CallToolRequest, in
     return nil, out, nil
 }
 
+
+// sliceText returns data[start:end] with offsets counted in unit ("byte" or
+// "rune"), capped at maxLen units and marked with the EOF and truncation
+// suffixes. Byte offsets that fall inside a multi-byte character are moved to
+// the character's boundary, as sliceSnippet does.
+func sliceText(data []byte, start, end int, unit string, maxLen int) (string, bool) {
+    var (
+        chunk     string
+        total     int
+        truncated bool
+    )
+    if unit == "rune" {
+        runes := []rune(string(data))
+        total = len(runes)
+        start, end = min(start, total), min(end, total)
+        if end-start > maxLen {
+            end = start + maxLen
+            truncated = true
+        }
+        chunk = string(runes[start:end])
+    } else {
+        total = len(data)
+        start, end = min(start, total), min(end, total)
+        if end-start > maxLen {
+            end = start + maxLen
+            truncated = true
+        }
+        start, end = alignRunes(data, start, end)
+        chunk = string(data[start:end])
+    }
+    if end >= total {
+        chunk += "<|EOF|>"
+    }
+    if truncated {
+        chunk += ". . .truncated"
+    }
+    return chunk, truncated
+}
+
+// readChunkSpan returns the bytes of the file at path covered by the
+// vector_chunk of fileID whose content_sha is sha, hex-encoded when asHex is
+// set. The span is re-hashed so that a file edited since it was indexed is
+// reported instead of returning the wrong text.
+func readChunkSpan(ctx context.Context, db *surreal.Client, path, fileID, sha string, asHex bool) (string, error) {
+    type row struct {
+        Start int64 `json:"start"`
+        End   int64 `json:"end"`
+    }
+    const q = `
+SELECT start, end
+FROM vector_chunk
+WHERE file = type::thing('file', $file_id) AND content_sha = $sha
+LIMIT 1
+`
+    rows, err := surreal.Query[row](ctx, db, q, map[string]any{"file_id": fileID, "sha": sha})
+    if err != nil {
+        return "", fmt.Errorf("lookup chunk: %w", err)
+    }
+    if len(rows) == 0 {
+        return "", fmt.Errorf("no chunk with contentSha %s in this file", sha)
+    }
+    start, end := rows[0].Start, rows[0].End
+    if start < 0 || end < start {
+        return "", fmt.Errorf("chunk %s has invalid span %d-%d", sha, start, end)
+    }
+
+    data, err := chunkSpanText(path, start, end, sha)
+    if err != nil {
+        return "", err
+    }
+    if asHex {
+        return hex.EncodeToString(data), nil
+    }
+    return string(data), nil
+}
+
+// chunkSpanText returns bytes start..end of the file at path after checking
+// they hash to sha. Offsets and hash refer to the decoded text, which differs
+// from the raw bytes for files with a BOM or in UTF-16 or Latin-1, so those
+// files are decoded before slicing; plain UTF-8 is read in place.
+func chunkSpanText(path string, start, end int64, sha string) ([]byte, error) {
+    data, err := readSpan(path, start, end)
+    if err != nil {
+        return nil, err
+    }
+    if matchesSHA(data, sha) {
+        return data, nil
+    }
+    text, err := readIndexedText(path)
+    if err != nil {
+        return nil, fmt.Errorf("read file: %w", err)
+    }
+    if end <= int64(len(text)) && matchesSHA(text[start:end], sha) {
+        return text[start:end], nil
+    }
+    return nil, fmt.Errorf("chunk %s no longer matches the file; it changed since it was indexed", sha)
+}
+
+// readSpan reads bytes start..end of the file at path without loading the rest.
+func readSpan(path string, start, end int64) ([]byte, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("read file: %w", err)
+    }
+    defer f.Close()
+    data := make([]byte, end-start)
+    n, err := f.ReadAt(data, start)
+    if err != nil && err != io.EOF {
+        return nil, fmt.Errorf("read file: %w", err)
+    }
+    return data[:n], nil
+}
+
+// matchesSHA reports whether data hashes to sha as the indexer hashes chunks.
+func matchesSHA(data []byte, sha string) bool {
+    sum := blake3.Sum256(data)
+    return hex.EncodeToString(sum[:]) == sha
+}
+
+// readLines returns the first head or last tail lines of the file at path,
+// reading at most maxBytes of it. Tail mode reads backwards from the end, so
+// only the returned span and a newline count before it touch the rest of the
+// file. When the lines do not fit in maxBytes, head keeps the leading bytes
+// and tail the trailing whole lines.
+func readLines(path string, head, tail, maxBytes int) (ReadWorkspaceFileOutput, error) {
+    var out ReadWorkspaceFileOutput
+    f, err := os.Open(path)
+    if err != nil {
+        return out, fmt.Errorf("read file: %w", err)
+    }
+    defer f.Close()
+    info, err := f.Stat()
+    if err != nil {
+        return out, fmt.Errorf("read file: %w", err)
+    }
+    size := info.Size()
+
+    var (
+        data  []byte
+        start int64
+    )
+    if head > 0 {
+        data, err = io.ReadAll(io.LimitReader(f, int64(maxBytes)+1))
+        if err != nil {
+            return out, fmt.Errorf("read file: %w", err)
+        }
+        if end := lineEnd(data, head); end >= 0 && end <= maxBytes {
+            data = data[:end]
+        } else if len(data) > maxBytes {
+            data = data[:maxBytes]
+            data = data[:len(data)-incompleteRuneSuffix(data)]
+            out.Truncated = true
+        }
+    } else {
+        start, out.Truncated, err = tailStart(f, size, tail, int64(maxBytes))
+        if err != nil {
+            return out, fmt.Errorf("read file: %w", err)
+        }
+        data = make([]byte, size-start)
+        if _, err := f.ReadAt(data, start); err != nil && err != io.EOF {
+            return out, fmt.Errorf("read file: %w", err)
+        }
+        if out.Truncated {
+            // Drop the partial first line, or at least a partial first rune.
+            if i := bytes.IndexByte(data, '\n'); i >= 0 && i < len(data)-1 {
+                start += int64(i + 1)
+                data = data[i+1:]
+            }
+            for len(data) > 0 && !utf8.RuneStart(data[0]) {
+                start++
+                data = data[1:]
+            }
+        }
+    }
+
+    out.Chunk = string(data)
+    if len(data) > 0 {
+        before, err := countNewlines(f, start)
+        if err != nil {
+            return out, fmt.Errorf("read file: %w", err)
+        }
+        out.StartLine = before + 1
+        out.EndLine = out.StartLine + bytes.Count(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+    }
+    if start+int64(len(data)) >= size {
+        out.Chunk += "<|EOF|>"
+    }
+    if out.Truncated {
+        out.Chunk += ". . .truncated"
+    }
+    return out, nil
+}
+
+// lineEnd returns the offset just past the n-th newline in data, or -1 when
+// data holds fewer than n newlines.
+func lineEnd(data []byte, n int) int {
+    off := 0
+    for i := 0; i < n; i++ {
+        j := bytes.IndexByte(data[off:], '\n')
+        if j < 0 {
+            return -1
+        }
+        off += j + 1
+    }
+    return off
+}
+
+// tailStart returns the offset where the last n lines of f begin, reading
+// backwards in blocks. A newline ending the file does not start an empty last
+// line. If those lines span more than maxBytes, the offset maxBytes before the
+// end is returned with truncated set.
+func tailStart(f io.ReaderAt, size int64, n int, maxBytes int64) (int64, bool, error) {
+    const blockSize = 32 * 1024
+    limit := max(size-maxBytes, 0)
+    pos := size
+    buf := make([]byte, blockSize)
+    found := 0
+    for pos > limit {
+        blockLen := min(int64(blockSize), pos-limit)
+        pos -= blockLen
+        block := buf[:blockLen]
+        if _, err := f.ReadAt(block, pos); err != nil && err != io.EOF {
+            return 0, false, err
+        }
+        for i := len(block) - 1; i >= 0; i-- {
+            if block[i] != '\n' {
+                continue
+            }
+            if pos+int64(i) == size-1 {
+                continue
+            }
+            found++
+            if found == n {
+                return pos + int64(i) + 1, false, nil
+            }
+        }
+    }
+    return limit, limit > 0, nil
+}
+
+// countNewlines counts the newlines in the first n bytes of f.
+func countNewlines(f io.ReaderAt, n int64) (int, error) {
+    buf := make([]byte, 32*1024)
+    r := io.NewSectionReader(f, 0, n)
+    count := 0
+    for {
+        m, err := r.Read(buf)
+        count += bytes.Count(buf[:m], []byte("\n"))
+        if err == io.EOF {
+            return count, nil
+        }
+        if err != nil {
+            return count, err
+        }
+    }
+}
+
+// incompleteRuneSuffix returns how many bytes at the end of b start a UTF-8
+// sequence that b does not hold in full.
+func incompleteRuneSuffix(b []byte) int {
+    for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+        if utf8.RuneStart(b[len(b)-i]) {
+            if utf8.FullRune(b[len(b)-i:]) {
+                return 0
+            }
+            return i
+        }
+    }
+    return 0
+}
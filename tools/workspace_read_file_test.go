@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/blake3"
+)
+
+func writeLinesFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "log.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestReadLinesHeadAndTail(t *testing.T) {
+	path := writeLinesFile(t, "one\ntwo\nthree\nfour\n")
+
+	cases := []struct {
+		name       string
+		head, tail int
+		chunk      string
+		start, end int
+	}{
+		{"head", 2, 0, "one\ntwo\n", 1, 2},
+		{"head past end", 10, 0, "one\ntwo\nthree\nfour\n<|EOF|>", 1, 4},
+		{"tail", 0, 2, "three\nfour\n<|EOF|>", 3, 4},
+		{"tail past start", 0, 10, "one\ntwo\nthree\nfour\n<|EOF|>", 1, 4},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := readLines(path, tc.head, tc.tail, 1024)
+			if err != nil {
+				t.Fatalf("readLines: %v", err)
+			}
+			if out.Chunk != tc.chunk || out.StartLine != tc.start || out.EndLine != tc.end {
+				t.Fatalf("got %q lines %d-%d, want %q lines %d-%d", out.Chunk, out.StartLine, out.EndLine, tc.chunk, tc.start, tc.end)
+			}
+		})
+	}
+}
+
+func TestReadLinesTailWithoutTrailingNewline(t *testing.T) {
+	path := writeLinesFile(t, "a\nb\nc")
+	out, err := readLines(path, 0, 1, 1024)
+	if err != nil {
+		t.Fatalf("readLines: %v", err)
+	}
+	if out.Chunk != "c<|EOF|>" || out.StartLine != 3 || out.EndLine != 3 {
+		t.Fatalf("unexpected %+v", out)
+	}
+}
+
+func TestReadLinesTailTruncatesToWholeLines(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 100; i++ {
+		b.WriteString("0123456789\n")
+	}
+	path := writeLinesFile(t, b.String())
+
+	// 11 bytes per line: a 50-byte cap keeps the last 4 whole lines.
+	out, err := readLines(path, 0, 100, 50)
+	if err != nil {
+		t.Fatalf("readLines: %v", err)
+	}
+	if !out.Truncated || out.StartLine != 97 || out.EndLine != 100 {
+		t.Fatalf("unexpected %+v", out)
+	}
+	if !strings.HasPrefix(out.Chunk, "0123456789\n") {
+		t.Fatalf("expected chunk to start on a line boundary, got %q", out.Chunk)
+	}
+}
+
+func TestChunkOffsetsRoundTripThroughRead(t *testing.T) {
+	data := []byte("héllo wörld\n日本語のテキスト\nend")
+	// Chunk offsets are byte offsets into the file, as the chunker reports them.
+	want := "日本語のテキスト"
+	start := strings.Index(string(data), want)
+	end := start + len(want)
+
+	got, truncated := sliceText(data, start, end, "byte", 1024)
+	if got != want || truncated {
+		t.Fatalf("byte read = %q, want %q", got, want)
+	}
+	if snippet := sliceSnippet(data, start, end); snippet != want {
+		t.Fatalf("snippet = %q, want %q", snippet, want)
+	}
+
+	// The same offsets as runes point somewhere else entirely.
+	if got, _ := sliceText(data, start, end, "rune", 1024); got == want {
+		t.Fatalf("rune offsets unexpectedly matched byte offsets")
+	}
+	runeStart := len([]rune(string(data[:start])))
+	if got, _ := sliceText(data, runeStart, runeStart+len([]rune(want)), "rune", 1024); got != want {
+		t.Fatalf("rune read = %q, want %q", got, want)
+	}
+}
+
+func TestSliceTextAlignsSplitCharacters(t *testing.T) {
+	data := []byte("aé日b")
+	// Byte 2 is inside é and byte 4 inside 日; both ends move to whole characters.
+	if got, _ := sliceText(data, 2, 4, "byte", 1024); got != "" {
+		t.Fatalf("expected empty slice for a span holding no whole character, got %q", got)
+	}
+	if got, _ := sliceText(data, 1, 6, "byte", 1024); got != "é日" {
+		t.Fatalf("got %q", got)
+	}
+	if got := sliceSnippet(data, 2, len(data)); got != "日b" {
+		t.Fatalf("snippet = %q", got)
+	}
+}
+
+func TestChunkSpanTextHashesDecodedText(t *testing.T) {
+	dir := t.TempDir()
+	chunk := []byte("second café")
+	sum := blake3.Sum256(chunk)
+	sha := hex.EncodeToString(sum[:])
+	cases := []struct {
+		name string
+		raw  []byte
+	}{
+		{"plain.txt", []byte("first\nsecond café\n")},
+		{"bom.txt", []byte("\xEF\xBB\xBFfirst\nsecond café\n")},
+		{"latin1.txt", []byte("first\nsecond caf\xE9\n")},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, tc.raw, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Offsets into the decoded text, as the indexer stored them.
+		got, err := chunkSpanText(path, 6, int64(6+len(chunk)), sha)
+		if err != nil || string(got) != string(chunk) {
+			t.Fatalf("%s: got (%q, %v), want %q", tc.name, got, err, chunk)
+		}
+	}
+
+	path := filepath.Join(dir, "plain.txt")
+	if err := os.WriteFile(path, []byte("first\nchanged!!!!!!\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chunkSpanText(path, 6, int64(6+len(chunk)), sha); err == nil || !strings.Contains(err.Error(), "no longer matches") {
+		t.Fatalf("expected stale chunk error, got %v", err)
+	}
+}
@@ -18,6 +18,7 @@ type WorkspaceRegisterInput struct {
 	WorkspaceID string `json:"workspaceId" jsonschema:"stable identifier for workspace"`
 	Path        string `json:"path" jsonschema:"absolute path to workspace root"`
 	NodeID      string `json:"nodeId,omitempty" jsonschema:"optional node id to relate via on_node"`
+	EmbedModel  string `json:"embedModel,omitempty" jsonschema:"embedding model for this workspace; empty uses embed_model from config"`
 }
 
 type WorkspaceRegisterOutput struct {
@@ -44,8 +45,14 @@ func (w *WorkspaceRegister) Register(ctx context.Context, _ *mcp.CallToolRequest
 		"rev":         "",
 		"content_sha": "",
 	}
+	// Merged rather than replaced so re-registering without embedModel keeps
+	// an override set earlier.
+	if model := strings.TrimSpace(input.EmbedModel); model != "" {
+		data["embed_model"] = model
+	}
 
-	if err := w.DB.UpsertRecord(ctx, "workspace", input.WorkspaceID, data); err != nil {
+	const q = "UPSERT type::thing('workspace', $ws_id) MERGE $data"
+	if _, err := surreal.Query[map[string]any](ctx, w.DB, q, map[string]any{"ws_id": input.WorkspaceID, "data": data}); err != nil {
 		return nil, WorkspaceRegisterOutput{}, fmt.Errorf("upsert workspace: %w", err)
 	}
 
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -17,24 +20,35 @@ type WorkspaceSearchText struct {
 }
 
 type WorkspaceSearchTextInput struct {
-	WorkspaceID   string `json:"workspaceId" jsonschema:"workspace identifier"`
-	Query         string `json:"query" jsonschema:"exact text snippet to find"`
-	CaseSensitive bool   `json:"caseSensitive,omitempty" jsonschema:"if true, match is case-sensitive"`
-	Limit         int    `json:"limit,omitempty" jsonschema:"max number of matches (default 20)"`
-	MaxFileBytes  int64  `json:"maxFileBytes,omitempty" jsonschema:"skip files larger than this many bytes (default 1048576)"`
+	WorkspaceID    string     `json:"workspaceId" jsonschema:"workspace identifier"`
+	Query          string     `json:"query,omitempty" jsonschema:"exact text snippet to find"`
+	Queries        []string   `json:"queries,omitempty" jsonschema:"further snippets; a line matches if it contains any of query and queries"`
+	CaseSensitive  bool       `json:"caseSensitive,omitempty" jsonschema:"if true, match is case-sensitive"`
+	Invert         bool       `json:"invert,omitempty" jsonschema:"return lines that contain none of the queries (blank lines are skipped)"`
+	Limit          int        `json:"limit,omitempty" jsonschema:"max number of matches (default 20)"`
+	HighlightPre   string     `json:"highlightPre,omitempty" jsonschema:"marker inserted before each matched term in snippets, e.g. «; highlighting is off unless highlightPre or highlightPost is set"`
+	HighlightPost  string     `json:"highlightPost,omitempty" jsonschema:"marker inserted after each matched term in snippets, e.g. »"`
+	PerFileLimit   int        `json:"perFileLimit,omitempty" jsonschema:"max matches taken from any one file (default unlimited); limit still bounds the total"`
+	MaxFileBytes   int64      `json:"maxFileBytes,omitempty" jsonschema:"skip files larger than this many bytes (default 1048576)"`
+	ModifiedAfter  *time.Time `json:"modifiedAfter,omitempty" jsonschema:"only search files modified at or after this RFC3339 time"`
+	ModifiedBefore *time.Time `json:"modifiedBefore,omitempty" jsonschema:"only search files modified at or before this RFC3339 time"`
 }
 
 type WorkspaceSearchTextOutput struct {
-	Matches []TextMatch `json:"matches" jsonschema:"list of file matches"`
+	Matches      []TextMatch `json:"matches" jsonschema:"list of file matches"`
+	SkippedFiles []string    `json:"skippedFiles,omitempty" jsonschema:"relpaths not searched to the end because a line exceeded 2 MiB (e.g. minified JS); matches before that line are still returned"`
 }
 
 type TextMatch struct {
-	RelPath    string `json:"relpath" jsonschema:"file path relative to workspace root"`
-	LineNumber int    `json:"lineNumber" jsonschema:"line number of match"`
-	Snippet    string `json:"snippet" jsonschema:"line containing the match"`
+	WorkspaceID string `json:"workspaceId,omitempty" jsonschema:"workspace containing the match (global search only)"`
+	RelPath     string `json:"relpath" jsonschema:"file path relative to workspace root"`
+	LineNumber  int    `json:"lineNumber" jsonschema:"line number of match"`
+	Snippet     string `json:"snippet" jsonschema:"line containing the match"`
+	Matched     string `json:"matched,omitempty" jsonschema:"the query term found on the line; empty for invert"`
 }
 
 func (s *WorkspaceSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest, input WorkspaceSearchTextInput) (*mcp.CallToolResult, WorkspaceSearchTextOutput, error) {
+	metrics.SearchRequests.WithLabelValues("workspace_search_text").Inc()
 
 	matches := make([]TextMatch, 0, input.Limit)
 
@@ -45,10 +59,11 @@ func (s *WorkspaceSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest
 	if wsID == "" {
 		return nil, WorkspaceSearchTextOutput{Matches: matches}, fmt.Errorf("workspaceId is required")
 	}
-	query := input.Query
-	if strings.TrimSpace(query) == "" {
-		return nil, WorkspaceSearchTextOutput{Matches: matches}, fmt.Errorf("query is required")
+	matcher, err := newLineMatcher(input.Query, input.Queries, input.CaseSensitive, input.Invert)
+	if err != nil {
+		return nil, WorkspaceSearchTextOutput{Matches: matches}, err
 	}
+	matcher.pre, matcher.post = input.HighlightPre, input.HighlightPost
 
 	maxBytes := input.MaxFileBytes
 	if maxBytes <= 0 {
@@ -64,63 +79,25 @@ func (s *WorkspaceSearchText) Search(ctx context.Context, _ *mcp.CallToolRequest
 		return nil, WorkspaceSearchTextOutput{Matches: matches}, err
 	}
 
-	files, err := s.listWorkspaceFiles(ctx, wsID)
+	files, err := s.listWorkspaceFiles(ctx, wsID, input.ModifiedAfter, input.ModifiedBefore)
 	if err != nil {
 		return nil, WorkspaceSearchTextOutput{Matches: matches}, err
 	}
 
-	caseSensitive := input.CaseSensitive
-	searchNeedle := query
-	if !caseSensitive {
-		searchNeedle = strings.ToLower(query)
-	}
-
-	for _, rel := range files {
+	var skipped []string
+	for _, file := range files {
 		if len(matches) >= limit {
 			break
 		}
-		fullPath := filepath.Join(wsPath, filepath.FromSlash(rel))
-		info, err := os.Stat(fullPath)
-		if err != nil || !info.Mode().IsRegular() {
-			continue
-		}
-		if info.Size() > maxBytes {
-			continue
-		}
-		content, err := os.Open(fullPath)
+		fullPath := filepath.Join(wsPath, filepath.FromSlash(file.RelPath))
+		found, err := searchFileLines(fullPath, file.RelPath, matcher, maxBytes, fileMatchCap(limit-len(matches), input.PerFileLimit))
 		if err != nil {
-			continue
-		}
-
-		scanner := bufio.NewScanner(content)
-		buf := make([]byte, 64*1024)
-		scanner.Buffer(buf, 2*1024*1024)
-		lineNo := 0
-		for scanner.Scan() {
-			lineNo++
-			line := scanner.Text()
-			lineForSearch := line
-			if !caseSensitive {
-				lineForSearch = strings.ToLower(line)
-			}
-			if strings.Contains(lineForSearch, searchNeedle) {
-				matches = append(matches, TextMatch{
-					RelPath:    rel,
-					LineNumber: lineNo,
-					Snippet:    strings.TrimSpace(line),
-				})
-				if len(matches) >= limit {
-					break
-				}
-			}
-		}
-		content.Close()
-		if len(matches) >= limit {
-			break
+			skipped = append(skipped, file.RelPath)
 		}
+		matches = append(matches, found...)
 	}
 
-	return nil, WorkspaceSearchTextOutput{Matches: matches}, nil
+	return nil, WorkspaceSearchTextOutput{Matches: matches, SkippedFiles: skipped}, nil
 }
 
 func (s *WorkspaceSearchText) lookupWorkspacePath(ctx context.Context, wsID string) (string, error) {
@@ -140,21 +117,148 @@ SELECT path FROM workspace WHERE id = type::thing('workspace', $ws_id) LIMIT 1
 	return rows[0].Path, nil
 }
 
-func (s *WorkspaceSearchText) listWorkspaceFiles(ctx context.Context, wsID string) ([]string, error) {
-	type row struct {
-		RelPath string `json:"relpath"`
+func (s *WorkspaceSearchText) listWorkspaceFiles(ctx context.Context, wsID string, after, before *time.Time) ([]workspaceFile, error) {
+	return listWorkspaceFiles(ctx, s.DB, wsID, after, before)
+}
+
+type workspaceFile struct {
+	RelPath string    `json:"relpath"`
+	MTime   time.Time `json:"mtime"`
+}
+
+// listWorkspaceFiles returns the files recorded for a workspace, optionally
+// restricted to those whose mtime falls within [after, before].
+func listWorkspaceFiles(ctx context.Context, db *surreal.Client, wsID string, after, before *time.Time) ([]workspaceFile, error) {
+	params := map[string]any{"ws_id": wsID}
+	mtimeFilter, err := timeRangeFilter("mtime", after, before, params)
+	if err != nil {
+		return nil, err
 	}
-	const q = `
-SELECT relpath FROM file WHERE ws = type::thing('workspace', $ws_id)
+	q := `
+SELECT relpath, mtime FROM file WHERE ws = type::thing('workspace', $ws_id)` + mtimeFilter + `
 ORDER BY relpath ASC
 `
-	rows, err := surreal.Query[row](ctx, s.DB, q, map[string]any{"ws_id": wsID})
+	rows, err := surreal.Query[workspaceFile](ctx, db, q, params)
 	if err != nil {
 		return nil, fmt.Errorf("list workspace files: %w", err)
 	}
-	out := make([]string, 0, len(rows))
-	for _, r := range rows {
-		out = append(out, r.RelPath)
+	return rows, nil
+}
+
+// fileMatchCap returns how many matches to take from the next file: the
+// remaining overall budget, further capped by perFile when it is positive.
+func fileMatchCap(remaining, perFile int) int {
+	if perFile > 0 && perFile < remaining {
+		return perFile
+	}
+	return remaining
+}
+
+// maxLineBytes is the longest line the text searches can scan.
+const maxLineBytes = 2 * 1024 * 1024
+
+// searchFileLines returns up to max lines of the file at fullPath accepted by
+// m. Files that are missing, irregular or larger than maxBytes yield no
+// matches. When the scan stops early, e.g. at a line longer than maxLineBytes,
+// the matches found so far are returned with the error.
+func searchFileLines(fullPath, rel string, m *lineMatcher, maxBytes int64, max int) ([]TextMatch, error) {
+	info, err := os.Stat(fullPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	if info.Size() > maxBytes {
+		return nil, nil
+	}
+	content, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil
+	}
+	defer content.Close()
+
+	var matches []TextMatch
+	scanner := bufio.NewScanner(content)
+	buf := make([]byte, 64*1024)
+	scanner.Buffer(buf, maxLineBytes)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if term, ok := m.match(line); ok {
+			matches = append(matches, TextMatch{
+				RelPath:    rel,
+				LineNumber: lineNo,
+				Snippet:    m.snippet(line),
+				Matched:    term,
+			})
+			if len(matches) >= max {
+				return matches, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return matches, fmt.Errorf("scan %s: %w", rel, err)
+	}
+	return matches, nil
+}
+
+// lineMatcher tests lines against a set of query terms, prepared once per
+// search.
+type lineMatcher struct {
+	terms         []string // as given, reported in TextMatch.Matched
+	needles       []string // terms lower-cased unless caseSensitive
+	caseSensitive bool
+	invert        bool
+	pre, post     string // highlight markers for snippet; none when both empty
+}
+
+// newLineMatcher combines query and queries into one term set, dropping blank
+// and repeated terms. At least one term is required.
+func newLineMatcher(query string, queries []string, caseSensitive, invert bool) (*lineMatcher, error) {
+	m := &lineMatcher{caseSensitive: caseSensitive, invert: invert}
+	for _, term := range append([]string{query}, queries...) {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+		needle := term
+		if !caseSensitive {
+			needle = strings.ToLower(term)
+		}
+		if slices.Contains(m.needles, needle) {
+			continue
+		}
+		m.terms = append(m.terms, term)
+		m.needles = append(m.needles, needle)
+	}
+	if len(m.terms) == 0 {
+		return nil, fmt.Errorf("query is required")
+	}
+	return m, nil
+}
+
+// snippet returns line trimmed for a TextMatch, with the terms it contains
+// wrapped in the highlight markers. Inverted matches contain no terms.
+func (m *lineMatcher) snippet(line string) string {
+	line = strings.TrimSpace(line)
+	if m.invert {
+		return line
+	}
+	return highlightTerms(line, m.terms, !m.caseSensitive, m.pre, m.post)
+}
+
+// match reports whether line contains any term and returns the first one
+// found. With invert set it instead accepts non-blank lines containing none
+// of the terms, and returns no term.
+func (m *lineMatcher) match(line string) (string, bool) {
+	if !m.caseSensitive {
+		line = strings.ToLower(line)
+	}
+	for i, needle := range m.needles {
+		if strings.Contains(line, needle) {
+			if m.invert {
+				return "", false
+			}
+			return m.terms[i], true
+		}
 	}
-	return out, nil
+	return "", m.invert && strings.TrimSpace(line) != ""
 }
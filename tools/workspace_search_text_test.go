@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineMatcher(t *testing.T) {
+	cases := []struct {
+		line, query           string
+		caseSensitive, invert bool
+		want                  bool
+	}{
+		{"// Comment", "//", false, false, true},
+		{"// Comment", "//", false, true, false},
+		{"code()", "//", false, true, true},
+		{"   ", "//", false, true, false},
+		{"TODO later", "todo", false, false, true},
+		{"TODO later", "todo", true, false, false},
+		{"TODO later", "todo", true, true, true},
+		{"TODO later", "todo", false, true, false},
+	}
+	for _, tc := range cases {
+		m, err := newLineMatcher(tc.query, nil, tc.caseSensitive, tc.invert)
+		if err != nil {
+			t.Fatalf("newLineMatcher: %v", err)
+		}
+		if _, got := m.match(tc.line); got != tc.want {
+			t.Errorf("match(%q) for %q caseSensitive=%v invert=%v = %v, want %v", tc.line, tc.query, tc.caseSensitive, tc.invert, got, tc.want)
+		}
+	}
+}
+
+func TestLineMatcherAnyOfQueries(t *testing.T) {
+	m, err := newLineMatcher("", []string{"Foo", " ", "bar", "foo"}, false, false)
+	if err != nil {
+		t.Fatalf("newLineMatcher: %v", err)
+	}
+	if len(m.terms) != 2 {
+		t.Fatalf("expected blank and repeated terms dropped, got %q", m.terms)
+	}
+	if term, ok := m.match("call BAR()"); !ok || term != "bar" {
+		t.Fatalf("expected bar to match, got %q %v", term, ok)
+	}
+	if term, ok := m.match("FOO and bar"); !ok || term != "Foo" {
+		t.Fatalf("expected the first term found to be reported, got %q %v", term, ok)
+	}
+	if _, ok := m.match("baz"); ok {
+		t.Fatalf("expected no match")
+	}
+
+	inv, _ := newLineMatcher("foo", []string{"bar"}, false, true)
+	if _, ok := inv.match("bar only"); ok {
+		t.Fatalf("invert should reject a line containing any term")
+	}
+	if _, err := newLineMatcher(" ", []string{""}, false, false); err == nil {
+		t.Fatalf("expected error without any term")
+	}
+}
+
+func TestFileMatchCap(t *testing.T) {
+	cases := []struct{ remaining, perFile, want int }{
+		{20, 0, 20},
+		{20, 5, 5},
+		{3, 5, 3},
+		{20, -1, 20},
+	}
+	for _, tc := range cases {
+		if got := fileMatchCap(tc.remaining, tc.perFile); got != tc.want {
+			t.Errorf("fileMatchCap(%d, %d) = %d, want %d", tc.remaining, tc.perFile, got, tc.want)
+		}
+	}
+}
+
+func TestSearchFileLinesReportsOversizedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.min.js")
+	content := "needle first\n" + strings.Repeat("x", 3<<20) + "\nneedle after\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := newLineMatcher("needle", nil, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := searchFileLines(path, "bundle.min.js", m, 4<<20, 10)
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("expected bufio.ErrTooLong, got %v", err)
+	}
+	if len(got) != 1 || got[0].LineNumber != 1 {
+		t.Fatalf("expected the match before the long line, got %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/indexer"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// WorkspaceSyncGit fast-forwards a Git workspace to its origin branch and
+// re-indexes it when the pull brought in new commits.
+type WorkspaceSyncGit struct {
+	DB     *surreal.Client
+	Engine *indexer.Indexer
+}
+
+type WorkspaceSyncGitInput struct {
+	WorkspaceID string `json:"workspaceId" jsonschema:"registered workspace identifier"`
+	Branch      string `json:"branch,omitempty" jsonschema:"branch to pull from origin (default: the checked-out branch)"`
+	DryRun      bool   `json:"dryRun,omitempty" jsonschema:"report what would be pulled from the last-fetched origin branch without fetching, merging or re-indexing"`
+	RunID       string `json:"runId,omitempty" jsonschema:"optional deterministic run id for the re-index"`
+}
+
+type WorkspaceSyncGitOutput struct {
+	PreviousRev  string             `json:"previousRev" jsonschema:"HEAD before the sync"`
+	NewRev       string             `json:"newRev" jsonschema:"HEAD after the sync; for dryRun, the origin branch head"`
+	FilesChanged int                `json:"filesChanged" jsonschema:"number of files that differ between previousRev and newRev"`
+	Acceptance   string             `json:"acceptance" jsonschema:"pass or fail; dry-run for dryRun"`
+	Run          *indexer.RunReport `json:"run,omitempty" jsonschema:"re-index report when new commits were pulled"`
+}
+
+func (o WorkspaceSyncGitOutput) auditAcceptance() string {
+	return o.Acceptance
+}
+
+func (w *WorkspaceSyncGit) Sync(ctx context.Context, req *mcp.CallToolRequest, input WorkspaceSyncGitInput) (*mcp.CallToolResult, WorkspaceSyncGitOutput, error) {
+	var out WorkspaceSyncGitOutput
+	if w == nil || w.DB == nil {
+		return nil, out, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, out, fmt.Errorf("workspaceId is required")
+	}
+	wsPath, err := lookupWorkspacePath(ctx, w.DB, wsID)
+	if err != nil {
+		return nil, out, err
+	}
+
+	res, err := syncGit(ctx, wsPath, strings.TrimSpace(input.Branch), input.DryRun)
+	out.PreviousRev, out.NewRev, out.FilesChanged = res.previousRev, res.newRev, res.filesChanged
+	if err != nil {
+		out.Acceptance = "fail"
+		return nil, out, err
+	}
+	if input.DryRun {
+		out.Acceptance = "dry-run"
+		return nil, out, nil
+	}
+
+	if err := w.DB.MergeRecord(ctx, "workspace", wsID, map[string]any{"vcs": "git", "rev": out.NewRev}); err != nil {
+		out.Acceptance = "fail"
+		return nil, out, fmt.Errorf("update workspace rev: %w", err)
+	}
+	out.Acceptance = "pass"
+	if out.NewRev == out.PreviousRev || w.Engine == nil {
+		return nil, out, nil
+	}
+	out.Run, err = w.Engine.All(ctx, indexer.WorkspaceRequest{
+		WorkspaceRoot: wsPath,
+		WorkspaceID:   wsID,
+		RunID:         input.RunID,
+		Request:       req,
+	})
+	if out.Run != nil {
+		out.Acceptance = out.Run.Acceptance
+	}
+	if err != nil {
+		out.Acceptance = "fail"
+	}
+	return nil, out, err
+}
+
+type gitSyncResult struct {
+	previousRev  string
+	newRev       string
+	filesChanged int
+}
+
+// syncGit fetches branch from origin and fast-forwards the checkout in dir to
+// it. With dryRun set nothing is fetched or merged; newRev is the origin
+// branch as of the last fetch.
+func syncGit(ctx context.Context, dir, branch string, dryRun bool) (gitSyncResult, error) {
+	var res gitSyncResult
+	if branch == "" {
+		current, err := runGit(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return res, err
+		}
+		if current == "HEAD" {
+			return res, fmt.Errorf("workspace has a detached HEAD; pass a branch")
+		}
+		branch = current
+	}
+	if strings.HasPrefix(branch, "-") {
+		return res, fmt.Errorf("invalid branch %q", branch)
+	}
+	remote := "origin/" + branch
+
+	prev, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return res, err
+	}
+	res.previousRev = prev
+
+	if !dryRun {
+		if _, err := runGit(ctx, dir, "fetch", "origin", branch); err != nil {
+			return res, err
+		}
+	}
+	target, err := runGit(ctx, dir, "rev-parse", "--verify", remote+"^{commit}")
+	if err != nil {
+		return res, err
+	}
+	if _, err := runGit(ctx, dir, "merge-base", "--is-ancestor", "HEAD", target); err != nil {
+		return res, fmt.Errorf("%s is not a fast-forward of HEAD: %w", remote, err)
+	}
+	changed, err := runGit(ctx, dir, "diff", "--name-only", prev, target)
+	if err != nil {
+		return res, err
+	}
+	if changed != "" {
+		res.filesChanged = strings.Count(changed, "\n") + 1
+	}
+	if dryRun {
+		res.newRev = target
+		return res, nil
+	}
+
+	if _, err := runGit(ctx, dir, "merge", "--ff-only", target); err != nil {
+		return res, err
+	}
+	res.newRev, err = runGit(ctx, dir, "rev-parse", "HEAD")
+	return res, err
+}
+
+// runGit runs git in dir and returns its trimmed stdout. Prompts for
+// credentials are disabled so a missing credential fails instead of hanging.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
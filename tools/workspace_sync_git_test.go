@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func gitT(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return out
+}
+
+func commitFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	gitT(t, dir, "add", name)
+	gitT(t, dir, "commit", "-q", "-m", "update "+name)
+	return gitT(t, dir, "rev-parse", "HEAD")
+}
+
+func TestSyncGitFastForwards(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com",
+		"GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_CONFIG_GLOBAL": os.DevNull, "GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(k, v)
+	}
+	upstream := t.TempDir()
+	gitT(t, upstream, "init", "-q", "-b", "main")
+	base := commitFile(t, upstream, "a.txt", "one")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	gitT(t, upstream, "clone", "-q", upstream, clone)
+	commitFile(t, upstream, "a.txt", "two")
+	head := commitFile(t, upstream, "b.txt", "new")
+
+	// A dry run only sees what has already been fetched.
+	res, err := syncGit(context.Background(), clone, "", true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if res.previousRev != base || res.newRev != base || res.filesChanged != 0 {
+		t.Fatalf("unexpected dry run %+v", res)
+	}
+
+	res, err = syncGit(context.Background(), clone, "main", false)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if res.previousRev != base || res.newRev != head || res.filesChanged != 2 {
+		t.Fatalf("unexpected sync %+v", res)
+	}
+	if got := gitT(t, clone, "rev-parse", "HEAD"); got != head {
+		t.Fatalf("clone HEAD = %s, want %s", got, head)
+	}
+
+	// Diverged history is refused rather than merged.
+	commitFile(t, clone, "local.txt", "local")
+	commitFile(t, upstream, "c.txt", "upstream")
+	if _, err := syncGit(context.Background(), clone, "main", false); err == nil {
+		t.Fatalf("expected non-fast-forward sync to fail")
+	}
+}
@@ -18,6 +18,8 @@ type WorkspaceTree struct {
 
 type WorkspaceTreeInput struct {
 	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
+	SortBy      string `json:"sortBy,omitempty" jsonschema:"order of files: path (default), size or mtime; directories are always by path"`
+	Desc        bool   `json:"desc,omitempty" jsonschema:"sort files descending, e.g. largest or most recently modified first"`
 }
 
 type WorkspaceTreeOutput struct {
@@ -41,6 +43,7 @@ type WorkspaceFile struct {
 	Size      int64     `json:"size" jsonschema:"file size in bytes"`
 	MTime     time.Time `json:"mtime" jsonschema:"modification time (UTC)"`
 	SHA       string    `json:"sha" jsonschema:"content hash"`
+	MimeType  string    `json:"mimeType,omitempty" jsonschema:"MIME type detected at scan time"`
 }
 
 func (t *WorkspaceTree) List(ctx context.Context, _ *mcp.CallToolRequest, input WorkspaceTreeInput) (*mcp.CallToolResult, WorkspaceTreeOutput, error) {
@@ -51,17 +54,22 @@ func (t *WorkspaceTree) List(ctx context.Context, _ *mcp.CallToolRequest, input
 	if wsID == "" {
 		return nil, WorkspaceTreeOutput{}, fmt.Errorf("workspaceId is required")
 	}
+	orderBy, err := fileOrderBy(input.SortBy, input.Desc)
+	if err != nil {
+		return nil, WorkspaceTreeOutput{}, err
+	}
 
 	type dirRow struct {
 		RelPath string `json:"relpath"`
 		SHA     string `json:"sha"`
 	}
 	type fileRow struct {
-		RelPath string    `json:"relpath"`
-		Lang    string    `json:"lang"`
-		Size    int64     `json:"size"`
-		MTime   time.Time `json:"mtime"`
-		SHA     string    `json:"sha"`
+		RelPath  string    `json:"relpath"`
+		Lang     string    `json:"lang"`
+		Size     int64     `json:"size"`
+		MTime    time.Time `json:"mtime"`
+		SHA      string    `json:"sha"`
+		MimeType string    `json:"mime_type"`
 	}
 
 	const dirQuery = `
@@ -70,11 +78,11 @@ FROM directory
 WHERE ws = type::thing('workspace', $ws_id)
 ORDER BY relpath ASC
 `
-	const fileQuery = `
-SELECT relpath, lang, size, mtime, sha
+	fileQuery := `
+SELECT relpath, lang, size, mtime, sha, mime_type
 FROM file
 WHERE ws = type::thing('workspace', $ws_id)
-ORDER BY relpath ASC
+ORDER BY ` + orderBy + `
 `
 
 	vars := map[string]any{"ws_id": wsID}
@@ -118,12 +126,10 @@ ORDER BY relpath ASC
 			Size:      f.Size,
 			MTime:     f.MTime,
 			SHA:       f.SHA,
+			MimeType:  f.MimeType,
 		}
 		wsFiles = append(wsFiles, entry)
 	}
-	sort.Slice(wsFiles, func(i, j int) bool {
-		return wsFiles[i].RelPath < wsFiles[j].RelPath
-	})
 
 	return nil, WorkspaceTreeOutput{
 		WorkspaceID: wsID,
@@ -2,42 +2,80 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/CryingSurrogate/chaosmith-core/internal/embedder"
+	"github.com/CryingSurrogate/chaosmith-core/internal/metrics"
 	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/surrealdb/surrealdb.go"
 )
 
 type WorkspaceVectorSearch struct {
 	DB       *surreal.Client
 	Embedder *embedder.Client
+	// Transform projects queries embedded with the configured model, as the
+	// indexer does with their stored vectors. Nil leaves them native.
+	Transform *embxform.Transform
+	// CandidateMultiplier sizes the KNN pool as the rows wanted times this;
+	// see Search.
+	CandidateMultiplier int
 }
 
+// defaultCandidateMultiplier applies when a vector search tool's
+// CandidateMultiplier is unset, and maxCandidateMultiplier caps it.
+const (
+	defaultCandidateMultiplier = 10
+	maxCandidateMultiplier     = 100
+)
+
 type WorkspaceVectorSearchInput struct {
-	WorkspaceID string   `json:"workspaceId" jsonschema:"workspace identifier"`
-	Query       string   `json:"query" jsonschema:"natural language query"`
-	TopK        int      `json:"topK,omitempty" jsonschema:"number of results (default 5, max 50)"`
-	ModelID     string   `json:"modelId,omitempty" jsonschema:"vector model slug override"`
-	FileFilter  []string `json:"fileFilter,omitempty" jsonschema:"optional list of file relpaths to include"`
+	WorkspaceID       string     `json:"workspaceId" jsonschema:"workspace identifier"`
+	Query             string     `json:"query,omitempty" jsonschema:"natural language query"`
+	Queries           []string   `json:"queries,omitempty" jsonschema:"several sub-queries fused with Reciprocal Rank Fusion; mutually exclusive with query"`
+	TopK              int        `json:"topK,omitempty" jsonschema:"number of results (default 5, max 50)"`
+	ModelID           string     `json:"modelId,omitempty" jsonschema:"vector model slug override"`
+	FileFilter        []string   `json:"fileFilter,omitempty" jsonschema:"optional list of file relpaths to include"`
+	Cursor            string     `json:"cursor,omitempty" jsonschema:"opaque cursor from a previous nextCursor to fetch the following page"`
+	UseMMR            bool       `json:"useMmr,omitempty" jsonschema:"rerank results with Maximal Marginal Relevance to diversify matches"`
+	MMRLambda         *float64   `json:"mmrLambda,omitempty" jsonschema:"MMR trade-off between relevance (1) and diversity (0); default 0.5"`
+	DedupeThreshold   float64    `json:"dedupeThreshold,omitempty" jsonschema:"drop results more similar than this to a higher-scoring result (0 disables)"`
+	NegativeExamples  []string   `json:"negativeExamples,omitempty" jsonschema:"texts to steer away from; results too similar to any of them are dropped"`
+	NegativeThreshold float64    `json:"negativeThreshold,omitempty" jsonschema:"drop results whose cosine similarity to a negative example exceeds this (default 0.9)"`
+	ModifiedAfter     *time.Time `json:"modifiedAfter,omitempty" jsonschema:"only match chunks embedded at or after this RFC3339 time"`
+	ModifiedBefore    *time.Time `json:"modifiedBefore,omitempty" jsonschema:"only match chunks embedded at or before this RFC3339 time"`
+	ContextLines      int        `json:"contextLines,omitempty" jsonschema:"lines of surrounding file content to return before and after each match (default 0, max 50)"`
+	ExpandToSymbol    bool       `json:"expandToSymbol,omitempty" jsonschema:"also return the indexed symbol (function, type, ...) enclosing each match"`
 }
 
 type WorkspaceVectorSearchOutput struct {
-	Matches []WorkspaceVectorMatch `json:"matches" jsonschema:"ranked vector matches across workspace"`
+	Matches    []WorkspaceVectorMatch `json:"matches" jsonschema:"ranked vector matches across workspace"`
+	NextCursor string                 `json:"nextCursor,omitempty" jsonschema:"cursor for the next page; empty when there are no more results"`
+	Warnings   []string               `json:"warnings,omitempty" jsonschema:"files whose context or symbols could not be read, e.g. because they moved since indexing"`
 }
 
 type WorkspaceVectorMatch struct {
-	Score      float64 `json:"score" jsonschema:"cosine similarity score"`
-	File       string  `json:"file" jsonschema:"file relpath"`
-	Start      int     `json:"start" jsonschema:"chunk start byte"`
-	End        int     `json:"end" jsonschema:"chunk end byte"`
-	TokenCount int     `json:"tokenCount" jsonschema:"chunk token count"`
-	ContentSHA string  `json:"contentSha" jsonschema:"chunk content hash"`
+	Score         float64     `json:"score" jsonschema:"cosine similarity score"`
+	RelPath       string      `json:"file" jsonschema:"file relpath"`
+	Start         int         `json:"start" jsonschema:"chunk start byte"`
+	End           int         `json:"end" jsonschema:"chunk end byte"`
+	TokenCount    int         `json:"tokenCount" jsonschema:"chunk token count"`
+	ContentSHA    string      `json:"contentSha" jsonschema:"chunk content hash"`
+	MMRScore      float64     `json:"mmrScore,omitempty" jsonschema:"marginal relevance score when useMmr is set"`
+	FusedScore    float64     `json:"fusedScore,omitempty" jsonschema:"Reciprocal Rank Fusion score when queries is set"`
+	ContextBefore string      `json:"contextBefore,omitempty" jsonschema:"lines preceding the chunk when contextLines is set"`
+	ContextAfter  string      `json:"contextAfter,omitempty" jsonschema:"lines following the chunk when contextLines is set"`
+	Symbol        *SymbolSpan `json:"symbol,omitempty" jsonschema:"enclosing symbol when expandToSymbol is set; absent when no symbol contains the chunk"`
 }
 
 func (s *WorkspaceVectorSearch) Search(ctx context.Context, _ *mcp.CallToolRequest, input WorkspaceVectorSearchInput) (*mcp.CallToolResult, WorkspaceVectorSearchOutput, error) {
+	metrics.SearchRequests.WithLabelValues("workspace_vector_search").Inc()
 	if s == nil || s.DB == nil || s.Embedder == nil {
 		return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("vector search requires surreal client and embedder")
 	}
@@ -46,9 +84,21 @@ func (s *WorkspaceVectorSearch) Search(ctx context.Context, _ *mcp.CallToolReque
 		return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("workspaceId is required")
 	}
 	query := strings.TrimSpace(input.Query)
-	if query == "" {
+	var queries []string
+	for _, q := range input.Queries {
+		if q = strings.TrimSpace(q); q != "" {
+			queries = append(queries, q)
+		}
+	}
+	switch {
+	case query != "" && len(queries) > 0:
+		return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("query and queries are mutually exclusive")
+	case query == "" && len(queries) == 0:
 		return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("query is required")
+	case len(queries) == 1:
+		query, queries = queries[0], nil
 	}
+	multi := len(queries) > 1
 
 	topK := input.TopK
 	if topK <= 0 {
@@ -73,31 +123,87 @@ func (s *WorkspaceVectorSearch) Search(ctx context.Context, _ *mcp.CallToolReque
 		}
 	}
 
-	includeSet := normalizeFilters(input.FileFilter)
-	includeList := make([]string, 0, len(includeSet))
-	for rel := range includeSet {
-		includeList = append(includeList, rel)
+	lambda := 0.5
+	if input.MMRLambda != nil {
+		if lambda = *input.MMRLambda; lambda < 0 || lambda > 1 {
+			return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("mmrLambda must be between 0 and 1")
+		}
 	}
 
-	// embed the query with the same model as stored vectors
-	qvec, err := s.embedQuery(ctx, modelID, query)
+	var negatives []string
+	for _, n := range input.NegativeExamples {
+		if n = strings.TrimSpace(n); n != "" {
+			negatives = append(negatives, n)
+		}
+	}
+	negThreshold := input.NegativeThreshold
+	if negThreshold <= 0 {
+		negThreshold = 0.9
+	}
+
+	params := map[string]any{}
+	tsFilter, err := timeRangeFilter("ts", input.ModifiedAfter, input.ModifiedBefore, params)
 	if err != nil {
 		return nil, WorkspaceVectorSearchOutput{}, err
 	}
 
-	// println(fmt.Sprintf("Vector: %v", qvec))
+	includeList := includeParam(input.FileFilter)
+
+	var after *vectorCursor
+	if strings.TrimSpace(input.Cursor) != "" {
+		after, err = decodeVectorCursor(input.Cursor)
+		if err != nil {
+			return nil, WorkspaceVectorSearchOutput{}, err
+		}
+		if multi {
+			return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("cursor is not supported with multiple queries")
+		}
+		if input.UseMMR {
+			return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("cursor is not supported with useMmr")
+		}
+	}
+
+	// Deduplication and negative filtering need spare candidates so that topK
+	// survive them, and MMR needs them to have something to diversify with;
+	// otherwise fetch one extra row to learn whether another page exists.
+	dedupe := input.DedupeThreshold > 0
+	filtered := dedupe || len(negatives) > 0
+	limit := topK + 1
+	if filtered || input.UseMMR {
+		limit = topK * 3
+	}
+	// The <|k,COSINE|> operator picks the k nearest chunks across the whole
+	// table; the workspace, model, file and time filters only run on those k
+	// afterwards. The pool is therefore widened by the candidate multiplier,
+	// and must also cover every page already returned.
+	knn := limit
+	cursorFilter := ""
+	if after != nil {
+		knn += after.Seen
+		cursorFilter = "\n  AND (distance > $last_dist OR (distance = $last_dist AND chunk_id > $last_id))"
+	}
+	knn *= candidateMultiplier(s.CandidateMultiplier)
+	// Vectors are only needed for MMR reranking, deduplication and negative
+	// filtering; skip the payload otherwise.
+	vectorField := ""
+	if input.UseMMR || filtered {
+		vectorField = "\n  vector,"
+	}
 
 	// Single KNN query across workspace; Surreal returns cosine distance
 	q := fmt.Sprintf(`
 SELECT * FROM (
     SELECT
+  meta::id(id) AS chunk_id,
   content_sha,
   start,
   end,
-  token_count,
+  token_count,%s
   file,
+  file.relpath AS relpath,
   model,
   ws,
+  ts,
   vector::distance::knn() AS distance
 FROM vector_chunk
 WHERE
@@ -106,49 +212,375 @@ WHERE
 WHERE ws = type::thing('workspace', $ws_id)
   AND model = type::thing('vector_model', $model_id)
   AND (array::len($include) = 0 OR file.relpath IN $include)
-  AND distance != NONE
-ORDER BY distance ASC
+  AND distance != NONE%s%s
+ORDER BY distance ASC, chunk_id ASC
 LIMIT %d;
-`, topK, topK)
+`, vectorField, knn, tsFilter, cursorFilter, limit)
 
-	type row struct {
-		File       string  `json:"file"`
-		Start      int     `json:"start"`
-		End        int     `json:"end"`
-		TokenCount int     `json:"token_count"`
-		ContentSHA string  `json:"content_sha"`
-		Distance   float64 `json:"distance"`
+	params["ws_id"] = wsID
+	params["model_id"] = modelID
+	params["include"] = includeList
+	if after != nil {
+		params["last_dist"] = after.Distance
+		params["last_id"] = after.ChunkID
 	}
 
-	params := map[string]any{
-		"ws_id":    wsID,
-		"model_id": modelID,
-		"qvec":     qvec,
-		"include":  includeList,
+	// Negatives use the same model and client as the query so similarities are
+	// comparable.
+	negVecs := make([][]float32, 0, len(negatives))
+	for _, n := range negatives {
+		vec, err := s.embedQuery(ctx, modelID, n)
+		if err != nil {
+			return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("negative example: %w", err)
+		}
+		negVecs = append(negVecs, vec)
 	}
 
-	queryResults, err := surrealdb.Query[[]row](ctx, s.DB.Db, q, params)
-	if err != nil {
-		return nil, WorkspaceVectorSearchOutput{}, fmt.Errorf("knn query: %w", err)
+	var rows []vectorSearchRow
+	hasMore := false
+	if multi {
+		// Each sub-query gets its own KNN; results are merged by rank.
+		lists := make([][]vectorSearchRow, 0, len(queries))
+		for _, sub := range queries {
+			list, err := s.knn(ctx, modelID, sub, q, params)
+			if err != nil {
+				return nil, WorkspaceVectorSearchOutput{}, err
+			}
+			lists = append(lists, list)
+		}
+		rows = fuseRRF(lists, rrfK)
+	} else {
+		rows, err = s.knn(ctx, modelID, query, q, params)
+		if err != nil {
+			return nil, WorkspaceVectorSearchOutput{}, err
+		}
+		hasMore = len(rows) > topK
 	}
-	if len(*queryResults) == 0 {
-		return nil, WorkspaceVectorSearchOutput{Matches: make([]WorkspaceVectorMatch, 0)}, nil
+
+	keep := make([]int, len(rows))
+	for i := range keep {
+		keep[i] = i
+	}
+	if len(negVecs) > 0 {
+		kept := keep[:0]
+		for _, idx := range keep {
+			if maxSimilarity(rows[idx].Vector, negVecs) <= negThreshold {
+				kept = append(kept, idx)
+			}
+		}
+		keep = kept
+	}
+	if dedupe {
+		vectors := make([][]float32, len(keep))
+		for i, idx := range keep {
+			vectors[i] = rows[idx].Vector
+		}
+		picked := dedupeBySimilarity(vectors, input.DedupeThreshold)
+		for i, p := range picked {
+			picked[i] = keep[p]
+		}
+		keep = picked
+	}
+	if filtered {
+		hasMore = !multi && (len(keep) > topK || len(rows) == limit)
+	}
+	var mmrScores []float64
+	if input.UseMMR {
+		// MMR picks topK from the whole pool rather than reordering the
+		// nearest topK, so it can trade relevance for diversity. Its picks are
+		// not a prefix of the distance order, so there is no next page.
+		keep, mmrScores = mmrPick(rows, keep, lambda)
+		hasMore = false
+	}
+	if len(keep) > topK {
+		keep = keep[:topK]
 	}
 
-	matches := make([]WorkspaceVectorMatch, len((*queryResults)[0].Result))
-	for i, r := range (*queryResults)[0].Result {
+	var nextCursor string
+	if hasMore && len(keep) > 0 {
+		// Seen counts rows consumed from the ranked stream, including duplicates
+		// and negatives dropped before the last match.
+		lastIdx := keep[len(keep)-1]
+		seen := lastIdx + 1
+		if after != nil {
+			seen += after.Seen
+		}
+		last := rows[lastIdx]
+		nextCursor = encodeVectorCursor(vectorCursor{Distance: last.Distance, ChunkID: last.ChunkID, Seen: seen})
+	}
 
+	matches := make([]WorkspaceVectorMatch, len(keep))
+	for i, idx := range keep {
+		r := rows[idx]
 		sim := 1.0 - r.Distance // cosine distance → similarity
 		matches[i] = WorkspaceVectorMatch{
 			Score:      sim,
-			File:       r.File,
+			RelPath:    r.RelPath,
 			Start:      r.Start,
 			End:        r.End,
 			TokenCount: r.TokenCount,
 			ContentSHA: r.ContentSHA,
+			FusedScore: r.FusedScore,
+		}
+		if mmrScores != nil {
+			matches[i].MMRScore = mmrScores[i]
+		}
+	}
+
+	var warnings []string
+	if input.ContextLines > 0 {
+		w, err := s.attachContext(ctx, wsID, matches, min(input.ContextLines, 50))
+		if err != nil {
+			return nil, WorkspaceVectorSearchOutput{}, err
 		}
+		warnings = append(warnings, w...)
 	}
-	return nil, WorkspaceVectorSearchOutput{Matches: matches}, nil
+	if input.ExpandToSymbol {
+		w, err := s.attachSymbols(ctx, wsID, matches)
+		if err != nil {
+			return nil, WorkspaceVectorSearchOutput{}, err
+		}
+		warnings = append(warnings, w...)
+	}
+	return nil, WorkspaceVectorSearchOutput{Matches: matches, NextCursor: nextCursor, Warnings: warnings}, nil
+}
+
+// candidateMultiplier returns m clamped to [1, maxCandidateMultiplier], or
+// the default when m is unset.
+func candidateMultiplier(m int) int {
+	if m <= 0 {
+		return defaultCandidateMultiplier
+	}
+	return min(m, maxCandidateMultiplier)
+}
+
+// mmrPick orders the candidates rows[keep] by Maximal Marginal Relevance and
+// returns their indices into rows along with each one's MMR score.
+func mmrPick(rows []vectorSearchRow, keep []int, lambda float64) ([]int, []float64) {
+	vectors := make([][]float32, len(keep))
+	relevance := make([]float64, len(keep))
+	for i, idx := range keep {
+		vectors[i] = rows[idx].Vector
+		relevance[i] = 1.0 - rows[idx].Distance
+	}
+	order, scores := mmrOrder(vectors, relevance, lambda)
+	picked := make([]int, len(order))
+	for i, o := range order {
+		picked[i] = keep[o]
+	}
+	return picked, scores
+}
+
+// vectorSearchRow is one KNN result. The query also returns the file, model
+// and ws record links for its filters; they are left undecoded. Vector is only
+// populated when MMR, deduplication or negative filtering needs it.
+type vectorSearchRow struct {
+	ChunkID    string    `json:"chunk_id"`
+	RelPath    string    `json:"relpath"`
+	Start      int       `json:"start"`
+	End        int       `json:"end"`
+	TokenCount int       `json:"token_count"`
+	ContentSHA string    `json:"content_sha"`
+	Distance   float64   `json:"distance"`
+	Vector     []float32 `json:"vector"`
+	FusedScore float64   `json:"-"`
+}
+
+// knn embeds query with the stored model and runs the prepared KNN statement q.
+// params is copied so each sub-query binds its own query vector.
+func (s *WorkspaceVectorSearch) knn(ctx context.Context, modelID, query, q string, params map[string]any) ([]vectorSearchRow, error) {
+	// embed the query with the same model as stored vectors
+	qvec, err := s.embedQuery(ctx, modelID, query)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]any, len(params)+1)
+	for k, v := range params {
+		vars[k] = v
+	}
+	vars["qvec"] = qvec
+
+	rows, err := surreal.Query[vectorSearchRow](ctx, s.DB, q, vars)
+	if err != nil {
+		return nil, fmt.Errorf("knn query: %w", err)
+	}
+	return rows, nil
+}
+
+// rrfK is the Reciprocal Rank Fusion constant from Cormack et al.
+const rrfK = 60
+
+// fuseRRF merges ranked result lists with Reciprocal Rank Fusion: each chunk
+// scores the sum of 1/(k+rank) over the lists it appears in. A chunk found by
+// several lists keeps its smallest distance. Rows are returned by descending
+// fused score.
+func fuseRRF(lists [][]vectorSearchRow, k int) []vectorSearchRow {
+	byID := make(map[string]*vectorSearchRow)
+	order := make([]string, 0)
+	for _, list := range lists {
+		for rank, r := range list {
+			score := 1.0 / float64(k+rank+1)
+			if existing, ok := byID[r.ChunkID]; ok {
+				existing.FusedScore += score
+				if r.Distance < existing.Distance {
+					existing.Distance = r.Distance
+				}
+				continue
+			}
+			row := r
+			row.FusedScore = score
+			byID[r.ChunkID] = &row
+			order = append(order, r.ChunkID)
+		}
+	}
+	fused := make([]vectorSearchRow, 0, len(order))
+	for _, id := range order {
+		fused = append(fused, *byID[id])
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].FusedScore != fused[j].FusedScore {
+			return fused[i].FusedScore > fused[j].FusedScore
+		}
+		if fused[i].Distance != fused[j].Distance {
+			return fused[i].Distance < fused[j].Distance
+		}
+		return fused[i].ChunkID < fused[j].ChunkID
+	})
+	return fused
+}
+
+// attachContext fills ContextBefore/ContextAfter for each match. The workspace
+// path is looked up once; see fillContext.
+func (s *WorkspaceVectorSearch) attachContext(ctx context.Context, wsID string, matches []WorkspaceVectorMatch, lines int) ([]string, error) {
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	wsPath, err := lookupWorkspacePath(ctx, s.DB, wsID)
+	if err != nil {
+		return nil, err
+	}
+	return fillContext(wsPath, matches, lines), nil
+}
+
+// fillContext reads each matched file under wsPath at most once and fills the
+// context lines around its matches. A file that cannot be read, e.g. because
+// it moved since indexing, leaves its matches without context and is
+// described in the returned warnings.
+func fillContext(wsPath string, matches []WorkspaceVectorMatch, lines int) []string {
+	var warnings []string
+	files := make(map[string][]byte)
+	for i := range matches {
+		m := &matches[i]
+		data, ok := files[m.RelPath]
+		if !ok {
+			var err error
+			if data, err = readIndexedText(filepath.Join(wsPath, filepath.FromSlash(m.RelPath))); err != nil {
+				warnings = append(warnings, fmt.Sprintf("context for %s unavailable: %v", m.RelPath, err))
+			}
+			files[m.RelPath] = data
+		}
+		m.ContextBefore, m.ContextAfter = contextLines(data, m.Start, m.End, lines)
+	}
+	return warnings
+}
+
+// attachSymbols fills Symbol for each match enclosed by an indexed symbol.
+// Symbols and content are loaded once per file. A file that cannot be read
+// leaves its matches unexpanded and is described in the returned warnings.
+func (s *WorkspaceVectorSearch) attachSymbols(ctx context.Context, wsID string, matches []WorkspaceVectorMatch) ([]string, error) {
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	wsPath, err := lookupWorkspacePath(ctx, s.DB, wsID)
+	if err != nil {
+		return nil, err
+	}
+	type fileSymbols struct {
+		data    []byte
+		symbols []symbolRange
+	}
+	var warnings []string
+	files := make(map[string]fileSymbols)
+	for i := range matches {
+		m := &matches[i]
+		f, ok := files[m.RelPath]
+		if !ok {
+			if f.symbols, err = lookupFileSymbols(ctx, s.DB, wsID, m.RelPath); err != nil {
+				return nil, err
+			}
+			if len(f.symbols) > 0 {
+				if f.data, err = readIndexedText(filepath.Join(wsPath, filepath.FromSlash(m.RelPath))); err != nil {
+					warnings = append(warnings, fmt.Sprintf("symbols for %s unavailable: %v", m.RelPath, err))
+				}
+			}
+			files[m.RelPath] = f
+		}
+		m.Symbol = expandToSymbol(f.data, f.symbols, m.Start, m.End)
+	}
+	return warnings, nil
+}
+
+// contextLines returns up to n whole lines preceding data[start:end] and up to
+// n whole lines following it. The partial lines the chunk starts and ends on
+// are included with the context.
+func contextLines(data []byte, start, end, n int) (string, string) {
+	if n <= 0 || len(data) == 0 {
+		return "", ""
+	}
+	start = max(0, min(start, len(data)))
+	end = max(start, min(end, len(data)))
+
+	from := start
+	for seen := 0; from > 0; from-- {
+		if data[from-1] == '\n' {
+			if seen == n {
+				break
+			}
+			seen++
+		}
+	}
+	to := end
+	seen := 0
+	if end > 0 && data[end-1] == '\n' {
+		seen = 1 // the chunk ended on a line boundary
+	}
+	for ; to < len(data); to++ {
+		if data[to] == '\n' {
+			seen++
+			if seen > n {
+				break
+			}
+		}
+	}
+	return string(data[from:start]), string(data[end:to])
+}
+
+// vectorCursor marks the last match returned on a page. Seen counts all matches
+// returned so far so the next KNN pool can be sized to reach past them.
+type vectorCursor struct {
+	Distance float64 `json:"d"`
+	ChunkID  string  `json:"id"`
+	Seen     int     `json:"n"`
+}
+
+func encodeVectorCursor(c vectorCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeVectorCursor(cursor string) (*vectorCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(cursor))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c vectorCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if c.ChunkID == "" || c.Seen <= 0 {
+		return nil, fmt.Errorf("invalid cursor: missing position")
+	}
+	return &c, nil
 }
 
 func (s *WorkspaceVectorSearch) resolveModel(ctx context.Context, wsID, override string) (string, error) {
@@ -175,22 +607,26 @@ LIMIT 1
 	return rows[0].ModelID, nil
 }
 
+// embedQuery embeds query with the model whose slug is modelID.
 func (s *WorkspaceVectorSearch) embedQuery(ctx context.Context, modelID, query string) ([]float32, error) {
-	if me, ok := any(s.Embedder).(modelAwareEmbedder); ok && modelID != "" {
-		vecs, err := me.EmbedWithModel(ctx, modelID, []string{query})
-		if err == nil && len(vecs) > 0 && len(vecs[0]) > 0 {
-			return vecs[0], nil
-		}
-		// fall through to generic path on error/empty
-	}
-	vecs, err := s.Embedder.Embed(ctx, []string{query})
+	model, err := vectorModelName(ctx, s.DB, s.Embedder, modelID)
 	if err != nil {
-		return nil, fmt.Errorf("embed query: %w", err)
+		return nil, err
 	}
-	if len(vecs) == 0 || len(vecs[0]) == 0 {
-		return nil, fmt.Errorf("embedding returned empty vector")
+	return embedQueryVector(ctx, s.Embedder, s.Transform, model, query)
+}
+
+// includeParam returns the sorted $include list for filters. It is never nil:
+// a nil slice is sent to SurrealDB as NULL rather than [], and the query's
+// array::len($include) = 0 guard only holds for an empty array.
+func includeParam(filters []string) []string {
+	set := normalizeFilters(filters)
+	list := make([]string, 0, len(set))
+	for rel := range set {
+		list = append(list, rel)
 	}
-	return vecs[0], nil
+	sort.Strings(list)
+	return list
 }
 
 func normalizeFilters(filters []string) map[string]struct{} {
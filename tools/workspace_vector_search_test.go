@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	surrealmodels "github.com/surrealdb/surrealdb.go/pkg/models"
+	"github.com/surrealdb/surrealdb.go/surrealcbor"
+)
+
+func TestIncludeParamEncodesEmptyArray(t *testing.T) {
+	codec := surrealcbor.New()
+	for _, filters := range [][]string{nil, {}, {" ", ""}} {
+		include := includeParam(filters)
+		if include == nil {
+			t.Fatalf("includeParam(%q) returned nil", filters)
+		}
+		params := map[string]any{"include": include}
+		js, _ := json.Marshal(params)
+		if string(js) != `{"include":[]}` {
+			t.Fatalf("includeParam(%q) JSON = %s", filters, js)
+		}
+		enc, err := codec.Marshal(params["include"])
+		if err != nil {
+			t.Fatalf("cbor: %v", err)
+		}
+		// 0x80 is an empty CBOR array; a nil slice would encode as null (0xf6).
+		if len(enc) != 1 || enc[0] != 0x80 {
+			t.Fatalf("includeParam(%q) CBOR = %x, want 80", filters, enc)
+		}
+	}
+	if got := includeParam([]string{"b.go", " a.go ", "b.go"}); strings.Join(got, ",") != "a.go,b.go" {
+		t.Fatalf("unexpected include list %v", got)
+	}
+}
+
+func TestVectorSearchRowDecodesRecordLinks(t *testing.T) {
+	codec := surrealcbor.New()
+	// A KNN row as Surreal returns it: file, model and ws are record links.
+	enc, err := codec.Marshal(map[string]any{
+		"chunk_id":    "vec-1",
+		"content_sha": "abc",
+		"start":       10,
+		"end":         20,
+		"token_count": 4,
+		"file":        surrealmodels.NewRecordID("file", "f1"),
+		"relpath":     "src/main.go",
+		"model":       surrealmodels.NewRecordID("vector_model", "nomic"),
+		"ws":          surrealmodels.NewRecordID("workspace", "ws"),
+		"distance":    0.25,
+	})
+	if err != nil {
+		t.Fatalf("cbor: %v", err)
+	}
+	var row vectorSearchRow
+	if err := codec.Unmarshal(enc, &row); err != nil {
+		t.Fatalf("decode row: %v", err)
+	}
+	if row.ChunkID != "vec-1" || row.RelPath != "src/main.go" || row.Start != 10 || row.Distance != 0.25 {
+		t.Fatalf("unexpected row %+v", row)
+	}
+}
+
+func TestVectorCursorRoundTrip(t *testing.T) {
+	in := vectorCursor{Distance: 0.125, ChunkID: "vec-abc", Seen: 10}
+	out, err := decodeVectorCursor(encodeVectorCursor(in))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if *out != in {
+		t.Fatalf("round trip mismatch: got %+v want %+v", *out, in)
+	}
+
+	if _, err := decodeVectorCursor("not-base64!"); err == nil {
+		t.Fatalf("expected error for malformed cursor")
+	}
+	if _, err := decodeVectorCursor(encodeVectorCursor(vectorCursor{})); err == nil {
+		t.Fatalf("expected error for empty cursor position")
+	}
+}
+
+func TestContextLines(t *testing.T) {
+	data := []byte("l1\nl2\nl3\nCHUNK\nl5\nl6\nl7\n")
+	start := strings.Index(string(data), "CHUNK")
+	end := start + len("CHUNK")
+
+	before, after := contextLines(data, start, end, 2)
+	if before != "l2\nl3\n" || after != "\nl5\nl6" {
+		t.Fatalf("unexpected context %q / %q", before, after)
+	}
+
+	before, after = contextLines(data, start, end+1, 1)
+	if before != "l3\n" || after != "l5" {
+		t.Fatalf("unexpected context at line boundary %q / %q", before, after)
+	}
+
+	before, after = contextLines(data, 0, 2, 5)
+	if before != "" || after != "\nl2\nl3\nCHUNK\nl5\nl6" {
+		t.Fatalf("unexpected context at file start %q / %q", before, after)
+	}
+}
+
+func TestFillContextReadsByRelPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := "l1\nCHUNK\nl3\n"
+	if err := os.WriteFile(filepath.Join(dir, "src", "a.go"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(data, "CHUNK")
+	matches := []WorkspaceVectorMatch{
+		{RelPath: "src/a.go", Start: start, End: start + len("CHUNK")},
+		{RelPath: "src/gone.go", Start: 0, End: 4},
+	}
+
+	warnings := fillContext(dir, matches, 1)
+	if matches[0].ContextBefore != "l1\n" || matches[0].ContextAfter != "\nl3" {
+		t.Fatalf("unexpected context %q / %q", matches[0].ContextBefore, matches[0].ContextAfter)
+	}
+	if matches[1].ContextBefore != "" || matches[1].ContextAfter != "" {
+		t.Fatalf("expected no context for a missing file, got %+v", matches[1])
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "src/gone.go") {
+		t.Fatalf("expected one warning naming the missing file, got %v", warnings)
+	}
+}
+
+func TestFuseRRF(t *testing.T) {
+	a := []vectorSearchRow{{ChunkID: "x", Distance: 0.2}, {ChunkID: "y", Distance: 0.3}}
+	b := []vectorSearchRow{{ChunkID: "y", Distance: 0.1}, {ChunkID: "z", Distance: 0.4}}
+	got := fuseRRF([][]vectorSearchRow{a, b}, rrfK)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 fused rows, got %d", len(got))
+	}
+	if got[0].ChunkID != "y" {
+		t.Fatalf("chunk in both lists should rank first, got %q", got[0].ChunkID)
+	}
+	if want := 1.0/62 + 1.0/61; math.Abs(got[0].FusedScore-want) > 1e-12 {
+		t.Fatalf("fused score: got %v want %v", got[0].FusedScore, want)
+	}
+	if got[0].Distance != 0.1 {
+		t.Fatalf("fused row should keep best distance, got %v", got[0].Distance)
+	}
+	if got[1].ChunkID != "x" || got[2].ChunkID != "z" {
+		t.Fatalf("unexpected order: %q, %q", got[1].ChunkID, got[2].ChunkID)
+	}
+}
+
+func TestMMRPickReachesBeyondTopK(t *testing.T) {
+	rows := []vectorSearchRow{
+		{ChunkID: "a", Distance: 0, Vector: []float32{1, 0}},
+		{ChunkID: "a2", Distance: 0.01, Vector: []float32{1, 0.01}},
+		{ChunkID: "skip", Distance: 0.02, Vector: []float32{1, 0.02}},
+		{ChunkID: "b", Distance: 0.3, Vector: []float32{0, 1}},
+	}
+	keep := []int{0, 1, 3} // "skip" was filtered out earlier
+	ids := func(picked []int) []string {
+		var out []string
+		for _, idx := range picked[:2] {
+			out = append(out, rows[idx].ChunkID)
+		}
+		return out
+	}
+
+	picked, scores := mmrPick(rows, keep, 0.5)
+	if got := ids(picked); got[0] != "a" || got[1] != "b" {
+		t.Fatalf("lambda 0.5: expected the distant chunk second, got %v", got)
+	}
+	if len(scores) != len(keep) {
+		t.Fatalf("expected a score per candidate, got %v", scores)
+	}
+	picked, _ = mmrPick(rows, keep, 1)
+	if got := ids(picked); got[0] != "a" || got[1] != "a2" {
+		t.Fatalf("lambda 1: expected pure relevance order, got %v", got)
+	}
+	picked, _ = mmrPick(rows, keep, 0)
+	if got := ids(picked); got[1] != "b" {
+		t.Fatalf("lambda 0: expected pure diversity, got %v", got)
+	}
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/zeebo/blake3"
+)
+
+type WriteWorkspaceFile struct {
+	DB *surreal.Client
+}
+
+type WriteWorkspaceFileInput struct {
+	WorkspaceID string `json:"workspaceId" jsonschema:"workspace identifier"`
+	RelPath     string `json:"relPath" jsonschema:"file path relative to workspace root"`
+	Content     string `json:"content" jsonschema:"text to write"`
+	Mode        string `json:"mode,omitempty" jsonschema:"create (default; fails if the file exists) | overwrite | append"`
+	Encoding    string `json:"encoding,omitempty" jsonschema:"encoding of the written file; only utf-8 (the default) is supported"`
+}
+
+type WriteWorkspaceFileOutput struct {
+	RelPath string `json:"relPath" jsonschema:"file path relative to workspace root"`
+	Size    int64  `json:"size" jsonschema:"file size in bytes after the write"`
+	SHA     string `json:"sha" jsonschema:"content hash after the write"`
+	Indexed bool   `json:"indexed" jsonschema:"true if the file record was updated; new files appear after the next scan"`
+}
+
+func (w *WriteWorkspaceFile) Write(ctx context.Context, _ *mcp.CallToolRequest, input WriteWorkspaceFileInput) (*mcp.CallToolResult, WriteWorkspaceFileOutput, error) {
+	rel := strings.TrimSpace(input.RelPath)
+	out := WriteWorkspaceFileOutput{RelPath: rel}
+	if w == nil || w.DB == nil {
+		return nil, out, fmt.Errorf("surreal client not configured")
+	}
+	wsID := strings.TrimSpace(input.WorkspaceID)
+	if wsID == "" {
+		return nil, out, fmt.Errorf("workspaceId is required")
+	}
+	if rel == "" {
+		return nil, out, fmt.Errorf("relPath is required")
+	}
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return nil, out, errOutsideWorkspace
+	}
+	switch enc := strings.ToLower(strings.TrimSpace(input.Encoding)); enc {
+	case "", "utf-8", "utf8":
+	default:
+		return nil, out, fmt.Errorf("unsupported encoding %q (only utf-8)", input.Encoding)
+	}
+	mode := strings.ToLower(strings.TrimSpace(input.Mode))
+	if mode == "" {
+		mode = "create"
+	}
+	if mode != "create" && mode != "overwrite" && mode != "append" {
+		return nil, out, fmt.Errorf("unsupported mode %q", input.Mode)
+	}
+
+	wsPath, err := lookupWorkspacePath(ctx, w.DB, wsID)
+	if err != nil {
+		return nil, out, err
+	}
+	full := filepath.Join(wsPath, filepath.FromSlash(rel))
+	if err := checkParentInWorkspace(wsPath, full); err != nil {
+		return nil, out, err
+	}
+
+	content := []byte(input.Content)
+	perm := fs.FileMode(0o644)
+	info, err := os.Stat(full)
+	switch {
+	case err == nil && mode == "create":
+		return nil, out, fmt.Errorf("file %s already exists", rel)
+	case err == nil && !info.Mode().IsRegular():
+		return nil, out, fmt.Errorf("%s is not a regular file", rel)
+	case err == nil:
+		perm = info.Mode().Perm()
+		if mode == "append" {
+			existing, err := os.ReadFile(full)
+			if err != nil {
+				return nil, out, fmt.Errorf("read file: %w", err)
+			}
+			content = append(existing, content...)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, out, fmt.Errorf("stat file: %w", err)
+	}
+
+	if err := writeFileAtomic(full, content, perm); err != nil {
+		return nil, out, err
+	}
+	info, err = os.Stat(full)
+	if err != nil {
+		return nil, out, fmt.Errorf("stat file: %w", err)
+	}
+	sum := blake3.Sum256(content)
+	out.Size = info.Size()
+	out.SHA = hex.EncodeToString(sum[:])
+
+	// Files not yet scanned have no record; the next scan picks them up.
+	fileID, err := lookupFileRecordID(ctx, w.DB, wsID, rel)
+	if errors.Is(err, errFileNotIndexed) {
+		return nil, out, nil
+	}
+	if err != nil {
+		return nil, out, fmt.Errorf("file written but its record was not updated: %w", err)
+	}
+	if err := w.DB.MergeRecord(ctx, "file", fileID, map[string]any{
+		"sha":   out.SHA,
+		"size":  out.Size,
+		"mtime": info.ModTime().UTC(),
+	}); err != nil {
+		return nil, out, fmt.Errorf("update file record: %w", err)
+	}
+	out.Indexed = true
+	return nil, out, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers see either the old or the new content.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create parent directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomicReplacesContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "notes.txt")
+
+	if err := writeFileAtomic(path, []byte("first"), 0o600); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("second"), 0o600); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != "second" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temp files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestCheckParentInWorkspace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need elevated privileges on windows")
+	}
+	ws := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(ws, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ws, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(ws, "src"), filepath.Join(ws, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	for rel, wantErr := range map[string]bool{
+		"a.txt":               false,
+		"src/a.txt":           false,
+		"alias/a.txt":         false, // link to a directory inside the workspace
+		"new/dir/a.txt":       false, // created under the workspace
+		"escape/a.txt":        true,
+		"escape/new/dir/a.go": true,
+	} {
+		err := checkParentInWorkspace(ws, filepath.Join(ws, filepath.FromSlash(rel)))
+		if (err != nil) != wantErr {
+			t.Fatalf("%s: err = %v, want error %v", rel, err, wantErr)
+		}
+	}
+}
@@ -3,17 +3,22 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
-	"gonum.org/v1/gonum/mat"
+	"github.com/CryingSurrogate/chaosmith-core/internal/config"
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	"github.com/CryingSurrogate/chaosmith-core/util/embxform"
 )
 
 type embedReq struct {
@@ -27,35 +32,172 @@ type embedResp struct {
 }
 
 func main() {
-	var outPath, model, endpoint string
-	var max int
+	var outPath, model, endpoint, in, cfgPath string
+	var max, dim int
+	var whiten, store bool
 	flag.StringVar(&outPath, "out", "/etc/chaosmith/pca_nomic_v15_768to1024.json", "output path for PCA json")
 	flag.StringVar(&model, "model", "nomic-embed-text-v1.5", "embedding model")
 	flag.StringVar(&endpoint, "endpoint", "http://127.0.0.1:1234/v1/embeddings", "embed endpoint")
+	flag.StringVar(&in, "in", "", "glob or directory of .ndjson (or .ndjson.gz) training files; stdin when empty")
 	flag.IntVar(&max, "max", 50000, "max samples")
+	flag.IntVar(&dim, "dim", 0, "number of components to output, at most the native dimension (default all); match effective_dim")
+	flag.BoolVar(&whiten, "whiten", false, "scale each component by 1/sqrt(its variance) and record the singular values")
+	flag.BoolVar(&store, "store", false, "also upsert the transform into SurrealDB's vector_transform table under its transform_id")
+	flag.StringVar(&cfgPath, "config", "etc/centralmcp.toml", "config file with the SurrealDB connection used by -store")
 	flag.Parse()
+	if dim < 0 {
+		fmt.Fprintln(os.Stderr, "-dim must not be negative")
+		os.Exit(2)
+	}
 
-	texts := readNDJSON(os.Stdin, max)
+	var texts []string
+	if in == "" {
+		texts = readNDJSON(os.Stdin, max)
+	} else {
+		paths, err := inputFiles(in)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if texts, err = readFiles(paths, max); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 	if len(texts) == 0 {
 		fmt.Fprintln(os.Stderr, "no input")
 		os.Exit(1)
 	}
-	X := fetchEmbeddings(endpoint, model, texts)
-	mean, comps := pca(X)
-	// zero-extend comps to 1024 cols
-	ext := make([][]float32, len(comps))
-	for i := range comps {
-		row := make([]float32, 1024)
-		copy(row, comps[i])
-		ext[i] = row
-	}
-	obj := map[string]any{"mean": mean, "components": ext}
-	b, _ := json.Marshal(obj)
+	// Embeddings are folded into the covariance as each batch arrives, so
+	// memory stays O(d²) however many samples are read.
+	var cov *embxform.Covariance
+	fetchEmbeddings(endpoint, model, texts, func(vec []float32) {
+		if cov == nil {
+			if dim > len(vec) {
+				fmt.Fprintf(os.Stderr, "-dim %d exceeds the native dimension %d\n", dim, len(vec))
+				os.Exit(2)
+			}
+			cov = embxform.NewCovariance(len(vec))
+		}
+		if err := cov.Add(vec); err != nil {
+			panic(err)
+		}
+	})
+	if cov == nil {
+		fmt.Fprintln(os.Stderr, "no embeddings returned")
+		os.Exit(1)
+	}
+	if dim == 0 {
+		dim = cov.Dim()
+	}
+	t, err := cov.PCA(dim, whiten)
+	if err != nil {
+		panic(err)
+	}
+	b, err := t.Marshal()
+	if err != nil {
+		panic(err)
+	}
 	if err := os.WriteFile(outPath, b, 0o644); err != nil {
 		panic(err)
 	}
-	// derive id
-	fmt.Println(deriveID(b))
+	id := embxform.DeriveID(b, t.NativeDim(), t.Dim)
+	if store {
+		if err := storeTransform(cfgPath, id, t); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	fmt.Println("transform_id=" + id)
+}
+
+// storeTransform connects with the server's SurrealDB settings and upserts t.
+func storeTransform(cfgPath, id string, t *embxform.Transform) error {
+	cfg, err := config.Load(resolveConfigPath(cfgPath))
+	if err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
+	db, err := surreal.NewClient(cfg.SurrealURL, cfg.SurrealUser, cfg.SurrealPass, cfg.SurrealNS, cfg.SurrealDB, time.Duration(cfg.SurrealConnectTimeoutMS)*time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("surreal client: %w", err)
+	}
+	return embxform.Store(context.Background(), db, id, t)
+}
+
+// resolveConfigPath returns proposed when it exists, else CHAOSMITH_CONFIG,
+// else "" so the config comes entirely from env vars.
+func resolveConfigPath(proposed string) string {
+	if proposed != "" {
+		if _, err := os.Stat(proposed); err == nil {
+			return proposed
+		}
+	}
+	if envPath := os.Getenv("CHAOSMITH_CONFIG"); envPath != "" {
+		if _, err := os.Stat(envPath); err == nil {
+			return envPath
+		}
+	}
+	return ""
+}
+
+// inputFiles expands -in: a directory contributes its .ndjson and .ndjson.gz
+// files, anything else is a glob. Paths are returned sorted.
+func inputFiles(in string) ([]string, error) {
+	var paths []string
+	if info, err := os.Stat(in); err == nil && info.IsDir() {
+		for _, pattern := range []string{"*.ndjson", "*.ndjson.gz"} {
+			matches, _ := filepath.Glob(filepath.Join(in, pattern))
+			paths = append(paths, matches...)
+		}
+	} else {
+		matches, err := filepath.Glob(in)
+		if err != nil {
+			return nil, fmt.Errorf("-in %q: %w", in, err)
+		}
+		paths = matches
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("-in %q matched no files", in)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readFiles concatenates the texts of paths in order, stopping at limit.
+func readFiles(paths []string, limit int) ([]string, error) {
+	var out []string
+	for _, path := range paths {
+		left := 0
+		if limit > 0 {
+			if left = limit - len(out); left <= 0 {
+				break
+			}
+		}
+		texts, err := readFile(path, left)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, texts...)
+	}
+	return out, nil
+}
+
+func readFile(path string, limit int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return readNDJSON(r, limit), nil
 }
 
 func readNDJSON(r io.Reader, max int) []string {
@@ -75,9 +217,9 @@ func readNDJSON(r io.Reader, max int) []string {
 	return out
 }
 
-func fetchEmbeddings(url, model string, texts []string) [][]float32 {
+// fetchEmbeddings embeds texts in batches and passes each vector to fn.
+func fetchEmbeddings(url, model string, texts []string, fn func([]float32)) {
 	const batch = 128
-	var out [][]float32
 	ctx := context.Background()
 	for i := 0; i < len(texts); i += batch {
 		j := i + batch
@@ -92,64 +234,18 @@ func fetchEmbeddings(url, model string, texts []string) [][]float32 {
 		if err != nil {
 			panic(err)
 		}
-		defer resp.Body.Close()
 		if resp.StatusCode/100 != 2 {
+			resp.Body.Close()
 			panic(fmt.Sprintf("embed http %d", resp.StatusCode))
 		}
 		var er embedResp
-		if err := json.NewDecoder(resp.Body).Decode(&er); err != nil {
+		err = json.NewDecoder(resp.Body).Decode(&er)
+		resp.Body.Close()
+		if err != nil {
 			panic(err)
 		}
 		for _, d := range er.Data {
-			out = append(out, d.Embedding)
+			fn(d.Embedding)
 		}
 	}
-	return out
-}
-
-func pca(X [][]float32) ([]float32, [][]float32) {
-	n := len(X)
-	if n == 0 {
-		return nil, nil
-	}
-	d := len(X[0])
-	// compute mean
-	mean := make([]float32, d)
-	for _, row := range X {
-		for i, v := range row {
-			mean[i] += v
-		}
-	}
-	for i := range mean {
-		mean[i] /= float32(n)
-	}
-	// center matrix
-	M := mat.NewDense(n, d, nil)
-	for r := 0; r < n; r++ {
-		for c := 0; c < d; c++ {
-			M.Set(r, c, float64(X[r][c]-mean[c]))
-		}
-	}
-	var svd mat.SVD
-	ok := svd.Factorize(M, mat.SVDThin)
-	if !ok {
-		panic("svd failed")
-	}
-	var v mat.Dense
-	svd.VTo(&v)
-	vm := v.RawMatrix()
-	cols := vm.Cols
-	comps := make([][]float32, d)
-	for i := 0; i < d; i++ {
-		comps[i] = make([]float32, d)
-		for j := 0; j < cols; j++ {
-			comps[i][j] = float32(v.At(i, j))
-		}
-	}
-	return mean, comps
-}
-
-func deriveID(b []byte) string {
-	sum := sha256.Sum256(b)
-	return "transform_id=pca-nomic-v1.5-768to1024@" + hex.EncodeToString(sum[:4])
 }
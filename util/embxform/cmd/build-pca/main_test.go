@@ -0,0 +1,52 @@
+package main
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadFilesConcatenatesUpToMax(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.ndjson"), []byte("{\"text\":\"one\"}\n{\"text\":\"two\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "b.ndjson.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte("{\"text\":\"three\"}\n{\"other\":1}\n{\"text\":\"four\"}\n"))
+	gz.Close()
+	f.Close()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("{\"text\":\"skip\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := inputFiles(dir)
+	if err != nil {
+		t.Fatalf("inputFiles: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected the two ndjson files, got %v", paths)
+	}
+	texts, err := readFiles(paths, 0)
+	if err != nil {
+		t.Fatalf("readFiles: %v", err)
+	}
+	if want := []string{"one", "two", "three", "four"}; !slices.Equal(texts, want) {
+		t.Fatalf("texts = %q, want %q", texts, want)
+	}
+	if texts, _ := readFiles(paths, 3); len(texts) != 3 {
+		t.Fatalf("expected max to stop at 3 texts, got %q", texts)
+	}
+
+	if paths, err := inputFiles(filepath.Join(dir, "a.*")); err != nil || len(paths) != 1 {
+		t.Fatalf("glob: %v %v", paths, err)
+	}
+	if _, err := inputFiles(filepath.Join(dir, "*.missing")); err == nil {
+		t.Fatalf("expected an error when nothing matches")
+	}
+}
@@ -0,0 +1,188 @@
+// Package embxform builds and applies PCA transforms that project embeddings
+// from their native dimension down to a smaller effective dimension.
+package embxform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Transform is a PCA projection as written by build-pca. Components[i][j] is
+// coordinate i of component j, so it has one row per native dimension and
+// one column per output dimension. When Whiten is set the components are
+// already scaled by 1/sqrt(variance), so Apply needs no extra step.
+type Transform struct {
+	Mean           []float32   `json:"mean"`
+	Components     [][]float32 `json:"components"`
+	Dim            int         `json:"dim,omitempty"`
+	Whiten         bool        `json:"whiten,omitempty"`
+	SingularValues []float64   `json:"singular_values,omitempty"`
+}
+
+// LoadTransform reads and validates a transform file. Files written before
+// dim was recorded take it from the component rows.
+func LoadTransform(path string) (*Transform, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read transform: %w", err)
+	}
+	var t Transform
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("decode transform %s: %w", path, err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+func (t *Transform) validate() error {
+	if len(t.Mean) == 0 {
+		return fmt.Errorf("mean is empty")
+	}
+	if len(t.Components) != len(t.Mean) {
+		return fmt.Errorf("components has %d rows, want one per mean entry (%d)", len(t.Components), len(t.Mean))
+	}
+	if t.Dim == 0 {
+		t.Dim = len(t.Components[0])
+	}
+	for i, row := range t.Components {
+		if len(row) != t.Dim {
+			return fmt.Errorf("component row %d has %d columns, want %d", i, len(row), t.Dim)
+		}
+	}
+	return nil
+}
+
+// NativeDim is the dimension of the vectors Apply accepts.
+func (t *Transform) NativeDim() int {
+	return len(t.Mean)
+}
+
+// Apply centres vec on the mean and projects it onto the components. It
+// returns nil when vec does not have the native dimension.
+func (t *Transform) Apply(vec []float32) []float32 {
+	if len(vec) != len(t.Mean) {
+		return nil
+	}
+	out := make([]float64, t.Dim)
+	for i, v := range vec {
+		c := float64(v - t.Mean[i])
+		for j, w := range t.Components[i] {
+			out[j] += c * float64(w)
+		}
+	}
+	res := make([]float32, t.Dim)
+	for j, v := range out {
+		res[j] = float32(v)
+	}
+	return res
+}
+
+// Marshal encodes t in the transform file format.
+func (t *Transform) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// DeriveID returns the transform_id for an encoded transform: its native and
+// output dimensions plus a short hash of the file contents.
+func DeriveID(data []byte, native, dim int) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("pca-nomic-v1.5-%dto%d@", native, dim) + hex.EncodeToString(sum[:4])
+}
+
+// Covariance accumulates the mean and scatter matrix of a stream of vectors
+// with Welford's update, so its state is O(d²) however many vectors are added.
+type Covariance struct {
+	n     int
+	mean  []float64
+	m2    *mat.SymDense // sum of outer products of deviations from the mean
+	delta *mat.VecDense
+}
+
+// NewCovariance returns an empty accumulator for d-dimensional vectors.
+func NewCovariance(d int) *Covariance {
+	return &Covariance{
+		mean:  make([]float64, d),
+		m2:    mat.NewSymDense(d, nil),
+		delta: mat.NewVecDense(d, nil),
+	}
+}
+
+// Dim is the dimension of the vectors being accumulated.
+func (c *Covariance) Dim() int { return len(c.mean) }
+
+// Count is the number of vectors added so far.
+func (c *Covariance) Count() int { return c.n }
+
+// Add folds x into the running mean and scatter matrix.
+func (c *Covariance) Add(x []float32) error {
+	if len(x) != len(c.mean) {
+		return fmt.Errorf("vector has %d dims, expected %d", len(x), len(c.mean))
+	}
+	c.n++
+	for i, v := range x {
+		c.delta.SetVec(i, float64(v)-c.mean[i])
+		c.mean[i] += c.delta.AtVec(i) / float64(c.n)
+	}
+	// m2 += delta ⊗ (x - newMean), which equals (n-1)/n · delta ⊗ delta.
+	c.m2.SymRankOne(c.m2, float64(c.n-1)/float64(c.n), c.delta)
+	return nil
+}
+
+// PCA returns the transform keeping the first k principal components, ordered
+// by decreasing variance. Columns past the sample count are zero. With whiten
+// set each component is divided by the standard deviation of the samples
+// along it, so projected coordinates have unit variance; components with no
+// variance are zeroed rather than blown up.
+func (c *Covariance) PCA(k int, whiten bool) (*Transform, error) {
+	d := len(c.mean)
+	if k <= 0 || k > d {
+		return nil, fmt.Errorf("dim %d must be between 1 and the native dimension %d", k, d)
+	}
+	if c.n == 0 {
+		return nil, fmt.Errorf("no vectors added")
+	}
+	var eig mat.EigenSym
+	if ok := eig.Factorize(c.m2, true); !ok {
+		return nil, fmt.Errorf("eigendecomposition failed")
+	}
+	var v mat.Dense
+	eig.VectorsTo(&v)
+	values := eig.Values(nil)
+
+	t := &Transform{Mean: make([]float32, d), Components: make([][]float32, d), Dim: k, Whiten: whiten}
+	for i, m := range c.mean {
+		t.Mean[i] = float32(m)
+	}
+	// Eigenvalues come in ascending order; components are wanted descending.
+	cols := min(c.n, k)
+	sv := make([]float64, k)
+	scale := make([]float64, k)
+	for j := 0; j < cols; j++ {
+		sv[j] = math.Sqrt(max(0, values[d-1-j]))
+		scale[j] = 1
+		if whiten {
+			scale[j] = 0
+			if std := sv[j] / math.Sqrt(float64(max(c.n-1, 1))); std > 1e-12 {
+				scale[j] = 1 / std
+			}
+		}
+	}
+	for i := 0; i < d; i++ {
+		t.Components[i] = make([]float32, k)
+		for j := 0; j < cols; j++ {
+			t.Components[i][j] = float32(v.At(i, d-1-j) * scale[j])
+		}
+	}
+	if whiten {
+		t.SingularValues = sv
+	}
+	return t, nil
+}
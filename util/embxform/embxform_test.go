@@ -0,0 +1,232 @@
+package embxform
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// batchPCA is the previous in-memory implementation: an SVD of the centred
+// sample matrix.
+func batchPCA(X [][]float32) ([]float32, [][]float32) {
+	n, d := len(X), len(X[0])
+	mean := make([]float32, d)
+	for _, row := range X {
+		for i, v := range row {
+			mean[i] += v
+		}
+	}
+	for i := range mean {
+		mean[i] /= float32(n)
+	}
+	M := mat.NewDense(n, d, nil)
+	for r := 0; r < n; r++ {
+		for c := 0; c < d; c++ {
+			M.Set(r, c, float64(X[r][c]-mean[c]))
+		}
+	}
+	var svd mat.SVD
+	if !svd.Factorize(M, mat.SVDThin) {
+		panic("svd failed")
+	}
+	var v mat.Dense
+	svd.VTo(&v)
+	cols := v.RawMatrix().Cols
+	comps := make([][]float32, d)
+	for i := 0; i < d; i++ {
+		comps[i] = make([]float32, d)
+		for j := 0; j < cols; j++ {
+			comps[i][j] = float32(v.At(i, j))
+		}
+	}
+	return mean, comps
+}
+
+func TestStreamingPCAMatchesBatch(t *testing.T) {
+	const n, d = 400, 8
+	rng := rand.New(rand.NewSource(1))
+	// Distinct variances per axis, then a fixed mixing so the components are
+	// not just the coordinate axes.
+	mix := mat.NewDense(d, d, nil)
+	for i := 0; i < d; i++ {
+		for j := 0; j < d; j++ {
+			mix.Set(i, j, rng.NormFloat64())
+		}
+	}
+	X := make([][]float32, n)
+	for r := range X {
+		z := mat.NewVecDense(d, nil)
+		for i := 0; i < d; i++ {
+			z.SetVec(i, rng.NormFloat64()*float64(d-i)+3)
+		}
+		var x mat.VecDense
+		x.MulVec(mix, z)
+		X[r] = make([]float32, d)
+		for i := range X[r] {
+			X[r][i] = float32(x.AtVec(i))
+		}
+	}
+
+	wantMean, wantComps := batchPCA(X)
+	cov := NewCovariance(d)
+	for _, row := range X {
+		if err := cov.Add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tr, err := cov.PCA(d, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotMean, gotComps := tr.Mean, tr.Components
+
+	for i := range wantMean {
+		if math.Abs(float64(gotMean[i]-wantMean[i])) > 1e-3 {
+			t.Fatalf("mean[%d] = %v, want %v", i, gotMean[i], wantMean[i])
+		}
+	}
+	// Components are unique up to sign, so compare |cos| between columns.
+	for j := 0; j < d; j++ {
+		var dot, a, b float64
+		for i := 0; i < d; i++ {
+			g, w := float64(gotComps[i][j]), float64(wantComps[i][j])
+			dot += g * w
+			a += g * g
+			b += w * w
+		}
+		if cos := math.Abs(dot) / math.Sqrt(a*b); cos < 0.999 {
+			t.Fatalf("component %d differs from batch: |cos| = %v", j, cos)
+		}
+	}
+}
+
+func TestPCAKeepsRequestedComponents(t *testing.T) {
+	cov := NewCovariance(4)
+	for _, row := range [][]float32{{1, 2, 0, 0}, {2, 4, 1, 0}, {3, 6, 0, 1}, {4, 8, 1, 1}} {
+		if err := cov.Add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tr, err := cov.PCA(2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comps := tr.Components
+	if len(comps) != 4 {
+		t.Fatalf("expected a row per native dimension, got %d", len(comps))
+	}
+	for i, row := range comps {
+		if len(row) != 2 {
+			t.Fatalf("row %d has %d components, want 2", i, len(row))
+		}
+	}
+}
+
+func TestWhitenedProjectionHasUnitVariance(t *testing.T) {
+	const n, d = 500, 3
+	rng := rand.New(rand.NewSource(2))
+	X := make([][]float32, n)
+	cov := NewCovariance(d)
+	for r := range X {
+		X[r] = []float32{float32(rng.NormFloat64() * 10), float32(rng.NormFloat64() * 3), float32(rng.NormFloat64())}
+		if err := cov.Add(X[r]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tr, err := cov.PCA(d, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Whiten || len(tr.SingularValues) != d {
+		t.Fatalf("expected whiten and %d singular values, got %+v", d, tr.SingularValues)
+	}
+
+	for j := 0; j < d; j++ {
+		var sum, sumSq float64
+		for _, x := range X {
+			p := float64(tr.Apply(x)[j])
+			sum += p
+			sumSq += p * p
+		}
+		variance := (sumSq - sum*sum/n) / (n - 1)
+		if math.Abs(variance-1) > 1e-3 {
+			t.Fatalf("component %d: projected variance %v, want 1", j, variance)
+		}
+	}
+}
+
+func TestLoadTransformAndApply(t *testing.T) {
+	tr := &Transform{
+		Mean:       []float32{1, 1, 1},
+		Components: [][]float32{{1, 0}, {0, 1}, {0, 0}},
+		Dim:        2,
+	}
+	data, err := tr.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "pca.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadTransform(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	got := loaded.Apply([]float32{3, 0, 7})
+	if len(got) != 2 || got[0] != 2 || got[1] != -1 {
+		t.Fatalf("Apply = %v, want [2 -1]", got)
+	}
+	if loaded.Apply([]float32{1, 2}) != nil {
+		t.Fatalf("expected nil for a vector of the wrong dimension")
+	}
+
+	again, _ := loaded.Marshal()
+	if !bytes.Equal(data, again) {
+		t.Fatalf("round trip changed the encoding")
+	}
+	id := DeriveID(data, 3, 2)
+	if id != DeriveID(again, 3, 2) || !strings.HasPrefix(id, "pca-nomic-v1.5-3to2@") {
+		t.Fatalf("unexpected or unstable id %q", id)
+	}
+	if DeriveID([]byte("other"), 3, 2) == id {
+		t.Fatalf("different contents share id %q", id)
+	}
+}
+
+func TestLoadTransformRejectsMismatchedShape(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pca.json")
+	if err := os.WriteFile(path, []byte(`{"mean":[0,0],"components":[[1,0],[0]]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTransform(path); err == nil {
+		t.Fatalf("expected an error for ragged components")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	for _, id := range []string{"", "none", "NONE"} {
+		if tr, err := Resolve(ctx, "", nil, id); tr != nil || err != nil {
+			t.Fatalf("Resolve(%q) = %v, %v; want no transform", id, tr, err)
+		}
+	}
+	if _, err := Resolve(ctx, "", nil, "pca-test@abcd"); err == nil {
+		t.Fatalf("expected an error for a transform id without a database")
+	}
+	path := filepath.Join(t.TempDir(), "pca.json")
+	if err := os.WriteFile(path, []byte(`{"mean":[0,0],"components":[[1],[0]]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := Resolve(ctx, path, nil, "none")
+	if err != nil || tr == nil || tr.Dim != 1 {
+		t.Fatalf("Resolve(path) = %v, %v; want the file's transform", tr, err)
+	}
+}
@@ -0,0 +1,67 @@
+package embxform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CryingSurrogate/chaosmith-core/internal/surreal"
+	surrealmodels "github.com/surrealdb/surrealdb.go/pkg/models"
+)
+
+// Store upserts t as the vector_transform record named by its transform id,
+// so every node can load it from the database instead of a local file.
+func Store(ctx context.Context, db *surreal.Client, id string, t *Transform) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return fmt.Errorf("transform id is required")
+	}
+	var sv any = surrealmodels.None
+	if len(t.SingularValues) > 0 {
+		sv = t.SingularValues
+	}
+	if err := db.UpsertRecord(ctx, "vector_transform", id, map[string]any{
+		"native_dim":      t.NativeDim(),
+		"dim":             t.Dim,
+		"whiten":          t.Whiten,
+		"mean":            t.Mean,
+		"components":      t.Components,
+		"singular_values": sv,
+		"ts":              time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("store transform %s: %w", id, err)
+	}
+	return nil
+}
+
+// LoadFromDB reads the vector_transform record id.
+func LoadFromDB(ctx context.Context, db *surreal.Client, id string) (*Transform, error) {
+	t, err := surreal.SelectRecord[Transform](ctx, db, "vector_transform", id)
+	if err != nil {
+		return nil, fmt.Errorf("load transform %s: %w", id, err)
+	}
+	if t == nil {
+		return nil, fmt.Errorf("transform %s not found", id)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("transform %s: %w", id, err)
+	}
+	return t, nil
+}
+
+// Resolve loads the transform from path when one is configured and otherwise
+// from the database by transform id. It returns nil when neither is set or id
+// is "none", meaning vectors are stored as the embedder returns them.
+func Resolve(ctx context.Context, path string, db *surreal.Client, id string) (*Transform, error) {
+	if path = strings.TrimSpace(path); path != "" {
+		return LoadTransform(path)
+	}
+	if id = strings.TrimSpace(id); id == "" || strings.EqualFold(id, "none") {
+		return nil, nil
+	}
+	if db == nil {
+		return nil, fmt.Errorf("no transform file configured and no database to load %s from", id)
+	}
+	return LoadFromDB(ctx, db, id)
+}